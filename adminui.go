@@ -0,0 +1,196 @@
+package mysqlbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+)
+
+const (
+	// adminerImage is the Docker image of the admin UI container.
+	adminerImage = "adminer:4"
+
+	// adminerPort is the container port of the Adminer web server.
+	adminerPort = "8080/tcp"
+
+	// adminUIStartTimeout is the maximum time to wait for the Adminer web server to respond.
+	adminUIStartTimeout = 30 * time.Second
+)
+
+// adminUI is an Adminer container started by StartAdminUI().
+type adminUI struct {
+	containerID string
+
+	// networkID is the ID of the network created for the admin UI, or a blank string if the box is on Config.Network.
+	networkID string
+
+	url string
+}
+
+// StartAdminUI starts an Adminer container connected to the MySQL container and returns the URL of its web page,
+// which is also printed with Config.Logger. The login form is filled in with the server, root user, and Database,
+// so only the root password has to be entered. The containers share Config.Network if it is set, or a network
+// created for the admin UI otherwise. Calling StartAdminUI() again returns the URL of the running admin UI. The
+// container is removed by Stop().
+func (b *MySQLBox) StartAdminUI(ctx context.Context) (string, error) {
+	if b == nil {
+		return "", errors.New("mysqlbox is nil")
+	}
+
+	b.adminUIMu.Lock()
+	defer b.adminUIMu.Unlock()
+
+	if b.adminUI != nil {
+		return b.adminUI.url, nil
+	}
+
+	ui, err := b.startAdminUI(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error starting admin UI: %w", err)
+	}
+	b.adminUI = ui
+
+	b.logger.Printf("Adminer is running at %s", ui.url)
+
+	return ui.url, nil
+}
+
+// MustStartAdminUI starts an Adminer container connected to the MySQL container and returns the URL of its web page.
+func (b *MySQLBox) MustStartAdminUI(ctx context.Context) string {
+	u, err := b.StartAdminUI(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	return u
+}
+
+// startAdminUI connects the MySQL container to a network shared with a new Adminer container, starts Adminer, and
+// waits for its web server to respond.
+func (b *MySQLBox) startAdminUI(ctx context.Context) (*adminUI, error) {
+	ui := &adminUI{}
+
+	networkName := b.network
+	host := b.containerName
+	if len(b.networkAliases) > 0 {
+		host = b.networkAliases[0]
+	}
+
+	if networkName == "" {
+		networkName = b.containerName + "-admin"
+		created, err := b.cli.NetworkCreate(ctx, networkName, types.NetworkCreate{
+			CheckDuplicate: true,
+			Labels:         containerLabels(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error creating network: %w", err)
+		}
+		ui.networkID = created.ID
+
+		err = b.cli.NetworkConnect(ctx, ui.networkID, b.containerID, &network.EndpointSettings{})
+		if err != nil {
+			b.stopAdminUI(ui)
+			return nil, fmt.Errorf("error connecting container to network: %w", err)
+		}
+	}
+
+	cfg := &container.Config{
+		Image:        adminerImage,
+		Env:          []string{"ADMINER_DEFAULT_SERVER=" + host},
+		ExposedPorts: nat.PortSet{adminerPort: {}},
+		Labels:       containerLabels(),
+	}
+	hostCfg := &container.HostConfig{
+		AutoRemove:   true,
+		NetworkMode:  container.NetworkMode(networkName),
+		PortBindings: nat.PortMap{adminerPort: {{HostIP: b.bindIP}}},
+	}
+
+	id, err := b.createContainer(ctx, cfg, hostCfg, nil)
+	if err != nil {
+		b.stopAdminUI(ui)
+		return nil, err
+	}
+	ui.containerID = id
+
+	err = b.retry.startContainer(ctx, b.cli, id)
+	if err != nil {
+		b.stopAdminUI(ui)
+		return nil, err
+	}
+
+	port, err := containerHostPort(ctx, b.cli, id, adminerPort)
+	if err != nil {
+		b.stopAdminUI(ui)
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("server", host)
+	query.Set("username", "root")
+	query.Set("db", b.databaseName)
+	ui.url = fmt.Sprintf("http://%s/?%s", net.JoinHostPort(b.host, strconv.Itoa(port)), query.Encode())
+
+	err = waitForHTTP(ctx, ui.url, adminUIStartTimeout)
+	if err != nil {
+		b.stopAdminUI(ui)
+		return nil, err
+	}
+
+	return ui, nil
+}
+
+// stopAdminUI removes the Adminer container and the network created for it.
+func (b *MySQLBox) stopAdminUI(ui *adminUI) {
+	ctx := context.Background()
+
+	if ui.containerID != "" {
+		_ = b.cli.ContainerRemove(ctx, ui.containerID, types.ContainerRemoveOptions{Force: true})
+	}
+
+	if ui.networkID != "" {
+		_ = b.cli.NetworkDisconnect(ctx, ui.networkID, b.containerID, true)
+		_ = b.cli.NetworkRemove(ctx, ui.networkID)
+	}
+}
+
+// waitForHTTP waits for a GET request of the URL to succeed.
+func waitForHTTP(ctx context.Context, u string, timeout time.Duration) error {
+	client := &http.Client{Timeout: time.Second}
+	deadline := time.Now().Add(timeout)
+
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.Do(req)
+		if err == nil {
+			_ = resp.Body.Close()
+			if resp.StatusCode < http.StatusInternalServerError {
+				return nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return phaseTimeout("admin UI start", timeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(waitBetweenPings):
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package mysqlbox
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AttachConfig contains settings for attaching to an existing MySQL container with Attach().
+type AttachConfig struct {
+	// Database specifies the name of the database used by the box. If blank, it defaults to the value of the
+	// MYSQL_DATABASE environment variable of the container, or "testing" if it is not set.
+	Database string
+
+	// RootPassword specifies the password of the MySQL root user. If blank, it defaults to the value of the
+	// MYSQL_ROOT_PASSWORD environment variable of the container.
+	RootPassword string
+
+	// DoNotCleanTables specifies a list of MySQL tables in Database that will not be cleaned when CleanAllTables()
+	// is called.
+	DoNotCleanTables []string
+
+	// CleanWorkers specifies the number of connections used to truncate tables concurrently when CleanAllTables()
+	// is called. The default is 4.
+	CleanWorkers int
+
+	// ReadyTimeout is the maximum time to wait for MySQL to accept connections. The default is 90 seconds.
+	ReadyTimeout time.Duration
+
+	// Logger receives the messages of the box. If nil, messages are printed to stderr.
+	Logger Logger
+
+	// InstrumentSQL is an optional function that wraps the driver connector of the DBs returned by DB() and
+	// ConnectDB().
+	InstrumentSQL func(driver.Connector) driver.Connector
+}
+
+// Attach returns a MySQLBox for an already running MySQL container that was not started by Start(), e.g. a
+// container started by Docker Compose. The container is found by name or ID, and its published MySQL port and
+// environment variables are used to connect to the server. The returned box supports the same operations as a
+// started box, except that Stop() does not stop the container. Attach() waits until MySQL accepts connections.
+func Attach(ctx context.Context, containerNameOrID string, c *AttachConfig) (*MySQLBox, error) {
+	if c == nil {
+		c = &AttachConfig{}
+	}
+
+	cli, err := newDockerClient()
+	if err != nil {
+		return nil, err
+	}
+
+	cr, err := cli.ContainerInspect(ctx, containerNameOrID)
+	if err != nil {
+		return nil, fmt.Errorf("error inspecting container: %w", err)
+	}
+
+	if cr.State == nil || !cr.State.Running {
+		return nil, fmt.Errorf("container %s is not running", containerNameOrID)
+	}
+
+	port, err := containerHostPort(ctx, cli, cr.ID, "3306/tcp")
+	if err != nil {
+		return nil, fmt.Errorf("MySQL port is not published: %w", err)
+	}
+
+	// The X Protocol port is optional.
+	xPort, _ := containerHostPort(ctx, cli, cr.ID, "33060/tcp")
+	adminHostPort, _ := containerHostPort(ctx, cli, cr.ID, adminPort)
+
+	env := map[string]string{}
+	if cr.Config != nil {
+		env = containerEnv(cr.Config.Env)
+	}
+
+	database := c.Database
+	if database == "" {
+		database = env["MYSQL_DATABASE"]
+	}
+	if database == "" {
+		database = "testing"
+	}
+
+	rootPassword := c.RootPassword
+	if rootPassword == "" {
+		rootPassword = env["MYSQL_ROOT_PASSWORD"]
+	}
+
+	cleanWorkers := c.CleanWorkers
+	if cleanWorkers <= 0 {
+		cleanWorkers = defaultCleanWorkers
+	}
+
+	readyTimeout := c.ReadyTimeout
+	if readyTimeout == 0 {
+		readyTimeout = startTimeout
+	}
+
+	logger := c.Logger
+	if logger == nil {
+		logger = stderrLogger{}
+	}
+
+	db, dsn, err := connectDB(defaultBindIP, port, database, rootPassword, "", c.InstrumentSQL)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &MySQLBox{
+		db:               db,
+		dsn:              dsn,
+		rootPassword:     rootPassword,
+		host:             defaultBindIP,
+		bindIP:           defaultBindIP,
+		port:             port,
+		xPort:            xPort,
+		adminPort:        adminHostPort,
+		cli:              cli,
+		containerID:      cr.ID,
+		containerName:    strings.TrimPrefix(cr.Name, "/"),
+		attached:         true,
+		instrumentSQL:    c.InstrumentSQL,
+		databaseName:     database,
+		doNotCleanTables: c.DoNotCleanTables,
+		cleanWorkers:     cleanWorkers,
+		logger:           logger,
+		pull:             pullConfig{logger: logger, output: defaultPullOutput(logger), retries: defaultPullRetries},
+		retry:            dockerRetry{logger: logger, retries: defaultDockerRetries},
+	}
+
+	err = b.waitForDB(readyTimeout, nil)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// MustAttach is the same as Attach() but panics instead of returning an error.
+func MustAttach(ctx context.Context, containerNameOrID string, c *AttachConfig) *MySQLBox {
+	b, err := Attach(ctx, containerNameOrID, c)
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
+
+// containerEnv returns the container environment variables by name.
+func containerEnv(vars []string) map[string]string {
+	env := make(map[string]string, len(vars))
+	for _, v := range vars {
+		name, value, _ := strings.Cut(v, "=")
+		env[name] = value
+	}
+
+	return env
+}
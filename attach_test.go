@@ -0,0 +1,18 @@
+package mysqlbox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainerEnv(t *testing.T) {
+	env := containerEnv([]string{"MYSQL_DATABASE=app", "MYSQL_ROOT_PASSWORD=a=b", "EMPTY=", "NOVALUE"})
+
+	require.Equal(t, map[string]string{
+		"MYSQL_DATABASE":      "app",
+		"MYSQL_ROOT_PASSWORD": "a=b",
+		"EMPTY":               "",
+		"NOVALUE":             "",
+	}, env)
+}
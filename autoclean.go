@@ -0,0 +1,200 @@
+package mysqlbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// maxLeftoverRows is the number of rows of each table shown when Config.VerifyEmptyTables finds leftover rows.
+const maxLeftoverRows = 5
+
+// leftoverTable is a table of the Database that has rows at the start of a test.
+type leftoverTable struct {
+	name    string
+	count   int64
+	columns []string
+	rows    [][]sql.NullString
+}
+
+// AutoClean registers a cleanup function that truncates the tables of the Database that have rows when the test
+// finishes, so that the next test starts with empty tables regardless of the order the tests run in. Tables in
+// Config.DoNotCleanTables are not truncated. Only the tables that have rows are truncated, which is faster than
+// CleanAllTables() for large schemas where a test touches a few tables.
+//
+// If Config.VerifyEmptyTables is set, AutoClean also fails the test immediately if a table has rows at its start,
+// and shows the leftover rows, which points at a test that wrote to the Database without calling AutoClean. The
+// leftover rows are still truncated when the failed test finishes, so that the following tests are not affected.
+// Tests that call AutoClean on the same box must not run in parallel.
+func (b *MySQLBox) AutoClean(t testing.TB) {
+	t.Helper()
+
+	if b == nil {
+		t.Fatal("mysqlbox is nil")
+	}
+
+	ctx := context.Background()
+
+	// The cleanup is registered first, so that the leftover rows found below are also truncated.
+	t.Cleanup(func() {
+		err := b.cleanDirtyTables(ctx)
+		if err != nil {
+			t.Errorf("error cleaning tables: %s", err.Error())
+		}
+	})
+
+	if b.verifyEmptyTables {
+		leftovers, err := b.leftoverTables(ctx)
+		if err != nil {
+			t.Fatalf("error verifying that tables are empty: %s", err.Error())
+		}
+
+		if len(leftovers) > 0 {
+			t.Fatal(formatLeftoverTables(leftovers))
+		}
+	}
+}
+
+// cleanDirtyTables truncates the tables to clean that have rows.
+func (b *MySQLBox) cleanDirtyTables(ctx context.Context) error {
+	tables, err := b.dirtyTables(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(tables) == 0 {
+		return nil
+	}
+
+	if b.killLockHolders {
+		err := b.killMetadataLockHolders(ctx, tables)
+		if err != nil {
+			return err
+		}
+	}
+
+	return b.withoutForeignKeyChecks(ctx, func(conn *sql.Conn) error {
+		for _, table := range tables {
+			_, err := conn.ExecContext(ctx, fmt.Sprintf("TRUNCATE TABLE %s", quoteIdent(table)))
+			if err != nil {
+				return fmt.Errorf("truncate table %s failed: %w", table, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// dirtyTables returns the tables to clean that have at least one row, ordered by name.
+func (b *MySQLBox) dirtyTables(ctx context.Context) ([]string, error) {
+	tables, err := b.tablesToClean(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(tables)
+
+	var dirty []string
+	for _, table := range tables {
+		var hasRows bool
+		err := b.db.QueryRowContext(ctx, fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s)", quoteIdent(table))).
+			Scan(&hasRows)
+		if err != nil {
+			return nil, fmt.Errorf("error reading table %s: %w", table, err)
+		}
+
+		if hasRows {
+			dirty = append(dirty, table)
+		}
+	}
+
+	return dirty, nil
+}
+
+// leftoverTables returns the tables to clean that have rows, with their row count and first rows.
+func (b *MySQLBox) leftoverTables(ctx context.Context) ([]leftoverTable, error) {
+	tables, err := b.dirtyTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var leftovers []leftoverTable
+	for _, table := range tables {
+		leftover, err := b.leftoverTable(ctx, table)
+		if err != nil {
+			return nil, fmt.Errorf("error reading table %s: %w", table, err)
+		}
+		leftovers = append(leftovers, leftover)
+	}
+
+	return leftovers, nil
+}
+
+// leftoverTable returns the row count and first rows of a table.
+func (b *MySQLBox) leftoverTable(ctx context.Context, table string) (leftoverTable, error) {
+	leftover := leftoverTable{name: table}
+
+	err := b.db.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s", quoteIdent(table))).
+		Scan(&leftover.count)
+	if err != nil {
+		return leftover, err
+	}
+
+	rows, err := b.db.QueryContext(ctx, fmt.Sprintf("SELECT * FROM %s LIMIT %d", quoteIdent(table), maxLeftoverRows))
+	if err != nil {
+		return leftover, err
+	}
+	defer rows.Close()
+
+	leftover.columns, err = rows.Columns()
+	if err != nil {
+		return leftover, err
+	}
+
+	for rows.Next() {
+		values := make([]sql.NullString, len(leftover.columns))
+		ptrs := make([]interface{}, len(values))
+		for n := range values {
+			ptrs[n] = &values[n]
+		}
+
+		err := rows.Scan(ptrs...)
+		if err != nil {
+			return leftover, err
+		}
+		leftover.rows = append(leftover.rows, values)
+	}
+
+	return leftover, rows.Err()
+}
+
+// formatLeftoverTables returns the test failure message for tables that have rows at the start of a test.
+func formatLeftoverTables(leftovers []leftoverTable) string {
+	var sb strings.Builder
+	sb.WriteString("tables are not empty at the start of the test, a previous test did not clean up:")
+
+	for _, leftover := range leftovers {
+		fmt.Fprintf(&sb, "\n  %s (%d rows):", leftover.name, leftover.count)
+
+		for _, row := range leftover.rows {
+			fields := make([]string, len(row))
+			for n, value := range row {
+				formatted := "NULL"
+				if value.Valid {
+					formatted = strconv.Quote(value.String)
+				}
+				fields[n] = leftover.columns[n] + "=" + formatted
+			}
+			fmt.Fprintf(&sb, "\n    %s", strings.Join(fields, " "))
+		}
+
+		if leftover.count > int64(len(leftover.rows)) {
+			fmt.Fprintf(&sb, "\n    ... %d more", leftover.count-int64(len(leftover.rows)))
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,36 @@
+package mysqlbox
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatLeftoverTables(t *testing.T) {
+	message := formatLeftoverTables([]leftoverTable{
+		{
+			name:    "categories",
+			count:   7,
+			columns: []string{"id", "name", "parent_id"},
+			rows: [][]sql.NullString{
+				{{String: "1", Valid: true}, {String: "Books", Valid: true}, {}},
+				{{String: "2", Valid: true}, {String: `Toys "R" Us`, Valid: true}, {String: "1", Valid: true}},
+			},
+		},
+		{
+			name:    "users",
+			count:   1,
+			columns: []string{"id"},
+			rows:    [][]sql.NullString{{{String: "5", Valid: true}}},
+		},
+	})
+
+	require.Equal(t, `tables are not empty at the start of the test, a previous test did not clean up:
+  categories (7 rows):
+    id="1" name="Books" parent_id=NULL
+    id="2" name="Toys \"R\" Us" parent_id="1"
+    ... 5 more
+  users (1 rows):
+    id="5"`, message)
+}
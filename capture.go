@@ -0,0 +1,659 @@
+package mysqlbox
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxPacketLength is the payload length of a MySQL packet that is continued in the next packet.
+const maxPacketLength = 0xffffff
+
+// Capability flags of the MySQL protocol.
+const (
+	clientCompress     = 0x20
+	clientProtocol41   = 0x200
+	clientSSL          = 0x800
+	clientDeprecateEOF = 1 << 24
+)
+
+// commandNames contains the names of the MySQL client commands.
+var commandNames = map[byte]string{
+	0x01: "COM_QUIT",
+	0x02: "COM_INIT_DB",
+	0x03: "COM_QUERY",
+	0x04: "COM_FIELD_LIST",
+	0x08: "COM_STATISTICS",
+	0x0e: "COM_PING",
+	0x11: "COM_CHANGE_USER",
+	0x16: "COM_STMT_PREPARE",
+	0x17: "COM_STMT_EXECUTE",
+	0x18: "COM_STMT_SEND_LONG_DATA",
+	0x19: "COM_STMT_CLOSE",
+	0x1a: "COM_STMT_RESET",
+	0x1b: "COM_SET_OPTION",
+	0x1c: "COM_STMT_FETCH",
+	0x1f: "COM_RESET_CONNECTION",
+}
+
+// maxSummaryQuery is the maximum length of a statement in the summary of a packet.
+const maxSummaryQuery = 256
+
+// CapturedPacket is a MySQL protocol packet sent through the capturing proxy enabled with Config.CaptureTraffic.
+type CapturedPacket struct {
+	// Conn is the number of the connection, starting from 1.
+	Conn int
+
+	// Time is the time the packet was received by the proxy.
+	Time time.Time
+
+	// FromClient is set for packets sent by the client, and unset for packets sent by the server.
+	FromClient bool
+
+	// Seq is the sequence ID of the packet.
+	Seq uint8
+
+	// Payload is the payload of the packet, without the header. For encrypted or compressed connections, it is the
+	// data received in one read.
+	Payload []byte
+
+	// Summary describes the packet, e.g. "COM_QUERY SELECT 1" or "AuthSwitchRequest mysql_native_password".
+	Summary string
+}
+
+// String returns the time, connection, direction, sequence ID, length, and summary of the packet, e.g.
+// "15:04:05.000000 #1 C>S seq=0 len=9 COM_QUERY SELECT 1".
+func (p CapturedPacket) String() string {
+	direction := "S>C"
+	if p.FromClient {
+		direction = "C>S"
+	}
+
+	return fmt.Sprintf("%s #%d %s seq=%d len=%d %s", p.Time.Format("15:04:05.000000"), p.Conn, direction, p.Seq,
+		len(p.Payload), p.Summary)
+}
+
+// TrafficCapture is a proxy in front of the MySQL server that captures the traffic of the connections made through
+// it, enabled with Config.CaptureTraffic. It is safe for concurrent use.
+type TrafficCapture struct {
+	listener net.Listener
+	port     int
+	box      *MySQLBox
+
+	mu      sync.Mutex
+	nextID  int
+	conns   map[*captureConn]bool
+	events  []captureEvent
+	packets []CapturedPacket
+}
+
+// captureEventKind is the kind of a captured event.
+type captureEventKind int
+
+const (
+	captureOpen captureEventKind = iota
+	captureData
+	captureClose
+)
+
+// captureEvent is an event of a captured connection, used to write the pcap file.
+type captureEvent struct {
+	kind       captureEventKind
+	conn       *captureConn
+	time       time.Time
+	fromClient bool
+	data       []byte
+}
+
+// capturePhase is the protocol phase of a captured connection.
+type capturePhase int
+
+const (
+	phaseGreeting capturePhase = iota
+	phaseAuth
+	phaseCommand
+)
+
+// responseState is the state of the server response to a command.
+type responseState int
+
+const (
+	stateResponse responseState = iota
+	stateColumns
+	stateColumnsEOF
+	stateRows
+	statePrepareDefs
+)
+
+// captureConn is a connection made through the capturing proxy, with the state of the protocol decoder.
+type captureConn struct {
+	id         int
+	clientPort int
+	client     net.Conn
+	server     net.Conn
+
+	phase        capturePhase
+	capabilities uint32
+	opaque       string
+	command      byte
+	state        responseState
+	remaining    uint64
+	pending      [2][]byte
+	split        [2]bool
+}
+
+// Capture returns the capturing proxy in front of the MySQL server when Config.CaptureTraffic is set.
+func (b *MySQLBox) Capture() (*TrafficCapture, error) {
+	if b == nil {
+		return nil, errors.New("mysqlbox is nil")
+	}
+
+	if b.capture == nil {
+		return nil, errors.New("traffic capture is not enabled")
+	}
+
+	return b.capture, nil
+}
+
+// MustCapture returns the capturing proxy in front of the MySQL server when Config.CaptureTraffic is set.
+func (b *MySQLBox) MustCapture() *TrafficCapture {
+	c, err := b.Capture()
+	if err != nil {
+		panic(err)
+	}
+
+	return c
+}
+
+// Addr returns the host address of the capturing proxy.
+func (c *TrafficCapture) Addr() string {
+	return net.JoinHostPort(c.box.host, strconv.Itoa(c.port))
+}
+
+// DSN returns a DSN for connecting to the database as root through the capturing proxy.
+func (c *TrafficCapture) DSN(database string) string {
+	mysqlCfg := newMySQLConfig(c.box.host, c.port, database, c.box.rootPassword, c.box.tlsConfigName())
+	return mysqlCfg.FormatDSN()
+}
+
+// Timeline returns the captured packets of all connections in the order they were received. Packets sent after an
+// SSLRequest are encrypted and only their lengths are shown, so TLS should be disabled in the DSN of the captured
+// connections to decode them.
+func (c *TrafficCapture) Timeline() []CapturedPacket {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]CapturedPacket(nil), c.packets...)
+}
+
+// String returns the captured packets, one per line, e.g. for the message of a failed assertion.
+func (c *TrafficCapture) String() string {
+	packets := c.Timeline()
+	lines := make([]string, len(packets))
+	for n, p := range packets {
+		lines[n] = p.String()
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Reset removes the captured traffic, e.g. after the test data is set up and before the code under test runs.
+func (c *TrafficCapture) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.events = nil
+	c.packets = nil
+}
+
+// WritePcap writes the captured traffic to w as a pcap file that can be opened with Wireshark or tcpdump. The TCP
+// segments are reconstructed from the data received by the proxy, with the server on port 3306 so that Wireshark
+// decodes them as MySQL traffic.
+func (c *TrafficCapture) WritePcap(w io.Writer) error {
+	c.mu.Lock()
+	events := append([]captureEvent(nil), c.events...)
+	c.mu.Unlock()
+
+	return writePcap(w, events)
+}
+
+// startCapture starts a capturing proxy in front of the MySQL server.
+func (b *MySQLBox) startCapture() (*TrafficCapture, error) {
+	listener, err := net.Listen("tcp", net.JoinHostPort(b.bindIP, "0"))
+	if err != nil {
+		return nil, err
+	}
+
+	c := &TrafficCapture{
+		listener: listener,
+		port:     listener.Addr().(*net.TCPAddr).Port,
+		box:      b,
+		conns:    map[*captureConn]bool{},
+	}
+	go c.serve()
+
+	return c, nil
+}
+
+// serve accepts connections until the listener is closed.
+func (c *TrafficCapture) serve() {
+	for {
+		client, err := c.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go c.proxy(client)
+	}
+}
+
+// proxy forwards the traffic between a client connection and the MySQL server until either side closes.
+func (c *TrafficCapture) proxy(client net.Conn) {
+	server, err := net.Dial("tcp", net.JoinHostPort(c.box.host, strconv.Itoa(c.box.port)))
+	if err != nil {
+		_ = client.Close()
+		return
+	}
+
+	conn := &captureConn{client: client, server: server}
+	if addr, ok := client.RemoteAddr().(*net.TCPAddr); ok {
+		conn.clientPort = addr.Port
+	}
+
+	c.mu.Lock()
+	c.nextID++
+	conn.id = c.nextID
+	c.conns[conn] = true
+	c.events = append(c.events, captureEvent{kind: captureOpen, conn: conn, time: time.Now()})
+	c.mu.Unlock()
+
+	done := make(chan struct{}, 2)
+	go c.forward(conn, server, client, true, done)
+	go c.forward(conn, client, server, false, done)
+
+	// Closing both connections when either side closes ends the other copy.
+	<-done
+	_ = client.Close()
+	_ = server.Close()
+	<-done
+
+	c.mu.Lock()
+	delete(c.conns, conn)
+	c.events = append(c.events, captureEvent{kind: captureClose, conn: conn, time: time.Now()})
+	c.mu.Unlock()
+}
+
+// forward copies the data from src to dst and captures it.
+func (c *TrafficCapture) forward(conn *captureConn, dst net.Conn, src net.Conn, fromClient bool,
+	done chan<- struct{}) {
+	defer func() {
+		done <- struct{}{}
+	}()
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			c.record(conn, fromClient, buf[:n])
+
+			_, werr := dst.Write(buf[:n])
+			if werr != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// record captures data received from one side of a connection and decodes its complete packets.
+func (c *TrafficCapture) record(conn *captureConn, fromClient bool, data []byte) {
+	now := time.Now()
+	data = append([]byte(nil), data...)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.events = append(c.events, captureEvent{kind: captureData, conn: conn, time: now, fromClient: fromClient,
+		data: data})
+	c.packets = append(c.packets, conn.decode(fromClient, data, now)...)
+}
+
+// stop closes the listener and the connections of the proxy.
+func (c *TrafficCapture) stop() {
+	_ = c.listener.Close()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for conn := range c.conns {
+		_ = conn.client.Close()
+		_ = conn.server.Close()
+	}
+}
+
+// decode returns the complete packets in the data received from one side of the connection. Incomplete packets are
+// kept until the rest of their data is received.
+func (conn *captureConn) decode(fromClient bool, data []byte, now time.Time) []CapturedPacket {
+	if conn.opaque != "" {
+		return []CapturedPacket{{
+			Conn:       conn.id,
+			Time:       now,
+			FromClient: fromClient,
+			Payload:    data,
+			Summary:    conn.opaque + " data",
+		}}
+	}
+
+	dir := 0
+	if fromClient {
+		dir = 1
+	}
+
+	buf := append(conn.pending[dir], data...)
+	var packets []CapturedPacket
+	for len(buf) >= 4 {
+		length := int(buf[0]) | int(buf[1])<<8 | int(buf[2])<<16
+		if len(buf) < 4+length {
+			break
+		}
+
+		p := CapturedPacket{
+			Conn:       conn.id,
+			Time:       now,
+			FromClient: fromClient,
+			Seq:        buf[3],
+			Payload:    append([]byte(nil), buf[4:4+length]...),
+		}
+		buf = buf[4+length:]
+
+		if conn.split[dir] {
+			p.Summary = "continuation of the previous packet"
+		} else if fromClient {
+			p.Summary = conn.decodeClient(p.Seq, p.Payload)
+		} else {
+			p.Summary = conn.decodeServer(p.Payload)
+		}
+
+		conn.split[dir] = length == maxPacketLength
+		if conn.split[dir] {
+			p.Summary += " (split: continues in the next packet)"
+		}
+		packets = append(packets, p)
+
+		// The data after an SSLRequest is encrypted, and the data after a compressed session is established is
+		// compressed.
+		if conn.opaque != "" {
+			if len(buf) > 0 {
+				packets = append(packets, CapturedPacket{
+					Conn:       conn.id,
+					Time:       now,
+					FromClient: fromClient,
+					Payload:    append([]byte(nil), buf...),
+					Summary:    conn.opaque + " data",
+				})
+			}
+			buf = nil
+			break
+		}
+	}
+	conn.pending[dir] = append([]byte(nil), buf...)
+
+	return packets
+}
+
+// decodeClient describes a packet sent by the client.
+func (conn *captureConn) decodeClient(seq uint8, payload []byte) string {
+	switch conn.phase {
+	case phaseGreeting:
+		if len(payload) < 4 {
+			return fmt.Sprintf("handshake response (%d bytes)", len(payload))
+		}
+
+		conn.capabilities = binary.LittleEndian.Uint32(payload)
+		conn.phase = phaseAuth
+		if conn.capabilities&clientSSL != 0 && len(payload) == 32 {
+			conn.opaque = "TLS"
+			return "SSLRequest"
+		}
+
+		if conn.capabilities&clientProtocol41 == 0 || len(payload) < 33 {
+			return "HandshakeResponse"
+		}
+
+		return fmt.Sprintf("HandshakeResponse user=%s", nullTerminated(payload[32:]))
+	case phaseAuth:
+		return fmt.Sprintf("auth data (%d bytes)", len(payload))
+	}
+
+	if seq != 0 || len(payload) == 0 {
+		return fmt.Sprintf("data (%d bytes)", len(payload))
+	}
+
+	conn.command = payload[0]
+	conn.state = stateResponse
+
+	name, ok := commandNames[payload[0]]
+	if !ok {
+		return fmt.Sprintf("command 0x%02x", payload[0])
+	}
+
+	switch payload[0] {
+	case 0x02, 0x03, 0x16:
+		query := string(payload[1:])
+		if len(query) > maxSummaryQuery {
+			query = query[:maxSummaryQuery] + "..."
+		}
+		return name + " " + query
+	case 0x17, 0x18, 0x19, 0x1a, 0x1c:
+		if len(payload) >= 5 {
+			return fmt.Sprintf("%s statement=%d", name, binary.LittleEndian.Uint32(payload[1:]))
+		}
+	}
+
+	return name
+}
+
+// decodeServer describes a packet sent by the server.
+func (conn *captureConn) decodeServer(payload []byte) string {
+	if len(payload) == 0 {
+		return "empty packet"
+	}
+
+	switch conn.phase {
+	case phaseGreeting:
+		if payload[0] == 0xff {
+			return errPacketSummary(payload)
+		}
+
+		return fmt.Sprintf("Handshake protocol=%d server=%s", payload[0], nullTerminated(payload[1:]))
+	case phaseAuth:
+		switch payload[0] {
+		case 0x00:
+			conn.phase = phaseCommand
+			if conn.capabilities&clientCompress != 0 {
+				conn.opaque = "compressed"
+			}
+			return okPacketSummary(payload)
+		case 0xff:
+			return errPacketSummary(payload)
+		case 0xfe:
+			return "AuthSwitchRequest " + nullTerminated(payload[1:])
+		case 0x01:
+			if len(payload) == 2 && payload[1] == 3 {
+				return "AuthMoreData fast auth succeeded"
+			}
+			if len(payload) == 2 && payload[1] == 4 {
+				return "AuthMoreData full authentication required"
+			}
+			return fmt.Sprintf("AuthMoreData (%d bytes)", len(payload)-1)
+		}
+
+		return fmt.Sprintf("auth data (%d bytes)", len(payload))
+	}
+
+	// Rows of the binary protocol start with 0x00, so only EOF and ERR packets are recognized in result sets.
+	eof := payload[0] == 0xfe && len(payload) < maxPacketLength
+	if payload[0] == 0xff {
+		conn.state = stateResponse
+		return errPacketSummary(payload)
+	}
+
+	switch conn.state {
+	case stateColumns:
+		conn.remaining--
+		if conn.remaining == 0 {
+			conn.state = stateRows
+			if conn.capabilities&clientDeprecateEOF == 0 {
+				conn.state = stateColumnsEOF
+			}
+		}
+		return columnSummary(payload)
+	case stateColumnsEOF:
+		conn.state = stateRows
+		if eof {
+			return "EOF"
+		}
+	case stateRows:
+		if eof {
+			conn.state = stateResponse
+			return "end of result set"
+		}
+		return fmt.Sprintf("row (%d bytes)", len(payload))
+	case statePrepareDefs:
+		if eof {
+			return "EOF"
+		}
+		conn.remaining--
+		if conn.remaining == 0 {
+			conn.state = stateResponse
+		}
+		return columnSummary(payload)
+	}
+
+	switch {
+	case payload[0] == 0x00 && conn.command == 0x16 && len(payload) >= 9:
+		id := binary.LittleEndian.Uint32(payload[1:])
+		columns := binary.LittleEndian.Uint16(payload[5:])
+		params := binary.LittleEndian.Uint16(payload[7:])
+		if columns+params > 0 {
+			conn.state = statePrepareDefs
+			conn.remaining = uint64(columns) + uint64(params)
+		}
+		return fmt.Sprintf("prepare OK statement=%d columns=%d params=%d", id, columns, params)
+	case payload[0] == 0x00:
+		return okPacketSummary(payload)
+	case eof:
+		return "EOF"
+	case payload[0] == 0xfb:
+		return "LOCAL INFILE request " + string(payload[1:])
+	}
+
+	count, n := lengthEncodedInt(payload)
+	if n == 0 || count == 0 {
+		return fmt.Sprintf("response (%d bytes)", len(payload))
+	}
+
+	conn.state = stateColumns
+	conn.remaining = count
+
+	return fmt.Sprintf("result set columns=%d", count)
+}
+
+// okPacketSummary describes an OK packet.
+func okPacketSummary(payload []byte) string {
+	affected, n := lengthEncodedInt(payload[1:])
+	if n == 0 {
+		return "OK"
+	}
+
+	insertID, m := lengthEncodedInt(payload[1+n:])
+	if m == 0 {
+		return fmt.Sprintf("OK affected_rows=%d", affected)
+	}
+
+	return fmt.Sprintf("OK affected_rows=%d last_insert_id=%d", affected, insertID)
+}
+
+// errPacketSummary describes an ERR packet, e.g. "ERR 1045 (28000): Access denied for user 'root'".
+func errPacketSummary(payload []byte) string {
+	if len(payload) < 3 {
+		return "ERR"
+	}
+
+	code := binary.LittleEndian.Uint16(payload[1:])
+	message := payload[3:]
+	if len(message) >= 6 && message[0] == '#' {
+		return fmt.Sprintf("ERR %d (%s): %s", code, message[1:6], message[6:])
+	}
+
+	return fmt.Sprintf("ERR %d: %s", code, message)
+}
+
+// columnSummary describes a column definition packet, e.g. "column users.email".
+func columnSummary(payload []byte) string {
+	var fields []string
+	rest := payload
+	// The column definition starts with the catalog, schema, table, original table, and name strings.
+	for i := 0; i < 5; i++ {
+		length, n := lengthEncodedInt(rest)
+		if n == 0 || uint64(len(rest)-n) < length {
+			return fmt.Sprintf("column definition (%d bytes)", len(payload))
+		}
+		fields = append(fields, string(rest[n:n+int(length)]))
+		rest = rest[n+int(length):]
+	}
+
+	if fields[2] == "" {
+		return "column " + fields[4]
+	}
+
+	return "column " + fields[2] + "." + fields[4]
+}
+
+// lengthEncodedInt decodes a length-encoded integer. It returns the number of bytes read, which is 0 if the data is
+// not a valid length-encoded integer.
+func lengthEncodedInt(data []byte) (uint64, int) {
+	if len(data) == 0 {
+		return 0, 0
+	}
+
+	switch data[0] {
+	case 0xfc:
+		if len(data) < 3 {
+			return 0, 0
+		}
+		return uint64(data[1]) | uint64(data[2])<<8, 3
+	case 0xfd:
+		if len(data) < 4 {
+			return 0, 0
+		}
+		return uint64(data[1]) | uint64(data[2])<<8 | uint64(data[3])<<16, 4
+	case 0xfe:
+		if len(data) < 9 {
+			return 0, 0
+		}
+		return binary.LittleEndian.Uint64(data[1:]), 9
+	case 0xfb, 0xff:
+		return 0, 0
+	}
+
+	return uint64(data[0]), 1
+}
+
+// nullTerminated returns the string at the start of the data up to the first null byte.
+func nullTerminated(data []byte) string {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return string(data[:i])
+	}
+
+	return string(data)
+}
@@ -0,0 +1,267 @@
+package mysqlbox
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// mysqlPacket returns a MySQL packet with the sequence ID and payload.
+func mysqlPacket(seq uint8, payload []byte) []byte {
+	header := []byte{byte(len(payload)), byte(len(payload) >> 8), byte(len(payload) >> 16), seq}
+	return append(header, payload...)
+}
+
+// handshakeResponse returns a client handshake response with the capabilities and user.
+func handshakeResponse(capabilities uint32, user string) []byte {
+	payload := make([]byte, 32)
+	binary.LittleEndian.PutUint32(payload, capabilities)
+	payload = append(payload, user...)
+	return append(payload, 0)
+}
+
+func summaries(packets []CapturedPacket) []string {
+	s := make([]string, len(packets))
+	for n, p := range packets {
+		s[n] = p.Summary
+	}
+	return s
+}
+
+func TestCaptureConnDecode(t *testing.T) {
+	now := time.Now()
+
+	t.Run("session", func(t *testing.T) {
+		conn := &captureConn{id: 1}
+		var packets []CapturedPacket
+
+		packets = append(packets, conn.decode(false, mysqlPacket(0, append([]byte{10}, "8.0.32\x00"...)), now)...)
+		packets = append(packets, conn.decode(true, mysqlPacket(1,
+			handshakeResponse(clientProtocol41|clientDeprecateEOF, "root")), now)...)
+		packets = append(packets, conn.decode(false, mysqlPacket(2,
+			append([]byte{0xfe}, "mysql_native_password\x00"...)), now)...)
+		packets = append(packets, conn.decode(true, mysqlPacket(3, make([]byte, 20)), now)...)
+		packets = append(packets, conn.decode(false, mysqlPacket(4, []byte{0x00, 0, 0, 2, 0, 0, 0}), now)...)
+		require.Equal(t, phaseCommand, conn.phase)
+
+		packets = append(packets, conn.decode(true, mysqlPacket(0, append([]byte{0x03}, "SELECT id FROM t"...)),
+			now)...)
+
+		column := []byte{3, 'd', 'e', 'f', 2, 'd', 'b', 1, 't', 1, 't', 2, 'i', 'd', 2, 'i', 'd'}
+		var response []byte
+		response = append(response, mysqlPacket(1, []byte{1})...)
+		response = append(response, mysqlPacket(2, column)...)
+		response = append(response, mysqlPacket(3, []byte{1, '7'})...)
+		response = append(response, mysqlPacket(4, []byte{0xfe, 0, 0, 2, 0, 0, 0})...)
+
+		// The response is received in two reads that split a packet.
+		packets = append(packets, conn.decode(false, response[:10], now)...)
+		packets = append(packets, conn.decode(false, response[10:], now)...)
+
+		packets = append(packets, conn.decode(true, mysqlPacket(0, append([]byte{0x03}, "INSERT INTO t VALUES (1)"...)),
+			now)...)
+		packets = append(packets, conn.decode(false, mysqlPacket(1,
+			append([]byte{0xff, 0x26, 0x04}, "#23000Duplicate entry '1' for key 't.PRIMARY'"...)), now)...)
+
+		require.Equal(t, []string{
+			"Handshake protocol=10 server=8.0.32",
+			"HandshakeResponse user=root",
+			"AuthSwitchRequest mysql_native_password",
+			"auth data (20 bytes)",
+			"OK affected_rows=0 last_insert_id=0",
+			"COM_QUERY SELECT id FROM t",
+			"result set columns=1",
+			"column t.id",
+			"row (2 bytes)",
+			"end of result set",
+			"COM_QUERY INSERT INTO t VALUES (1)",
+			"ERR 1062 (23000): Duplicate entry '1' for key 't.PRIMARY'",
+		}, summaries(packets))
+	})
+
+	t.Run("eof", func(t *testing.T) {
+		conn := &captureConn{phase: phaseCommand}
+		conn.decode(true, mysqlPacket(0, append([]byte{0x03}, "SELECT 1"...)), now)
+
+		var response []byte
+		response = append(response, mysqlPacket(1, []byte{1})...)
+		response = append(response, mysqlPacket(2, []byte{3, 'd', 'e', 'f', 0, 0, 0, 1, '1', 0})...)
+		response = append(response, mysqlPacket(3, []byte{0xfe, 0, 0, 2, 0})...)
+		response = append(response, mysqlPacket(4, []byte{1, '1'})...)
+		response = append(response, mysqlPacket(5, []byte{0xfe, 0, 0, 2, 0})...)
+
+		require.Equal(t, []string{
+			"result set columns=1",
+			"column 1",
+			"EOF",
+			"row (2 bytes)",
+			"end of result set",
+		}, summaries(conn.decode(false, response, now)))
+	})
+
+	t.Run("prepare", func(t *testing.T) {
+		conn := &captureConn{phase: phaseCommand, capabilities: clientDeprecateEOF}
+		conn.decode(true, mysqlPacket(0, append([]byte{0x16}, "SELECT ?"...)), now)
+
+		var response []byte
+		response = append(response, mysqlPacket(1, []byte{0x00, 5, 0, 0, 0, 1, 0, 1, 0, 0, 0, 0})...)
+		response = append(response, mysqlPacket(2, []byte{3, 'd', 'e', 'f', 0, 0, 0, 1, '?', 0})...)
+		response = append(response, mysqlPacket(3, []byte{3, 'd', 'e', 'f', 0, 0, 0, 1, '?', 0})...)
+		response = append(response, mysqlPacket(0, []byte{0x00})...)
+
+		packets := conn.decode(false, response, now)
+		require.Equal(t, []string{
+			"prepare OK statement=5 columns=1 params=1",
+			"column ?",
+			"column ?",
+			"OK",
+		}, summaries(packets))
+
+		packets = conn.decode(true, mysqlPacket(0, []byte{0x17, 5, 0, 0, 0, 0, 1, 0, 0, 0}), now)
+		require.Equal(t, []string{"COM_STMT_EXECUTE statement=5"}, summaries(packets))
+	})
+
+	t.Run("split", func(t *testing.T) {
+		conn := &captureConn{phase: phaseCommand}
+		large := make([]byte, maxPacketLength)
+		large[0] = 0x03
+
+		var data []byte
+		data = append(data, mysqlPacket(0, large)...)
+		data = append(data, mysqlPacket(1, []byte("rest"))...)
+
+		packets := conn.decode(true, data, now)
+		require.Len(t, packets, 2)
+		require.True(t, strings.HasSuffix(packets[0].Summary, "(split: continues in the next packet)"))
+		require.Equal(t, "continuation of the previous packet", packets[1].Summary)
+	})
+
+	t.Run("tls", func(t *testing.T) {
+		conn := &captureConn{}
+		conn.decode(false, mysqlPacket(0, append([]byte{10}, "8.0.32\x00"...)), now)
+
+		data := mysqlPacket(1, handshakeResponse(clientProtocol41|clientSSL, "")[:32])
+		data = append(data, 0x16, 0x03, 0x01)
+		packets := conn.decode(true, data, now)
+		require.Equal(t, []string{"SSLRequest", "TLS data"}, summaries(packets))
+
+		packets = conn.decode(false, []byte{0x16, 0x03, 0x03, 0x00}, now)
+		require.Equal(t, []string{"TLS data"}, summaries(packets))
+	})
+}
+
+func TestWritePcap(t *testing.T) {
+	conn := &captureConn{id: 1, clientPort: 50000}
+	now := time.Unix(1700000000, 123000)
+	events := []captureEvent{
+		{kind: captureOpen, conn: conn, time: now},
+		{kind: captureData, conn: conn, time: now, data: []byte("greeting")},
+		{kind: captureData, conn: conn, time: now, fromClient: true, data: []byte("hello")},
+		{kind: captureClose, conn: conn, time: now},
+	}
+
+	var buf bytes.Buffer
+	err := writePcap(&buf, events)
+	require.NoError(t, err)
+
+	data := buf.Bytes()
+	require.Equal(t, uint32(0xa1b2c3d4), binary.LittleEndian.Uint32(data))
+	require.Equal(t, uint32(pcapLinkTypeRaw), binary.LittleEndian.Uint32(data[20:]))
+	data = data[24:]
+
+	type segment struct {
+		srcPort uint16
+		seq     uint32
+		ack     uint32
+		flags   byte
+		data    string
+	}
+
+	var segments []segment
+	for len(data) > 0 {
+		require.Equal(t, uint32(1700000000), binary.LittleEndian.Uint32(data))
+		require.Equal(t, uint32(123), binary.LittleEndian.Uint32(data[4:]))
+		length := binary.LittleEndian.Uint32(data[8:])
+		packet := data[16 : 16+length]
+		data = data[16+length:]
+
+		// The checksums of valid headers add up to zero.
+		require.Equal(t, uint16(0), checksum(packet[:20], 0))
+		pseudo := []byte{127, 0, 0, 1, 127, 0, 0, 1, 0, 6, 0, byte(len(packet) - 20)}
+		require.Equal(t, uint16(0), checksum(packet[20:], wordSum(pseudo)))
+
+		tcp := packet[20:]
+		segments = append(segments, segment{
+			srcPort: binary.BigEndian.Uint16(tcp),
+			seq:     binary.BigEndian.Uint32(tcp[4:]),
+			ack:     binary.BigEndian.Uint32(tcp[8:]),
+			flags:   tcp[13],
+			data:    string(tcp[20:]),
+		})
+	}
+
+	require.Equal(t, []segment{
+		{srcPort: 50000, seq: 0, ack: 0, flags: tcpSYN},
+		{srcPort: 3306, seq: 0, ack: 1, flags: tcpSYN | tcpACK},
+		{srcPort: 50000, seq: 1, ack: 1, flags: tcpACK},
+		{srcPort: 3306, seq: 1, ack: 1, flags: tcpPSH | tcpACK, data: "greeting"},
+		{srcPort: 50000, seq: 1, ack: 9, flags: tcpPSH | tcpACK, data: "hello"},
+		{srcPort: 50000, seq: 6, ack: 9, flags: tcpFIN | tcpACK},
+		{srcPort: 3306, seq: 9, ack: 7, flags: tcpFIN | tcpACK},
+		{srcPort: 50000, seq: 7, ack: 10, flags: tcpACK},
+	}, segments)
+}
+
+func TestTrafficCaptureProxy(t *testing.T) {
+	server, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer server.Close()
+
+	go func() {
+		conn, err := server.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		_, _ = conn.Write(mysqlPacket(0, append([]byte{10}, "8.0.32\x00"...)))
+		buf := make([]byte, 1024)
+		_, _ = conn.Read(buf)
+	}()
+
+	b := &MySQLBox{host: "127.0.0.1", bindIP: "127.0.0.1", port: server.Addr().(*net.TCPAddr).Port}
+	capture, err := b.startCapture()
+	require.NoError(t, err)
+	defer capture.stop()
+
+	client, err := net.Dial("tcp", capture.Addr())
+	require.NoError(t, err)
+
+	buf := make([]byte, 1024)
+	n, err := client.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, mysqlPacket(0, append([]byte{10}, "8.0.32\x00"...)), buf[:n])
+
+	_, err = client.Write(mysqlPacket(1, handshakeResponse(clientProtocol41, "app")))
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return len(capture.Timeline()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	packets := capture.Timeline()
+	require.Equal(t, 1, packets[0].Conn)
+	require.False(t, packets[0].FromClient)
+	require.True(t, packets[1].FromClient)
+	require.Contains(t, capture.String(), "#1 C>S seq=1 len=36 HandshakeResponse user=app")
+
+	capture.Reset()
+	require.Empty(t, capture.Timeline())
+
+	_ = client.Close()
+}
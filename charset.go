@@ -0,0 +1,451 @@
+package mysqlbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxRowSize is the maximum size in bytes of the columns of a row, not counting TEXT and BLOB columns.
+	maxRowSize = 65535
+
+	// maxIndexKeySize and maxCompactIndexKeySize are the maximum sizes in bytes of an InnoDB index key for tables with
+	// the DYNAMIC or COMPRESSED row formats, and with the COMPACT or REDUNDANT row formats.
+	maxIndexKeySize        = 3072
+	maxCompactIndexKeySize = 767
+
+	// charsetProgressInterval is the interval at which the progress of a conversion is read.
+	charsetProgressInterval = 100 * time.Millisecond
+)
+
+// CharsetProgress is called by ConvertTableCharset() while the table is converted, with the amount of work done and
+// the estimated total amount of work, as reported by the InnoDB ALTER TABLE stages of performance_schema.
+type CharsetProgress func(done int64, total int64)
+
+// ConvertCharsetOptions contains settings for ConvertTableCharset.
+type ConvertCharsetOptions struct {
+	// DryRun only reports the columns that would be converted and the problems of the conversion, without changing
+	// the table.
+	DryRun bool
+
+	// Progress is called while the table is converted. When the conversion is done, it is called with done equal to
+	// total. Progress is only reported when performance_schema is enabled.
+	Progress CharsetProgress
+}
+
+// CharsetColumn is a column converted by ConvertTableCharset().
+type CharsetColumn struct {
+	// Name is the name of the column.
+	Name string
+
+	// Type is the type of the column before the conversion, e.g. "varchar(255)".
+	Type string
+
+	// Charset is the character set of the column before the conversion.
+	Charset string
+
+	// Collation is the collation of the column before the conversion.
+	Collation string
+
+	// LossyRows is the number of rows with values that cannot be represented in the new character set. These
+	// characters are replaced with "?" by the conversion.
+	LossyRows int64
+
+	// MaxBytes is the length in bytes of the longest value of the column in the new character set.
+	MaxBytes int64
+}
+
+// CharsetReport describes the conversion of a table by ConvertTableCharset().
+type CharsetReport struct {
+	// Table is the name of the table.
+	Table string
+
+	// Charset is the new character set of the table.
+	Charset string
+
+	// Collation is the new collation of the table.
+	Collation string
+
+	// Columns contains the columns whose character set or collation is changed by the conversion.
+	Columns []CharsetColumn
+
+	// Warnings describes the problems of the new character set that are not caused by the rows, e.g. index keys
+	// that would exceed the maximum key size of InnoDB.
+	Warnings []string
+}
+
+// Safe reports whether the conversion does not lose data and has no warnings.
+func (r *CharsetReport) Safe() bool {
+	if len(r.Warnings) > 0 {
+		return false
+	}
+
+	for _, column := range r.Columns {
+		if column.LossyRows > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String describes the columns with lossy rows and the warnings of the conversion.
+func (r *CharsetReport) String() string {
+	lines := []string{fmt.Sprintf("converting %s to %s (%s): %d columns", r.Table, r.Charset, r.Collation,
+		len(r.Columns))}
+
+	for _, column := range r.Columns {
+		if column.LossyRows > 0 {
+			lines = append(lines, fmt.Sprintf("column %s (%s %s): %d rows cannot be converted", column.Name,
+				column.Type, column.Charset, column.LossyRows))
+		}
+	}
+
+	lines = append(lines, r.Warnings...)
+
+	return strings.Join(lines, "\n")
+}
+
+// charsetColumn is a string column of a table with the maximum length of its character set.
+type charsetColumn struct {
+	CharsetColumn
+	dataType  string
+	maxChars  int64
+	maxLen    int64
+	converted bool
+}
+
+// ConvertTableCharset converts a table of the Database to a character set with ALTER TABLE ... CONVERT TO CHARACTER
+// SET, e.g. to rehearse a utf8 to utf8mb4 migration in a test. If collation is blank, the default collation of the
+// character set is used. Before the table is converted, the values of the columns are checked, and the returned report
+// contains the rows that cannot be represented in the new character set, and the index keys and rows that would
+// become too large. With ConvertCharsetOptions.DryRun, only the report is returned:
+//
+//	report := box.MustConvertTableCharset("comments", "utf8mb4", "", &mysqlbox.ConvertCharsetOptions{DryRun: true})
+//	require.True(t, report.Safe(), report.String())
+func (b *MySQLBox) ConvertTableCharset(table string, charset string, collation string,
+	opts *ConvertCharsetOptions) (*CharsetReport, error) {
+	if b == nil {
+		return nil, errors.New("mysqlbox is nil")
+	}
+
+	if opts == nil {
+		opts = &ConvertCharsetOptions{}
+	}
+
+	ctx := context.Background()
+	report, err := b.charsetReport(ctx, table, charset, collation)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.DryRun {
+		return report, nil
+	}
+
+	query := fmt.Sprintf("ALTER TABLE %s CONVERT TO CHARACTER SET %s COLLATE %s", quoteIdent(table), report.Charset,
+		report.Collation)
+	err = b.alterWithProgress(ctx, query, opts.Progress)
+	if err != nil {
+		return report, fmt.Errorf("error converting table %s: %w", table, err)
+	}
+
+	return report, nil
+}
+
+// MustConvertTableCharset converts a table of the Database to a character set.
+func (b *MySQLBox) MustConvertTableCharset(table string, charset string, collation string,
+	opts *ConvertCharsetOptions) *CharsetReport {
+	report, err := b.ConvertTableCharset(table, charset, collation, opts)
+	if err != nil {
+		panic(err)
+	}
+
+	return report
+}
+
+// charsetReport checks the conversion of a table to a character set and collation.
+func (b *MySQLBox) charsetReport(ctx context.Context, table string, charset string,
+	collation string) (*CharsetReport, error) {
+	charset, collation, maxLen, err := b.lookupCharset(ctx, charset, collation)
+	if err != nil {
+		return nil, err
+	}
+
+	var rowFormat string
+	err = b.db.QueryRowContext(ctx, "SELECT row_format FROM information_schema.tables "+
+		"WHERE table_schema = ? AND table_name = ?", b.databaseName, table).Scan(&rowFormat)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("table %s does not exist", table)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := b.charsetColumns(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CharsetReport{Table: table, Charset: charset, Collation: collation}
+	for n := range columns {
+		column := &columns[n]
+		if strings.EqualFold(column.Charset, charset) && strings.EqualFold(column.Collation, collation) {
+			continue
+		}
+
+		column.converted = true
+		column.maxLen = maxLen
+
+		// The character set names come from information_schema, so they can be used as identifiers.
+		query := fmt.Sprintf(`SELECT
+			COALESCE(SUM(CAST(CONVERT(CONVERT(%[1]s USING %[2]s) USING %[3]s) AS BINARY) <> CAST(%[1]s AS BINARY)), 0),
+			COALESCE(MAX(LENGTH(CONVERT(%[1]s USING %[2]s))), 0)
+			FROM %[4]s`, quoteIdent(column.Name), charset, column.Charset, quoteIdent(table))
+		err := b.db.QueryRowContext(ctx, query).Scan(&column.LossyRows, &column.MaxBytes)
+		if err != nil {
+			return nil, fmt.Errorf("error checking column %s: %w", column.Name, err)
+		}
+
+		report.Columns = append(report.Columns, column.CharsetColumn)
+	}
+
+	rowSize := charsetRowSize(columns)
+	if rowSize > maxRowSize {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("row size would be %d bytes, more than the maximum "+
+			"of %d bytes", rowSize, maxRowSize))
+	}
+
+	maxKeySize := int64(maxIndexKeySize)
+	if strings.EqualFold(rowFormat, "Compact") || strings.EqualFold(rowFormat, "Redundant") {
+		maxKeySize = maxCompactIndexKeySize
+	}
+
+	keySizes, err := b.charsetKeySizes(ctx, table, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keySizes {
+		if key.size > maxKeySize {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("index %s would be %d bytes, more than the "+
+				"maximum of %d bytes", key.index, key.size, maxKeySize))
+		}
+	}
+
+	return report, nil
+}
+
+// lookupCharset returns the name, the collation, and the maximum length of a character in bytes of a character set.
+// If collation is blank, the default collation of the character set is returned.
+func (b *MySQLBox) lookupCharset(ctx context.Context, charset string, collation string) (string, string, int64,
+	error) {
+	var name, defaultCollation string
+	var maxLen int64
+	query := "SELECT character_set_name, default_collate_name, maxlen FROM information_schema.character_sets " +
+		"WHERE character_set_name = ?"
+	err := b.db.QueryRowContext(ctx, query, charset).Scan(&name, &defaultCollation, &maxLen)
+
+	// Since MySQL 8.0.30, utf8 is only an alias of utf8mb3.
+	if errors.Is(err, sql.ErrNoRows) && strings.EqualFold(charset, "utf8") {
+		err = b.db.QueryRowContext(ctx, query, "utf8mb3").Scan(&name, &defaultCollation, &maxLen)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", 0, fmt.Errorf("unknown character set %s", charset)
+	}
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	if collation == "" {
+		return name, defaultCollation, maxLen, nil
+	}
+
+	var count int
+	err = b.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM information_schema.collations "+
+		"WHERE collation_name = ? AND character_set_name = ?", collation, name).Scan(&count)
+	if err != nil {
+		return "", "", 0, err
+	}
+	if count == 0 {
+		return "", "", 0, fmt.Errorf("unknown collation %s of character set %s", collation, name)
+	}
+
+	return name, strings.ToLower(collation), maxLen, nil
+}
+
+// charsetColumns returns the string columns of a table.
+func (b *MySQLBox) charsetColumns(ctx context.Context, table string) ([]charsetColumn, error) {
+	rows, err := b.db.QueryContext(ctx, `SELECT c.column_name, c.column_type, c.data_type, c.character_set_name,
+			c.collation_name, COALESCE(c.character_maximum_length, 0), cs.maxlen
+		FROM information_schema.columns c
+		JOIN information_schema.character_sets cs ON cs.character_set_name = c.character_set_name
+		WHERE c.table_schema = ? AND c.table_name = ?
+		ORDER BY c.ordinal_position`, b.databaseName, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []charsetColumn
+	for rows.Next() {
+		var c charsetColumn
+		err := rows.Scan(&c.Name, &c.Type, &c.dataType, &c.Charset, &c.Collation, &c.maxChars, &c.maxLen)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, c)
+	}
+
+	return columns, rows.Err()
+}
+
+// charsetRowSize returns the size in bytes of the CHAR and VARCHAR columns of a row.
+func charsetRowSize(columns []charsetColumn) int64 {
+	var size int64
+	for _, column := range columns {
+		switch strings.ToLower(column.dataType) {
+		case "char", "varchar":
+			size += column.maxChars * column.maxLen
+		}
+	}
+
+	return size
+}
+
+// charsetKeySize is the size of the string columns of an index key.
+type charsetKeySize struct {
+	index string
+	size  int64
+}
+
+// charsetKeySizes returns the size in bytes of the string columns of each index key of a table.
+func (b *MySQLBox) charsetKeySizes(ctx context.Context, table string, columns []charsetColumn) ([]charsetKeySize,
+	error) {
+	byName := map[string]charsetColumn{}
+	for _, column := range columns {
+		byName[strings.ToLower(column.Name)] = column
+	}
+
+	rows, err := b.db.QueryContext(ctx, `SELECT index_name, COALESCE(column_name, ''), COALESCE(sub_part, 0)
+		FROM information_schema.statistics
+		WHERE table_schema = ? AND table_name = ?
+		ORDER BY index_name, seq_in_index`, b.databaseName, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []charsetKeySize
+	for rows.Next() {
+		var index, name string
+		var subPart int64
+		err := rows.Scan(&index, &name, &subPart)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(keys) == 0 || keys[len(keys)-1].index != index {
+			keys = append(keys, charsetKeySize{index: index})
+		}
+
+		column, ok := byName[strings.ToLower(name)]
+		if !ok {
+			continue
+		}
+
+		chars := column.maxChars
+		if subPart > 0 {
+			chars = subPart
+		}
+		keys[len(keys)-1].size += chars * column.maxLen
+	}
+
+	return keys, rows.Err()
+}
+
+// alterWithProgress runs an ALTER TABLE statement and calls progress with the work done until it completes. The
+// progress is read from the InnoDB ALTER TABLE stages in performance_schema, which are enabled first.
+func (b *MySQLBox) alterWithProgress(ctx context.Context, query string, progress CharsetProgress) error {
+	if progress == nil {
+		_, err := b.db.ExecContext(ctx, query)
+		return err
+	}
+
+	conn, err := b.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var threadID int64
+	err = conn.QueryRowContext(ctx, "SELECT thread_id FROM performance_schema.threads "+
+		"WHERE processlist_id = CONNECTION_ID()").Scan(&threadID)
+	tracking := err == nil && b.enableAlterStages(ctx) == nil
+
+	var total int64
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	if tracking {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ticker := time.NewTicker(charsetProgressInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+				}
+
+				var completed, estimated sql.NullInt64
+				err := b.db.QueryRowContext(ctx, `SELECT work_completed, work_estimated
+					FROM performance_schema.events_stages_current
+					WHERE thread_id = ? AND work_estimated IS NOT NULL`, threadID).Scan(&completed, &estimated)
+				if err != nil || estimated.Int64 <= 0 {
+					continue
+				}
+
+				total = estimated.Int64
+				progress(completed.Int64, total)
+			}
+		}()
+	}
+
+	_, err = conn.ExecContext(ctx, query)
+	close(done)
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+
+	if total == 0 {
+		total = 1
+	}
+	progress(total, total)
+
+	return nil
+}
+
+// enableAlterStages enables the instruments and consumers of performance_schema that report the progress of InnoDB
+// ALTER TABLE statements.
+func (b *MySQLBox) enableAlterStages(ctx context.Context) error {
+	_, err := b.db.ExecContext(ctx, "UPDATE performance_schema.setup_instruments SET enabled = 'YES', timed = 'YES' "+
+		"WHERE name LIKE 'stage/innodb/alter%'")
+	if err != nil {
+		return err
+	}
+
+	_, err = b.db.ExecContext(ctx, "UPDATE performance_schema.setup_consumers SET enabled = 'YES' "+
+		"WHERE name LIKE 'events_stages_%'")
+
+	return err
+}
@@ -0,0 +1,37 @@
+package mysqlbox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCharsetRowSize(t *testing.T) {
+	columns := []charsetColumn{
+		{dataType: "varchar", maxChars: 255, maxLen: 4},
+		{dataType: "CHAR", maxChars: 10, maxLen: 3},
+		{dataType: "text", maxChars: 65535, maxLen: 4},
+	}
+	require.Equal(t, int64(255*4+10*3), charsetRowSize(columns))
+}
+
+func TestCharsetReport(t *testing.T) {
+	report := &CharsetReport{
+		Table:     "comments",
+		Charset:   "latin1",
+		Collation: "latin1_swedish_ci",
+		Columns: []CharsetColumn{
+			{Name: "title", Type: "varchar(100)", Charset: "utf8mb4"},
+			{Name: "body", Type: "text", Charset: "utf8mb4", LossyRows: 2},
+		},
+	}
+	require.False(t, report.Safe())
+	require.Equal(t, "converting comments to latin1 (latin1_swedish_ci): 2 columns\n"+
+		"column body (text utf8mb4): 2 rows cannot be converted", report.String())
+
+	report.Columns[1].LossyRows = 0
+	require.True(t, report.Safe())
+
+	report.Warnings = []string{"index title would be 1020 bytes, more than the maximum of 767 bytes"}
+	require.False(t, report.Safe())
+}
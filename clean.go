@@ -0,0 +1,289 @@
+package mysqlbox
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// CleanProgress is called by CleanAllTablesContext() after each table is truncated, with the number of truncated
+// tables, the number of tables to truncate, and the name of the truncated table.
+type CleanProgress func(done int, total int, table string)
+
+// CleanAllTables truncates all tables in the Database, except those provided in Config.DoNotCleanTables.
+// Foreign key checks are disabled while the tables are truncated. The tables are truncated concurrently using
+// Config.CleanWorkers connections. If Config.MaintenanceEvery is set, maintenance is run after the tables are
+// truncated.
+func (b *MySQLBox) CleanAllTables() error {
+	return b.CleanAllTablesContext(context.Background(), nil)
+}
+
+// CleanAllTablesContext truncates all tables in the Database like CleanAllTables(), and stops when the context is
+// done. If the context has a deadline, the lock_wait_timeout of the truncating connections is set to the remaining
+// time, so that a TRUNCATE waiting for a metadata lock held by a leaked transaction fails on the server too, and the
+// returned error wraps ErrTimeout. If progress is not nil, it is called after each table is truncated.
+func (b *MySQLBox) CleanAllTablesContext(ctx context.Context, progress CleanProgress) error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	started := time.Now()
+	err := b.cleanAllTables(ctx, progress)
+	recordClean(time.Since(started))
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("cleaning tables did not complete: %s: %w", err.Error(), ErrTimeout)
+	}
+
+	return err
+}
+
+// MustCleanAllTablesContext truncates all tables in the Database, and stops when the context is done.
+func (b *MySQLBox) MustCleanAllTablesContext(ctx context.Context, progress CleanProgress) {
+	err := b.CleanAllTablesContext(ctx, progress)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// cleanAllTables truncates the tables of CleanAllTablesContext() and runs the maintenance.
+func (b *MySQLBox) cleanAllTables(ctx context.Context, progress CleanProgress) error {
+	tables, err := b.tablesToClean(ctx)
+	if err != nil {
+		return err
+	}
+
+	if b.killLockHolders && len(tables) > 0 {
+		err := b.killMetadataLockHolders(ctx, tables)
+		if err != nil {
+			return err
+		}
+	}
+
+	var done int
+	var doneMu sync.Mutex
+	truncated := func(table string) {
+		if progress == nil {
+			return
+		}
+
+		doneMu.Lock()
+		defer doneMu.Unlock()
+
+		done++
+		progress(done, len(tables), table)
+	}
+
+	workers := b.cleanWorkers
+	if workers <= 0 {
+		workers = 1
+	}
+	if workers > len(tables) {
+		workers = len(tables)
+	}
+
+	tableCh := make(chan string)
+	errCh := make(chan error, workers)
+	var wg sync.WaitGroup
+
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errCh <- b.withoutForeignKeyChecks(ctx, func(conn *sql.Conn) error {
+				restore, firstErr := setLockWaitTimeout(ctx, conn)
+				defer restore()
+
+				for table := range tableCh {
+					if firstErr != nil {
+						continue
+					}
+
+					query := fmt.Sprintf("TRUNCATE TABLE %s", quoteIdent(table))
+					_, err := conn.ExecContext(ctx, query)
+					if err != nil {
+						firstErr = fmt.Errorf("truncate table %s failed: %w", table, err)
+						continue
+					}
+
+					truncated(table)
+				}
+
+				return firstErr
+			})
+
+			// Drain the remaining tables if the worker could not get a connection.
+			for range tableCh {
+			}
+		}()
+	}
+
+	for _, table := range tables {
+		tableCh <- table
+	}
+	close(tableCh)
+	wg.Wait()
+	close(errCh)
+
+	for err := range errCh {
+		if err != nil {
+			return err
+		}
+	}
+
+	err = b.maintainAfterClean(ctx)
+	if err != nil {
+		return fmt.Errorf("maintenance failed: %w", err)
+	}
+
+	return nil
+}
+
+// setLockWaitTimeout sets the lock_wait_timeout of the connection to the time remaining until the deadline of the
+// context, rounded up to seconds. It returns a function that restores the default timeout, or discards the connection
+// if the timeout cannot be restored. It does nothing if the context has no deadline.
+func setLockWaitTimeout(ctx context.Context, conn *sql.Conn) (func(), error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return func() {}, nil
+	}
+
+	seconds := int64(math.Ceil(time.Until(deadline).Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	restore := func() {
+		_, err := conn.ExecContext(context.Background(), "SET SESSION lock_wait_timeout = DEFAULT")
+		if err != nil {
+			// Do not return a connection with a short timeout to the pool.
+			_ = conn.Raw(func(interface{}) error {
+				return driver.ErrBadConn
+			})
+		}
+	}
+
+	_, err := conn.ExecContext(ctx, fmt.Sprintf("SET SESSION lock_wait_timeout = %d", seconds))
+	return restore, err
+}
+
+// tablesToClean returns the base tables in the Database, except those provided in Config.DoNotCleanTables.
+func (b *MySQLBox) tablesToClean(ctx context.Context) ([]string, error) {
+	conn, err := b.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	tables, err := baseTables(ctx, conn, b.databaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	excludedTables := map[string]bool{}
+	for _, table := range b.doNotCleanTables {
+		excludedTables[table] = true
+	}
+
+	var result []string
+	for _, table := range tables {
+		if !excludedTables[table] {
+			result = append(result, table)
+		}
+	}
+
+	return result, nil
+}
+
+// MustCleanAllTables truncates all tables in the Database, except those provided in Config.DoNotCleanTables.
+func (b *MySQLBox) MustCleanAllTables() {
+	err := b.CleanAllTables()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// CleanTables truncates the specified tables in the Database. Foreign key checks are disabled while the tables
+// are truncated.
+func (b *MySQLBox) CleanTables(tables ...string) error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	ctx := context.Background()
+	return b.withoutForeignKeyChecks(ctx, func(conn *sql.Conn) error {
+		for _, table := range tables {
+			query := fmt.Sprintf("TRUNCATE TABLE %s", quoteIdent(table))
+			_, err := conn.ExecContext(ctx, query)
+			if err != nil {
+				b.logger.Printf("truncate table failed (%s): %s", table, err.Error())
+			}
+		}
+
+		return nil
+	})
+}
+
+// MustCleanTables truncates the specified tables in the Database.
+func (b *MySQLBox) MustCleanTables(tables ...string) {
+	err := b.CleanTables(tables...)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// withoutForeignKeyChecks calls fn with a dedicated connection that has foreign key checks disabled. The checks
+// are enabled again before the connection is returned to the pool.
+func (b *MySQLBox) withoutForeignKeyChecks(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := b.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 0")
+	if err != nil {
+		return err
+	}
+
+	fnErr := fn(conn)
+
+	_, err = conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 1")
+	if err != nil {
+		// Do not return a connection with disabled checks to the pool.
+		_ = conn.Raw(func(interface{}) error {
+			return driver.ErrBadConn
+		})
+		if fnErr == nil {
+			fnErr = err
+		}
+	}
+
+	return fnErr
+}
+
+// baseTables returns the names of the base tables (not views) in a database.
+func baseTables(ctx context.Context, conn *sql.Conn, database string) ([]string, error) {
+	query := "SELECT table_name FROM information_schema.tables WHERE table_schema = ? AND table_type = 'BASE TABLE'"
+	rows, err := conn.QueryContext(ctx, query, database)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		err := rows.Scan(&table)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+
+	return tables, rows.Err()
+}
@@ -0,0 +1,152 @@
+package mysqlbox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+)
+
+const (
+	// containerLabel is set on all containers created by MySQLBox.
+	containerLabel = "com.github.virgild.mysqlbox"
+
+	// pidLabel contains the ID of the process that created the container.
+	pidLabel = "com.github.virgild.mysqlbox.pid"
+
+	// hostnameLabel contains the hostname of the machine where the container was created.
+	hostnameLabel = "com.github.virgild.mysqlbox.hostname"
+
+	// retainLabel contains the duration that a stopped container is kept for inspection (see Config.RetainFor).
+	retainLabel = "com.github.virgild.mysqlbox.retain"
+)
+
+// CleanupConfig contains settings for Cleanup.
+type CleanupConfig struct {
+	// MaxAge specifies the age after which a MySQLBox container is removed even if its owning process is still
+	// running. If zero, containers are only removed when their owning process is gone.
+	MaxAge time.Duration
+}
+
+// Cleanup removes MySQLBox containers that were left running by crashed or killed processes. A container is removed
+// when the process that created it is no longer running on this host, or when it is older than
+// CleanupConfig.MaxAge. Containers created on other hosts are only removed by age. Stopped containers that were
+// retained with Config.RetainFor are removed when their retention period is over. Cleanup returns the IDs of the
+// removed containers.
+func Cleanup(c *CleanupConfig) ([]string, error) {
+	if c == nil {
+		c = &CleanupConfig{}
+	}
+
+	cli, err := newDockerClient()
+	if err != nil {
+		return nil, err
+	}
+	defer cli.Close()
+
+	return cleanupContainers(context.Background(), cli, c)
+}
+
+// MustCleanup is the same as Cleanup() but panics instead of returning an error.
+func MustCleanup(c *CleanupConfig) []string {
+	removed, err := Cleanup(c)
+	if err != nil {
+		panic(err)
+	}
+
+	return removed
+}
+
+// cleanupContainers removes the orphaned MySQLBox containers using the provided Docker client.
+func cleanupContainers(ctx context.Context, cli *client.Client, c *CleanupConfig) ([]string, error) {
+	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", containerLabel)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing containers: %w", err)
+	}
+
+	hostname, _ := os.Hostname()
+	now := time.Now()
+
+	var removed []string
+	for _, ctr := range containers {
+		if ctr.Labels[retainLabel] != "" && ctr.State != "running" {
+			info, err := cli.ContainerInspect(ctx, ctr.ID)
+			if client.IsErrNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return removed, fmt.Errorf("error inspecting container %s: %w", ctr.ID, err)
+			}
+
+			// Containers that were never started have no finish time.
+			stopped, err := time.Parse(time.RFC3339Nano, info.State.FinishedAt)
+			if err != nil || stopped.IsZero() {
+				stopped = time.Unix(ctr.Created, 0)
+			}
+
+			if !retentionExpired(ctr.Labels[retainLabel], stopped, now) {
+				continue
+			}
+		} else if !isOrphaned(ctr, hostname, now, c.MaxAge) {
+			continue
+		}
+
+		err := cli.ContainerRemove(ctx, ctr.ID, types.ContainerRemoveOptions{Force: true})
+		if err != nil && !client.IsErrNotFound(err) {
+			return removed, fmt.Errorf("error removing container %s: %w", ctr.ID, err)
+		}
+
+		removed = append(removed, ctr.ID)
+	}
+
+	return removed, nil
+}
+
+// isOrphaned returns true if the container should be removed by Cleanup.
+func isOrphaned(ctr types.Container, hostname string, now time.Time, maxAge time.Duration) bool {
+	if maxAge > 0 && now.Sub(time.Unix(ctr.Created, 0)) > maxAge {
+		return true
+	}
+
+	if hostname == "" || ctr.Labels[hostnameLabel] != hostname {
+		return false
+	}
+
+	pid, err := strconv.Atoi(ctr.Labels[pidLabel])
+	if err != nil {
+		return false
+	}
+
+	return !processRunning(pid)
+}
+
+// retentionExpired returns true if the retention period in a retain label has passed since the container stopped. An
+// invalid retention period has always passed.
+func retentionExpired(label string, stopped time.Time, now time.Time) bool {
+	retain, err := time.ParseDuration(label)
+	if err != nil {
+		return true
+	}
+
+	return now.Sub(stopped) > retain
+}
+
+// newDockerClient returns a Docker client configured from the environment.
+func newDockerClient() (*client.Client, error) {
+	cli, err := client.NewClientWithOpts(client.FromEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	cli.NegotiateAPIVersion(context.Background())
+
+	return cli, nil
+}
@@ -0,0 +1,60 @@
+package mysqlbox
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsOrphaned(t *testing.T) {
+	now := time.Now()
+
+	container := func(hostname string, pid int, age time.Duration) types.Container {
+		return types.Container{
+			Created: now.Add(-age).Unix(),
+			Labels: map[string]string{
+				containerLabel: "1",
+				hostnameLabel:  hostname,
+				pidLabel:       strconv.Itoa(pid),
+			},
+		}
+	}
+
+	t.Run("owner_running", func(t *testing.T) {
+		ctr := container("host-a", os.Getpid(), time.Minute)
+		require.False(t, isOrphaned(ctr, "host-a", now, 0))
+	})
+
+	t.Run("owner_gone", func(t *testing.T) {
+		ctr := container("host-a", 1<<30, time.Minute)
+		require.True(t, isOrphaned(ctr, "host-a", now, 0))
+	})
+
+	t.Run("other_host", func(t *testing.T) {
+		ctr := container("host-b", 1<<30, time.Minute)
+		require.False(t, isOrphaned(ctr, "host-a", now, 0))
+	})
+
+	t.Run("max_age", func(t *testing.T) {
+		ctr := container("host-b", os.Getpid(), time.Hour)
+		require.True(t, isOrphaned(ctr, "host-a", now, time.Minute))
+		require.False(t, isOrphaned(ctr, "host-a", now, 2*time.Hour))
+	})
+
+	t.Run("no_labels", func(t *testing.T) {
+		ctr := types.Container{Created: now.Unix()}
+		require.False(t, isOrphaned(ctr, "host-a", now, time.Minute))
+	})
+}
+
+func TestRetentionExpired(t *testing.T) {
+	now := time.Now()
+
+	require.False(t, retentionExpired("30m0s", now.Add(-10*time.Minute), now))
+	require.True(t, retentionExpired("30m0s", now.Add(-time.Hour), now))
+	require.True(t, retentionExpired("forever", now, now))
+}
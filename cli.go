@@ -0,0 +1,95 @@
+package mysqlbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// CLIResult is the output of the mysql client run in the container.
+type CLIResult struct {
+	// Stdout is the standard output of the client.
+	Stdout string
+
+	// Stderr is the standard error of the client.
+	Stderr string
+
+	// ExitCode is the exit code of the client.
+	ExitCode int
+}
+
+// MySQLCLI runs the mysql client inside the container as root with the Database as the default database, and returns
+// its output and exit code. This is useful for scripts that are easier to run with the client than with database/sql,
+// e.g. scripts with DELIMITER or SOURCE commands, or queries with \G output. A non-zero exit code is not an error.
+//
+//	res, err := box.MySQLCLI("-e", "SHOW ENGINE INNODB STATUS\\G")
+func (b *MySQLBox) MySQLCLI(args ...string) (*CLIResult, error) {
+	if b == nil {
+		return nil, errors.New("mysqlbox is nil")
+	}
+
+	return b.runMySQLCLI(context.Background(), nil, args)
+}
+
+// MustMySQLCLI runs the mysql client inside the container and returns its output and exit code.
+func (b *MySQLBox) MustMySQLCLI(args ...string) *CLIResult {
+	res, err := b.MySQLCLI(args...)
+	if err != nil {
+		panic(err)
+	}
+
+	return res
+}
+
+// RunSQLFile runs an SQL script file from the host with the mysql client inside the container, against the Database.
+// The file is streamed to the client, so large dumps do not need to fit in memory. It returns an error containing
+// the client's standard error if the client exits with a non-zero exit code.
+func (b *MySQLBox) RunSQLFile(path string) (*CLIResult, error) {
+	if b == nil {
+		return nil, errors.New("mysqlbox is nil")
+	}
+
+	f, err := os.Open(path) // #nosec G304
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	res, err := b.runMySQLCLI(context.Background(), f, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.ExitCode != 0 {
+		return res, fmt.Errorf("mysql client exited with code %d: %s", res.ExitCode, strings.TrimSpace(res.Stderr))
+	}
+
+	return res, nil
+}
+
+// MustRunSQLFile runs an SQL script file from the host with the mysql client inside the container.
+func (b *MySQLBox) MustRunSQLFile(path string) *CLIResult {
+	res, err := b.RunSQLFile(path)
+	if err != nil {
+		panic(err)
+	}
+
+	return res
+}
+
+// runMySQLCLI runs the mysql client inside the container with the arguments. The root password is passed in the
+// environment, so that it does not appear in the process list. If stdin is not nil, it is the input of the client.
+func (b *MySQLBox) runMySQLCLI(ctx context.Context, stdin io.Reader, args []string) (*CLIResult, error) {
+	cmd := append([]string{"mysql", "--user=root", "--database=" + b.databaseName}, args...)
+	env := []string{"MYSQL_PWD=" + b.rootPassword}
+
+	stdout, stderr, exitCode, err := b.execInContainer(ctx, cmd, env, stdin)
+	if err != nil {
+		return nil, fmt.Errorf("error running mysql client: %w", err)
+	}
+
+	return &CLIResult{Stdout: string(stdout), Stderr: string(stderr), ExitCode: exitCode}, nil
+}
@@ -0,0 +1,162 @@
+package mysqlbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// CloneDatabase creates a new database with the given name and copies the tables and rows of the Database into it.
+// This allows the Database, populated once from Config.InitialSQL, to be used as a template for giving each test its
+// own database on a single container. It returns a DB connected to the new database and its DSN. Views, triggers,
+// and stored routines are not copied.
+func (b *MySQLBox) CloneDatabase(name string) (*sql.DB, string, error) {
+	if b == nil {
+		return nil, "", errors.New("mysqlbox is nil")
+	}
+
+	ctx := context.Background()
+	err := b.copyDatabase(ctx, b.databaseName, name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return b.ConnectDB(name)
+}
+
+// MustCloneDatabase creates a new database with the given name and copies the tables and rows of the Database into it.
+func (b *MySQLBox) MustCloneDatabase(name string) (*sql.DB, string) {
+	db, dsn, err := b.CloneDatabase(name)
+	if err != nil {
+		panic(err)
+	}
+
+	return db, dsn
+}
+
+// IsolatedDB clones the Database into a uniquely named database and returns a DB connected to it. The database is
+// dropped when the test finishes. It is meant for parallel tests that each need their own copy of the data.
+func (b *MySQLBox) IsolatedDB(t testing.TB) *sql.DB {
+	t.Helper()
+
+	name := fmt.Sprintf("%s_%s", b.databaseName, randStr(8))
+	db, _, err := b.CloneDatabase(name)
+	if err != nil {
+		t.Fatalf("error cloning database: %s", err.Error())
+	}
+
+	t.Cleanup(func() {
+		db.Close()
+		err := b.dropDatabase(context.Background(), name)
+		if err != nil {
+			t.Errorf("error dropping database %s: %s", name, err.Error())
+		}
+	})
+
+	return db
+}
+
+// copyDatabase creates the dst database and copies the tables and rows of the src database into it.
+func (b *MySQLBox) copyDatabase(ctx context.Context, src string, dst string) error {
+	err := b.createDatabase(ctx, dst)
+	if err != nil {
+		return err
+	}
+
+	return b.withoutForeignKeyChecks(ctx, func(conn *sql.Conn) error {
+		tables, err := baseTables(ctx, conn, src)
+		if err != nil {
+			return err
+		}
+
+		_, err = conn.ExecContext(ctx, fmt.Sprintf("USE %s", quoteIdent(dst)))
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_, _ = conn.ExecContext(ctx, fmt.Sprintf("USE %s", quoteIdent(b.databaseName)))
+		}()
+
+		for _, table := range tables {
+			err := copyTable(ctx, conn, src, dst, table)
+			if err != nil {
+				return fmt.Errorf("error copying table %s: %w", table, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// copyTable creates a table in the dst database with the definition of the same table in the src database and
+// copies its rows. The connection must have dst as its default database, so that foreign keys in the table definition
+// reference the dst tables.
+func copyTable(ctx context.Context, conn *sql.Conn, src string, dst string, table string) error {
+	err := createTableLike(ctx, conn, src, table)
+	if err != nil {
+		return err
+	}
+
+	return copyRows(ctx, conn, src, dst, table)
+}
+
+// createTableLike creates a table in the default database of the connection with the definition of the same table in
+// the src database.
+func createTableLike(ctx context.Context, conn *sql.Conn, src string, table string) error {
+	var name, ddl string
+	query := fmt.Sprintf("SHOW CREATE TABLE %s.%s", quoteIdent(src), quoteIdent(table))
+	err := conn.QueryRowContext(ctx, query).Scan(&name, &ddl)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.ExecContext(ctx, ddl)
+	return err
+}
+
+// copyRows copies the rows of a table in the src database into the same table in the dst database.
+func copyRows(ctx context.Context, conn *sql.Conn, src string, dst string, table string) error {
+	columns, err := insertableColumns(ctx, conn, src, table)
+	if err != nil {
+		return err
+	}
+
+	quoted := make([]string, len(columns))
+	for n, column := range columns {
+		quoted[n] = quoteIdent(column)
+	}
+	columnList := strings.Join(quoted, ", ")
+
+	query := fmt.Sprintf("INSERT INTO %s.%s (%s) SELECT %s FROM %s.%s", quoteIdent(dst), quoteIdent(table),
+		columnList, columnList, quoteIdent(src), quoteIdent(table))
+	_, err = conn.ExecContext(ctx, query)
+
+	return err
+}
+
+// insertableColumns returns the columns of a table that are not generated.
+func insertableColumns(ctx context.Context, conn *sql.Conn, database string, table string) ([]string, error) {
+	query := `SELECT column_name FROM information_schema.columns
+		WHERE table_schema = ? AND table_name = ? AND extra NOT LIKE '%GENERATED%'
+		ORDER BY ordinal_position`
+	rows, err := conn.QueryContext(ctx, query, database, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var column string
+		err := rows.Scan(&column)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+
+	return columns, rows.Err()
+}
@@ -0,0 +1,92 @@
+package mysqlbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+)
+
+// commitDumpFile is the file in the container where CommitToImage() writes the dump of the databases. The MySQL image
+// entrypoint loads it when a container of the committed image is started, before the other initial scripts.
+const commitDumpFile = "/docker-entrypoint-initdb.d/000-mysqlbox-commit.sql"
+
+// CommitOptions contains settings for CommitToImage.
+type CommitOptions struct {
+	// LockTables dumps the databases while all tables are locked with FLUSH TABLES WITH READ LOCK, so that the dump
+	// is consistent for non-transactional tables too, e.g. MyISAM tables. By default, the dump is made in a single
+	// transaction, which is only consistent for InnoDB tables but does not block writes.
+	LockTables bool
+
+	// Comment is an optional commit message of the image.
+	Comment string
+}
+
+// CommitToImage commits the current state of the box to a local Docker image with the tag, e.g.
+// "mysqlbox-failing:latest", and returns the image ID. A container started from the image, e.g. with Config.Image
+// set to the tag, has the same databases and rows as the box, so that the state of a failing test can be shared.
+//
+// The MySQL data directory is a Docker volume, which is not included in a committed image. So the user databases are
+// dumped with mysqldump into the /docker-entrypoint-initdb.d directory of the container before the commit, and are
+// loaded by the MySQL image entrypoint when a container of the image is started. MySQL users and grants are not
+// included. The container must be started without initial scripts that create the same tables, since those run after
+// the dump is loaded. The box keeps running.
+func (b *MySQLBox) CommitToImage(ctx context.Context, tag string, opts *CommitOptions) (string, error) {
+	if b == nil {
+		return "", errors.New("mysqlbox is nil")
+	}
+
+	if opts == nil {
+		opts = &CommitOptions{}
+	}
+
+	consistency := "--single-transaction"
+	if opts.LockTables {
+		consistency = "--lock-all-tables"
+	}
+
+	// The databases are listed in the container, so that the root password is only passed in the environment.
+	script := fmt.Sprintf(`set -e
+databases=$(mysql --user=root -N -e "SELECT schema_name FROM information_schema.schemata
+	WHERE schema_name NOT IN ('mysql', 'sys', 'information_schema', 'performance_schema')")
+mysqldump --user=root %s --routines --triggers --events --databases $databases > %s`, consistency,
+		commitDumpFile)
+
+	stdout, stderr, exitCode, err := b.execInContainer(ctx, []string{"sh", "-c", script},
+		[]string{"MYSQL_PWD=" + b.rootPassword}, nil)
+	if err != nil {
+		return "", fmt.Errorf("error dumping databases: %w", err)
+	}
+	if exitCode != 0 {
+		return "", fmt.Errorf("error dumping databases: exit code %d: %s", exitCode,
+			strings.TrimSpace(string(stderr)+string(stdout)))
+	}
+
+	// Remove the dump from the container after the commit, so that it does not take up space.
+	defer func() {
+		_, _, _, _ = b.execInContainer(context.Background(), []string{"rm", "-f", commitDumpFile}, nil, nil)
+	}()
+
+	resp, err := b.cli.ContainerCommit(ctx, b.containerID, types.ContainerCommitOptions{
+		Reference: tag,
+		Comment:   opts.Comment,
+		Pause:     true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error committing container: %w", err)
+	}
+
+	return resp.ID, nil
+}
+
+// MustCommitToImage commits the current state of the box to a local Docker image with the tag.
+func (b *MySQLBox) MustCommitToImage(ctx context.Context, tag string, opts *CommitOptions) string {
+	id, err := b.CommitToImage(ctx, tag, opts)
+	if err != nil {
+		panic(err)
+	}
+
+	return id
+}
@@ -0,0 +1,230 @@
+package mysqlbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testVariable is the user variable that identifies the test of a connection made with a DSN from DSNForTest().
+const testVariable = "mysqlbox_test"
+
+// Connection is a client connection to the MySQL server.
+type Connection struct {
+	// ID is the connection ID.
+	ID int64
+
+	// User is the MySQL user of the connection.
+	User string
+
+	// Host is the client host and port.
+	Host string
+
+	// Database is the default database of the connection.
+	Database string
+
+	// Command is the type of command the connection is executing, e.g. "Query" or "Sleep".
+	Command string
+
+	// Time is the time the connection has been in its current state.
+	Time time.Duration
+
+	// State is the state of the connection.
+	State string
+
+	// Info is the statement the connection is executing.
+	Info string
+
+	// ProgramName is the program_name connection attribute sent by the client.
+	ProgramName string
+
+	// Test is the name of the test of a connection made with a DSN from DSNForTest().
+	Test string
+
+	// Attributes contains the connection attributes sent by the client.
+	Attributes map[string]string
+}
+
+// String describes the connection.
+func (c Connection) String() string {
+	s := fmt.Sprintf("%d %s@%s (%s)", c.ID, c.User, c.Host, c.Command)
+	if c.Test != "" {
+		s += " test=" + c.Test
+	}
+	if c.ProgramName != "" {
+		s += " program=" + c.ProgramName
+	}
+
+	return s
+}
+
+// DSNForTest returns a DSN for connecting to the database as root that tags the connections with the name of the
+// test, so that they can be identified in the result of Connections().
+func (b *MySQLBox) DSNForTest(t testing.TB, database string) (string, error) {
+	if b == nil {
+		return "", errors.New("mysqlbox is nil")
+	}
+
+	return b.DSNFor("root", b.rootPassword, database, map[string]string{
+		"@" + testVariable: quoteString(t.Name()),
+	})
+}
+
+// MustDSNForTest returns a DSN for connecting to the database as root that tags the connections with the name of
+// the test.
+func (b *MySQLBox) MustDSNForTest(t testing.TB, database string) string {
+	dsn, err := b.DSNForTest(t, database)
+	if err != nil {
+		panic(err)
+	}
+
+	return dsn
+}
+
+// Connections returns the client connections to the MySQL server ordered by ID, except the connection used to
+// query them and the server's background threads. It requires the Performance Schema, which is enabled by default.
+func (b *MySQLBox) Connections() ([]Connection, error) {
+	if b == nil {
+		return nil, errors.New("mysqlbox is nil")
+	}
+
+	ctx := context.Background()
+	conn, err := b.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(ctx, `SELECT id, user, host, db, command, time, state, info
+		FROM information_schema.processlist WHERE id <> CONNECTION_ID() AND command <> 'Daemon'`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byID := map[int64]*Connection{}
+	var conns []*Connection
+	for rows.Next() {
+		var c Connection
+		var database, state, info sql.NullString
+		var seconds int64
+		err := rows.Scan(&c.ID, &c.User, &c.Host, &database, &c.Command, &seconds, &state, &info)
+		if err != nil {
+			return nil, err
+		}
+		c.Database = database.String
+		c.State = state.String
+		c.Info = info.String
+		c.Time = time.Duration(seconds) * time.Second
+		c.Attributes = map[string]string{}
+
+		byID[c.ID] = &c
+		conns = append(conns, &c)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	attrRows, err := conn.QueryContext(ctx, `SELECT processlist_id, attr_name, attr_value
+		FROM performance_schema.session_connect_attrs`)
+	if err != nil {
+		return nil, fmt.Errorf("error reading connection attributes: %w", err)
+	}
+	defer attrRows.Close()
+
+	for attrRows.Next() {
+		var id int64
+		var name string
+		var value sql.NullString
+		err := attrRows.Scan(&id, &name, &value)
+		if err != nil {
+			return nil, err
+		}
+
+		if c, ok := byID[id]; ok {
+			c.Attributes[name] = value.String
+			if name == "program_name" {
+				c.ProgramName = value.String
+			}
+		}
+	}
+	err = attrRows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	testRows, err := conn.QueryContext(ctx, `SELECT t.processlist_id, v.variable_value
+		FROM performance_schema.user_variables_by_thread v
+		JOIN performance_schema.threads t ON t.thread_id = v.thread_id
+		WHERE v.variable_name = ?`, testVariable)
+	if err != nil {
+		return nil, fmt.Errorf("error reading connection tests: %w", err)
+	}
+	defer testRows.Close()
+
+	for testRows.Next() {
+		var id sql.NullInt64
+		var name sql.NullString
+		err := testRows.Scan(&id, &name)
+		if err != nil {
+			return nil, err
+		}
+
+		if c, ok := byID[id.Int64]; ok {
+			c.Test = name.String
+		}
+	}
+	err = testRows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(conns, func(i, j int) bool {
+		return conns[i].ID < conns[j].ID
+	})
+
+	result := make([]Connection, len(conns))
+	for n, c := range conns {
+		result[n] = *c
+	}
+
+	return result, nil
+}
+
+// MustConnections returns the client connections to the MySQL server.
+func (b *MySQLBox) MustConnections() []Connection {
+	conns, err := b.Connections()
+	if err != nil {
+		panic(err)
+	}
+
+	return conns
+}
+
+// CheckNoOtherTestConnections fails the test if there are connections made with a DSN from DSNForTest() by other
+// tests. Calling it at the start of a test catches connections leaked by earlier tests that may interfere with it.
+func (b *MySQLBox) CheckNoOtherTestConnections(t testing.TB) {
+	t.Helper()
+
+	conns, err := b.Connections()
+	if err != nil {
+		t.Fatalf("error reading connections: %s", err.Error())
+	}
+
+	var others []string
+	for _, c := range conns {
+		if c.Test != "" && c.Test != t.Name() {
+			others = append(others, c.String())
+		}
+	}
+
+	if len(others) > 0 {
+		t.Errorf("connections from other tests: %s", strings.Join(others, "; "))
+	}
+}
@@ -0,0 +1,32 @@
+package mysqlbox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDSNForTest(t *testing.T) {
+	b := &MySQLBox{host: "127.0.0.1", port: 33060, rootPassword: "secret"}
+
+	dsn, err := b.DSNForTest(t, "app")
+	require.NoError(t, err)
+
+	cfg, err := mysql.ParseDSN(dsn)
+	require.NoError(t, err)
+	require.Equal(t, "root", cfg.User)
+	require.Equal(t, "secret", cfg.Passwd)
+	require.Equal(t, "app", cfg.DBName)
+	require.Equal(t, `'TestDSNForTest'`, cfg.Params["@mysqlbox_test"])
+}
+
+func TestConnectionString(t *testing.T) {
+	c := Connection{ID: 12, User: "root", Host: "172.17.0.1:51234", Command: "Sleep", Time: time.Second}
+	require.Equal(t, "12 root@172.17.0.1:51234 (Sleep)", c.String())
+
+	c.Test = "TestUsers"
+	c.ProgramName = "mysql"
+	require.Equal(t, "12 root@172.17.0.1:51234 (Sleep) test=TestUsers program=mysql", c.String())
+}
@@ -0,0 +1,181 @@
+package mysqlbox
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/docker/docker/api/types"
+)
+
+// CopyToContainer extracts a tar archive into a directory in the container. The directory must exist.
+func (b *MySQLBox) CopyToContainer(ctx context.Context, dir string, archive io.Reader) error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	return b.cli.CopyToContainer(ctx, b.containerID, dir, archive, types.CopyToContainerOptions{})
+}
+
+// MustCopyToContainer extracts a tar archive into a directory in the container.
+func (b *MySQLBox) MustCopyToContainer(ctx context.Context, dir string, archive io.Reader) {
+	err := b.CopyToContainer(ctx, dir, archive)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// CopyFromContainer returns a tar archive of a file or directory in the container. The caller must close it.
+func (b *MySQLBox) CopyFromContainer(ctx context.Context, srcPath string) (io.ReadCloser, error) {
+	if b == nil {
+		return nil, errors.New("mysqlbox is nil")
+	}
+
+	reader, _, err := b.cli.CopyFromContainer(ctx, b.containerID, srcPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return reader, nil
+}
+
+// MustCopyFromContainer returns a tar archive of a file or directory in the container.
+func (b *MySQLBox) MustCopyFromContainer(ctx context.Context, srcPath string) io.ReadCloser {
+	reader, err := b.CopyFromContainer(ctx, srcPath)
+	if err != nil {
+		panic(err)
+	}
+
+	return reader
+}
+
+// CopyFileToContainer copies a file from the host to a path in the container, e.g. a large dump that is loaded with
+// MySQLCLI(). The parent directory of the path must exist. The file is readable by all users in the container.
+func (b *MySQLBox) CopyFileToContainer(ctx context.Context, hostPath string, containerPath string) error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	f, err := os.Open(hostPath) // #nosec G304
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", hostPath)
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(pw)
+		err := tw.WriteHeader(&tar.Header{
+			Name:    path.Base(containerPath),
+			Mode:    0644,
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+		if err == nil {
+			_, err = io.Copy(tw, f)
+		}
+		if err == nil {
+			err = tw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	err = b.cli.CopyToContainer(ctx, b.containerID, path.Dir(containerPath), pr, types.CopyToContainerOptions{})
+	_ = pr.Close()
+
+	return err
+}
+
+// MustCopyFileToContainer copies a file from the host to a path in the container.
+func (b *MySQLBox) MustCopyFileToContainer(ctx context.Context, hostPath string, containerPath string) {
+	err := b.CopyFileToContainer(ctx, hostPath, containerPath)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// CopyFileFromContainer copies a file from the container to a path on the host, e.g. the general query log in
+// /var/lib/mysql/general-log.log. An existing file at the host path is replaced.
+func (b *MySQLBox) CopyFileFromContainer(ctx context.Context, containerPath string, hostPath string) error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	reader, _, err := b.cli.CopyFromContainer(ctx, b.containerID, containerPath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	tr := tar.NewReader(reader)
+	header, err := tr.Next()
+	if err != nil {
+		return fmt.Errorf("error reading archive of %s: %w", containerPath, err)
+	}
+
+	if header.Typeflag != tar.TypeReg {
+		return fmt.Errorf("%s is not a regular file", containerPath)
+	}
+
+	f, err := os.Create(hostPath) // #nosec G304
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(f, tr) // #nosec G110
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// MustCopyFileFromContainer copies a file from the container to a path on the host.
+func (b *MySQLBox) MustCopyFileFromContainer(ctx context.Context, containerPath string, hostPath string) {
+	err := b.CopyFileFromContainer(ctx, containerPath, hostPath)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// readContainerFile returns the contents of a file in the container.
+func (b *MySQLBox) readContainerFile(ctx context.Context, containerPath string) ([]byte, error) {
+	reader, _, err := b.cli.CopyFromContainer(ctx, b.containerID, containerPath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	tr := tar.NewReader(reader)
+	header, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("error reading archive of %s: %w", containerPath, err)
+	}
+
+	if header.Typeflag != tar.TypeReg {
+		return nil, fmt.Errorf("%s is not a regular file", containerPath)
+	}
+
+	var buf bytes.Buffer
+	_, err = io.Copy(&buf, tr) // #nosec G110
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,197 @@
+package mysqlbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// rowFormats are the InnoDB row formats that can be set with TableOptions.RowFormat.
+var rowFormats = []string{"DEFAULT", "DYNAMIC", "COMPACT", "REDUNDANT", "COMPRESSED"}
+
+// compressionAlgorithms are the InnoDB page compression algorithms that can be set with TableOptions.Compression.
+var compressionAlgorithms = []string{"zlib", "lz4", "none"}
+
+// TableOptions contains the storage options of a table created with CreateTable().
+type TableOptions struct {
+	// RowFormat is the InnoDB row format of the table: "DEFAULT", "DYNAMIC", "COMPACT", "REDUNDANT", or
+	// "COMPRESSED". If blank, the server default is used.
+	RowFormat string
+
+	// Compression is the InnoDB page compression algorithm of the table: "zlib", "lz4", or "none". Page
+	// compression requires MySQL 5.7.8 or later and a file system that supports hole punching.
+	Compression string
+
+	// Encryption encrypts the table at rest. It requires MySQL 5.7.11 or later with a keyring plugin or component.
+	Encryption bool
+}
+
+// CreateTable runs a CREATE TABLE statement with the storage options appended to it, e.g.
+//
+//	err := box.CreateTable("CREATE TABLE events (id INT PRIMARY KEY, body JSON)",
+//		&mysqlbox.TableOptions{RowFormat: "COMPRESSED"})
+//
+// The options are validated, and an error is returned before the table is created if the server does not support
+// them. This can be used to check that the DDL of an application works with the storage formats of the target server
+// version.
+func (b *MySQLBox) CreateTable(ddl string, opts *TableOptions) error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	if opts == nil {
+		opts = &TableOptions{}
+	}
+
+	query, err := opts.createTableQuery(ddl)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	err = b.checkTableOptions(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.db.ExecContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("error creating table: %w", err)
+	}
+
+	return nil
+}
+
+// MustCreateTable runs a CREATE TABLE statement with the storage options appended to it.
+func (b *MySQLBox) MustCreateTable(ddl string, opts *TableOptions) {
+	err := b.CreateTable(ddl, opts)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// createTableQuery returns the CREATE TABLE statement with the table options appended to it.
+func (o *TableOptions) createTableQuery(ddl string) (string, error) {
+	query := strings.TrimRight(strings.TrimSpace(ddl), "; \t\r\n")
+	if !isCreateTable(query) {
+		return "", errors.New("ddl is not a CREATE TABLE statement")
+	}
+
+	var options []string
+
+	if o.RowFormat != "" {
+		rowFormat, ok := matchOption(o.RowFormat, rowFormats)
+		if !ok {
+			return "", fmt.Errorf("unknown row format %q, must be one of %s", o.RowFormat,
+				strings.Join(rowFormats, ", "))
+		}
+		options = append(options, "ROW_FORMAT="+rowFormat)
+	}
+
+	if o.Compression != "" {
+		compression, ok := matchOption(o.Compression, compressionAlgorithms)
+		if !ok {
+			return "", fmt.Errorf("unknown compression %q, must be one of %s", o.Compression,
+				strings.Join(compressionAlgorithms, ", "))
+		}
+		options = append(options, "COMPRESSION="+quoteString(compression))
+	}
+
+	if o.Encryption {
+		options = append(options, "ENCRYPTION='Y'")
+	}
+
+	if len(options) == 0 {
+		return query, nil
+	}
+
+	return query + " " + strings.Join(options, " "), nil
+}
+
+// isCreateTable reports whether a statement is a CREATE TABLE or CREATE TEMPORARY TABLE statement.
+func isCreateTable(query string) bool {
+	fields := strings.Fields(strings.ToUpper(query))
+	if len(fields) < 3 || fields[0] != "CREATE" {
+		return false
+	}
+
+	return fields[1] == "TABLE" || fields[1] == "TEMPORARY" && fields[2] == "TABLE"
+}
+
+// checkTableOptions returns an error if the server does not support the table options.
+func (b *MySQLBox) checkTableOptions(ctx context.Context, opts *TableOptions) error {
+	if opts.Compression == "" && !opts.Encryption && !strings.EqualFold(opts.RowFormat, "COMPRESSED") {
+		return nil
+	}
+
+	version, err := b.serverVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	if opts.Compression != "" && !version.atLeast(5, 7, 8) {
+		return fmt.Errorf("MySQL %s does not support page compression", version)
+	}
+
+	// Compressed tables must be stored in their own tablespace unless a general tablespace is used.
+	if strings.EqualFold(opts.RowFormat, "COMPRESSED") {
+		var filePerTable int
+		err := b.db.QueryRowContext(ctx, "SELECT @@innodb_file_per_table").Scan(&filePerTable)
+		if err != nil {
+			return err
+		}
+		if filePerTable == 0 {
+			return errors.New("ROW_FORMAT=COMPRESSED requires innodb_file_per_table")
+		}
+	}
+
+	if opts.Encryption {
+		if !version.atLeast(5, 7, 11) {
+			return fmt.Errorf("MySQL %s does not support table encryption", version)
+		}
+
+		ok, err := b.hasKeyring(ctx, version)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("table encryption requires a keyring plugin or component")
+		}
+	}
+
+	return nil
+}
+
+// hasKeyring reports whether a keyring plugin is active, or since MySQL 8.0.24, a keyring component.
+func (b *MySQLBox) hasKeyring(ctx context.Context, version serverVersion) (bool, error) {
+	var plugins int
+	err := b.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM information_schema.plugins
+		WHERE plugin_name LIKE 'keyring%' AND plugin_status = 'ACTIVE'`).Scan(&plugins)
+	if err != nil {
+		return false, err
+	}
+	if plugins > 0 || !version.atLeast(8, 0, 24) {
+		return plugins > 0, nil
+	}
+
+	var components int
+	err = b.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM performance_schema.keyring_component_status
+		WHERE status_key = 'Component_status' AND status_value = 'Active'`).Scan(&components)
+	if err != nil {
+		return false, err
+	}
+
+	return components > 0, nil
+}
+
+// matchOption returns the option of the list that matches value case-insensitively.
+func matchOption(value string, options []string) (string, bool) {
+	for _, option := range options {
+		if strings.EqualFold(value, option) {
+			return option, true
+		}
+	}
+
+	return "", false
+}
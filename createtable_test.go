@@ -0,0 +1,46 @@
+package mysqlbox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateTableQuery(t *testing.T) {
+	ddl := "CREATE TABLE events (id INT PRIMARY KEY);\n"
+
+	t.Run("no_options", func(t *testing.T) {
+		query, err := (&TableOptions{}).createTableQuery(ddl)
+		require.NoError(t, err)
+		require.Equal(t, "CREATE TABLE events (id INT PRIMARY KEY)", query)
+	})
+
+	t.Run("all_options", func(t *testing.T) {
+		opts := &TableOptions{RowFormat: "dynamic", Compression: "LZ4", Encryption: true}
+		query, err := opts.createTableQuery(ddl)
+		require.NoError(t, err)
+		require.Equal(t, "CREATE TABLE events (id INT PRIMARY KEY) ROW_FORMAT=DYNAMIC COMPRESSION='lz4' "+
+			"ENCRYPTION='Y'", query)
+	})
+
+	t.Run("temporary_table", func(t *testing.T) {
+		query, err := (&TableOptions{RowFormat: "COMPACT"}).createTableQuery("create temporary table t (id INT)")
+		require.NoError(t, err)
+		require.Equal(t, "create temporary table t (id INT) ROW_FORMAT=COMPACT", query)
+	})
+
+	t.Run("not_create_table", func(t *testing.T) {
+		_, err := (&TableOptions{}).createTableQuery("DROP TABLE events")
+		require.Error(t, err)
+	})
+
+	t.Run("unknown_row_format", func(t *testing.T) {
+		_, err := (&TableOptions{RowFormat: "FIXED"}).createTableQuery(ddl)
+		require.ErrorContains(t, err, "unknown row format")
+	})
+
+	t.Run("unknown_compression", func(t *testing.T) {
+		_, err := (&TableOptions{Compression: "zstd"}).createTableQuery(ddl)
+		require.ErrorContains(t, err, "unknown compression")
+	})
+}
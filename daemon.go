@@ -0,0 +1,164 @@
+package mysqlbox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+)
+
+const (
+	// defaultDockerRetries is the number of retries of a failed container create or start when Config.DockerRetries
+	// is not set.
+	defaultDockerRetries = 3
+
+	// dockerRetryBackoff is the time to wait before the first retry of a failed container create or start. It is
+	// doubled for each retry, up to maxDockerRetryBackoff.
+	dockerRetryBackoff    = 500 * time.Millisecond
+	maxDockerRetryBackoff = 5 * time.Second
+)
+
+// transientDaemonErrors contains parts of the error messages of Docker daemon errors that are worth retrying.
+var transientDaemonErrors = []string{
+	"connection reset",
+	"broken pipe",
+	"unexpected eof",
+	"i/o timeout",
+}
+
+// dockerRetry contains the settings of retrying Docker daemon requests.
+type dockerRetry struct {
+	// logger receives the messages about retries.
+	logger Logger
+
+	// retries is the number of retries after transient errors.
+	retries int
+}
+
+// do calls fn until it succeeds, it returns an error that is not transient, or the retries are used up. Transient
+// errors are retried with exponential backoff. action describes the request in the log messages.
+func (r dockerRetry) do(ctx context.Context, action string, fn func() error, transient func(error) bool) error {
+	backoff := dockerRetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= r.retries || ctx.Err() != nil || !transient(err) {
+			return err
+		}
+
+		r.logger.Printf("%s failed, retrying in %s: %s", action, backoff, err.Error())
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > maxDockerRetryBackoff {
+			backoff = maxDockerRetryBackoff
+		}
+	}
+}
+
+// createContainer creates a container and retries transient daemon errors. A conflict with a container of the same
+// name is retried if that container is being removed, e.g. the auto-removed container of a box that was just stopped.
+func (r dockerRetry) createContainer(ctx context.Context, cli *client.Client, cfg *container.Config,
+	hostCfg *container.HostConfig, netCfg *network.NetworkingConfig, name string) (container.CreateResponse, error) {
+	var created container.CreateResponse
+	err := r.do(ctx, "creating container", func() error {
+		var err error
+		created, err = cli.ContainerCreate(ctx, cfg, hostCfg, netCfg, nil, name)
+		return err
+	}, func(err error) bool {
+		return isTransientDaemonError(err) || isStaleNameConflict(ctx, cli, name, err)
+	})
+
+	return created, err
+}
+
+// startContainer starts a container and retries transient daemon errors.
+func (r dockerRetry) startContainer(ctx context.Context, cli *client.Client, id string) error {
+	return r.do(ctx, "starting container", func() error {
+		return cli.ContainerStart(ctx, id, types.ContainerStartOptions{})
+	}, isTransientDaemonError)
+}
+
+// isTransientDaemonError reports whether a Docker daemon error is likely to go away when the request is retried,
+// e.g. because the connection to a daemon under load was reset. Errors returned by the daemon for invalid requests
+// are not transient.
+func isTransientDaemonError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errdefs.IsNotFound(err) || errdefs.IsConflict(err) || errdefs.IsInvalidParameter(err) ||
+		errdefs.IsUnauthorized(err) || errdefs.IsForbidden(err) {
+		return false
+	}
+
+	if errdefs.IsUnavailable(err) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	// The Docker client wraps transport errors in its own messages, e.g. "error during connect: Post ...: EOF".
+	message := strings.ToLower(err.Error())
+	if strings.HasSuffix(message, ": eof") {
+		return true
+	}
+	for _, part := range transientDaemonErrors {
+		if strings.Contains(message, part) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isPortConflict reports whether a container start error is caused by a host port that is already taken.
+func isPortConflict(err error) bool {
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "port is already allocated") ||
+		strings.Contains(message, "address already in use")
+}
+
+// isStaleNameConflict reports whether an error is a name conflict with a container that is going away: one that is
+// being removed, an exited auto-remove container, or a container that was created by this process but not started,
+// e.g. by a create request whose response was lost. Such a container is removed.
+func isStaleNameConflict(ctx context.Context, cli *client.Client, name string, err error) bool {
+	if name == "" || !errdefs.IsConflict(err) {
+		return false
+	}
+
+	ctr, err := cli.ContainerInspect(ctx, name)
+	if client.IsErrNotFound(err) {
+		return true
+	}
+	if err != nil || ctr.State == nil {
+		return false
+	}
+
+	switch ctr.State.Status {
+	case "removing":
+		return true
+	case "exited", "dead":
+		return ctr.HostConfig != nil && ctr.HostConfig.AutoRemove
+	case "created":
+		if ctr.Config == nil || ctr.Config.Labels[sessionLabel] != sessionID {
+			return false
+		}
+
+		err := cli.ContainerRemove(ctx, ctr.ID, types.ContainerRemoveOptions{Force: true})
+		return err == nil
+	}
+
+	return false
+}
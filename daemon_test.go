@@ -0,0 +1,106 @@
+package mysqlbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsTransientDaemonError(t *testing.T) {
+	tests := []struct {
+		err       error
+		transient bool
+	}{
+		{errors.New("error during connect: Post \"http://%2Fvar%2Frun%2Fdocker.sock/v1.42/containers/create\": EOF"),
+			true},
+		{errors.New("read unix @->/var/run/docker.sock: read: connection reset by peer"), true},
+		{fmt.Errorf("error reading response: %w", io.ErrUnexpectedEOF), true},
+		{errdefs.Unavailable(errors.New("daemon is shutting down")), true},
+		{errdefs.Conflict(errors.New("the container name \"/db\" is already in use")), false},
+		{errdefs.NotFound(errors.New("no such image: mysql:8")), false},
+		{errdefs.InvalidParameter(errors.New("invalid port specification")), false},
+		{errors.New("driver failed programming external connectivity: port is already allocated"), false},
+		{context.DeadlineExceeded, false},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.transient, isTransientDaemonError(test.err), test.err.Error())
+	}
+}
+
+func TestIsPortConflict(t *testing.T) {
+	require.True(t, isPortConflict(errors.New("Error response from daemon: driver failed programming external "+
+		"connectivity on endpoint db: Bind for 127.0.0.1:3306 failed: port is already allocated")))
+	require.True(t, isPortConflict(errors.New("Error starting userland proxy: listen tcp4 127.0.0.1:3306: bind: "+
+		"address already in use")))
+	require.False(t, isPortConflict(errors.New("No such container: abc123")))
+}
+
+func TestDockerRetry(t *testing.T) {
+	var creates, inspects, starts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/containers/create"):
+			// The name is still used by a container that is being removed.
+			if atomic.AddInt32(&creates, 1) == 1 {
+				w.WriteHeader(http.StatusConflict)
+				_, _ = w.Write([]byte(`{"message":"Conflict. The container name \"/db\" is already in use"}`))
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"Id":"abc123"}`))
+		case strings.HasSuffix(r.URL.Path, "/containers/db/json"):
+			atomic.AddInt32(&inspects, 1)
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"Id":"old","State":{"Status":"removing"}}`))
+		case strings.HasSuffix(r.URL.Path, "/containers/abc123/start"):
+			if atomic.AddInt32(&starts, 1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = w.Write([]byte(`{"message":"daemon is busy"}`))
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	cli, err := client.NewClientWithOpts(client.WithHost("tcp://"+strings.TrimPrefix(srv.URL, "http://")),
+		client.WithVersion("1.42"))
+	require.NoError(t, err)
+
+	var messages []string
+	logger := LoggerFunc(func(format string, v ...interface{}) {
+		messages = append(messages, fmt.Sprintf(format, v...))
+	})
+	retry := dockerRetry{logger: logger, retries: 1}
+
+	created, err := retry.createContainer(context.Background(), cli, &container.Config{Image: "mysql:8"}, nil, nil, "db")
+	require.NoError(t, err)
+	require.Equal(t, "abc123", created.ID)
+	require.EqualValues(t, 2, atomic.LoadInt32(&creates))
+	require.EqualValues(t, 1, atomic.LoadInt32(&inspects))
+
+	err = retry.startContainer(context.Background(), cli, created.ID)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, atomic.LoadInt32(&starts))
+	require.Len(t, messages, 2)
+	require.Contains(t, messages[0], "creating container failed, retrying in 500ms")
+
+	atomic.StoreInt32(&starts, 0)
+	err = dockerRetry{logger: DiscardLogger}.startContainer(context.Background(), cli, created.ID)
+	require.Error(t, err)
+	require.EqualValues(t, 1, atomic.LoadInt32(&starts))
+}
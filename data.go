@@ -43,3 +43,16 @@ func DataFromFile(filename string) *Data {
 
 	return DataFromBuffer(buf.Bytes())
 }
+
+// content returns the contents of the data. Data from a reader can only be read once.
+func (d *Data) content() ([]byte, error) {
+	if d.buf != nil {
+		return d.buf.Bytes(), nil
+	}
+
+	if d.reader != nil {
+		return io.ReadAll(d.reader)
+	}
+
+	return nil, nil
+}
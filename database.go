@@ -0,0 +1,64 @@
+package mysqlbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// CreateDatabase creates a new database with the given name. It returns a DB connected to the new database and
+// its DSN.
+func (b *MySQLBox) CreateDatabase(name string) (*sql.DB, string, error) {
+	if b == nil {
+		return nil, "", errors.New("mysqlbox is nil")
+	}
+
+	err := b.createDatabase(context.Background(), name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return b.ConnectDB(name)
+}
+
+// MustCreateDatabase creates a new database with the given name. It returns a DB connected to the new database and
+// its DSN.
+func (b *MySQLBox) MustCreateDatabase(name string) (*sql.DB, string) {
+	db, dsn, err := b.CreateDatabase(name)
+	if err != nil {
+		panic(err)
+	}
+
+	return db, dsn
+}
+
+// DropDatabase drops the database with the given name if it exists. The DBs returned by ConnectDB() or
+// CreateDatabase() for the database are not closed.
+func (b *MySQLBox) DropDatabase(name string) error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	return b.dropDatabase(context.Background(), name)
+}
+
+// MustDropDatabase drops the database with the given name if it exists.
+func (b *MySQLBox) MustDropDatabase(name string) {
+	err := b.DropDatabase(name)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// createDatabase creates a database.
+func (b *MySQLBox) createDatabase(ctx context.Context, name string) error {
+	_, err := b.db.ExecContext(ctx, fmt.Sprintf("CREATE DATABASE %s", quoteIdent(name)))
+	return err
+}
+
+// dropDatabase drops a database if it exists.
+func (b *MySQLBox) dropDatabase(ctx context.Context, name string) error {
+	_, err := b.db.ExecContext(ctx, fmt.Sprintf("DROP DATABASE IF EXISTS %s", quoteIdent(name)))
+	return err
+}
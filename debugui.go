@@ -0,0 +1,259 @@
+package mysqlbox
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// debugUILogLines is the number of container log lines shown by the debug UI.
+const debugUILogLines = "200"
+
+// debugUIQueries is the number of recent statements shown by the debug UI.
+const debugUIQueries = 50
+
+// debugPage is the data of the debug UI page.
+type debugPage struct {
+	ContainerName string
+	Database      string
+	Tables        []debugTable
+	Queries       []debugQuery
+	Logs          string
+	Errors        []string
+}
+
+// debugTable is a table of the Database and its row count.
+type debugTable struct {
+	Name string
+	Rows int64
+}
+
+// debugQuery is a recent statement from performance_schema.events_statements_history.
+type debugQuery struct {
+	ThreadID int64
+	Database string
+	SQL      string
+	Duration time.Duration
+	Error    string
+}
+
+var debugPageTemplate = template.Must(template.New("debug").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>mysqlbox {{.ContainerName}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.6em; text-align: left; vertical-align: top; }
+td.num { text-align: right; }
+pre { background: #f4f4f4; padding: 1em; overflow: auto; max-height: 40em; }
+.error { color: #b00; }
+</style>
+</head>
+<body>
+<h1>mysqlbox {{.ContainerName}}</h1>
+{{range .Errors}}<p class="error">{{.}}</p>
+{{end}}
+<h2>Tables in {{.Database}}</h2>
+<table>
+<tr><th>Table</th><th>Rows</th></tr>
+{{range .Tables}}<tr><td>{{.Name}}</td><td class="num">{{.Rows}}</td></tr>
+{{else}}<tr><td colspan="2">No tables</td></tr>
+{{end}}</table>
+<h2>Recent queries</h2>
+<table>
+<tr><th>Thread</th><th>Database</th><th>Duration</th><th>Statement</th><th>Error</th></tr>
+{{range .Queries}}<tr><td class="num">{{.ThreadID}}</td><td>{{.Database}}</td><td class="num">{{.Duration}}</td><td><code>{{.SQL}}</code></td><td class="error">{{.Error}}</td></tr>
+{{else}}<tr><td colspan="5">No queries</td></tr>
+{{end}}</table>
+<h2>Container logs</h2>
+<pre>{{.Logs}}</pre>
+</body>
+</html>
+`))
+
+// DebugUIAddr returns the address of the debug UI HTTP server when Config.DebugUIAddr is set, or a blank string
+// otherwise. It is useful when Config.DebugUIAddr has port 0.
+func (b *MySQLBox) DebugUIAddr() string {
+	if b == nil || b.debugUI == nil {
+		return ""
+	}
+
+	return b.debugUIAddr
+}
+
+// startDebugUI starts the debug UI HTTP server on the address. It is stopped by Stop().
+func (b *MySQLBox) startDebugUI(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", b.serveDebugUI)
+
+	b.debugUI = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	b.debugUIAddr = listener.Addr().String()
+
+	go func() {
+		_ = b.debugUI.Serve(listener)
+	}()
+
+	return nil
+}
+
+// serveDebugUI renders the debug UI page. Errors reading the state of the box are shown on the page, so that the
+// rest of the page is still useful.
+func (b *MySQLBox) serveDebugUI(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	page := b.debugPage(r.Context())
+
+	var buf bytes.Buffer
+	err := debugPageTemplate.Execute(&buf, page)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = buf.WriteTo(w)
+}
+
+// debugPage reads the tables, recent queries, and container logs shown by the debug UI.
+func (b *MySQLBox) debugPage(ctx context.Context) *debugPage {
+	page := &debugPage{
+		ContainerName: b.containerName,
+		Database:      b.databaseName,
+	}
+
+	// Use one connection, so that the statements of the debug UI can be excluded from the recent queries.
+	conn, err := b.db.Conn(ctx)
+	if err != nil {
+		page.Errors = append(page.Errors, fmt.Sprintf("error connecting to MySQL: %s", err.Error()))
+	} else {
+		defer conn.Close()
+
+		page.Tables, err = debugTables(ctx, conn, b.databaseName)
+		if err != nil {
+			page.Errors = append(page.Errors, fmt.Sprintf("error reading tables: %s", err.Error()))
+		}
+
+		page.Queries, err = debugQueries(ctx, conn)
+		if err != nil {
+			page.Errors = append(page.Errors, fmt.Sprintf("error reading recent queries: %s", err.Error()))
+		}
+	}
+
+	page.Logs, err = b.containerLogTail(ctx, debugUILogLines)
+	if err != nil {
+		page.Errors = append(page.Errors, fmt.Sprintf("error reading container logs: %s", err.Error()))
+	}
+
+	return page
+}
+
+// debugTables returns the base tables of a database with their row counts.
+func debugTables(ctx context.Context, conn *sql.Conn, database string) ([]debugTable, error) {
+	names, err := baseTables(ctx, conn, database)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]debugTable, 0, len(names))
+	for _, name := range names {
+		table := debugTable{Name: name}
+		query := fmt.Sprintf("SELECT COUNT(*) FROM %s.%s", quoteIdent(database), quoteIdent(name))
+		err := conn.QueryRowContext(ctx, query).Scan(&table.Rows)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+
+	return tables, nil
+}
+
+// debugQueries returns the most recent statements of the other connections, newest first.
+func debugQueries(ctx context.Context, conn *sql.Conn) ([]debugQuery, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT h.thread_id, h.current_schema, h.sql_text, h.timer_wait, h.message_text,
+			h.errors
+		FROM performance_schema.events_statements_history h
+		WHERE h.sql_text IS NOT NULL AND h.thread_id <> (
+			SELECT t.thread_id FROM performance_schema.threads t WHERE t.processlist_id = CONNECTION_ID())
+		ORDER BY h.timer_start DESC
+		LIMIT ?`, debugUIQueries)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var queries []debugQuery
+	for rows.Next() {
+		var q debugQuery
+		var database, message sql.NullString
+		var wait sql.NullInt64
+		var errCount int64
+		err := rows.Scan(&q.ThreadID, &database, &q.SQL, &wait, &message, &errCount)
+		if err != nil {
+			return nil, err
+		}
+		q.Database = database.String
+		// The timer is in picoseconds.
+		q.Duration = time.Duration(wait.Int64 / 1000)
+		if errCount > 0 {
+			q.Error = message.String
+		}
+		queries = append(queries, q)
+	}
+
+	return queries, rows.Err()
+}
+
+// containerLogTail returns the last lines of the container stdout and stderr logs.
+func (b *MySQLBox) containerLogTail(ctx context.Context, lines string) (string, error) {
+	if b.cli == nil {
+		return "", errors.New("docker client is not available")
+	}
+
+	logs, err := b.cli.ContainerLogs(ctx, b.containerID, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Tail:       lines,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer logs.Close()
+
+	var buf bytes.Buffer
+	_, err = stdcopy.StdCopy(&buf, &buf, logs)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,45 @@
+package mysqlbox
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugPageTemplate(t *testing.T) {
+	var buf bytes.Buffer
+	err := debugPageTemplate.Execute(&buf, &debugPage{
+		ContainerName: "mysqlbox-test",
+		Database:      "testing",
+		Tables:        []debugTable{{Name: "users", Rows: 3}},
+		Queries: []debugQuery{
+			{ThreadID: 42, Database: "testing", SQL: "SELECT '<b>'", Duration: 1500 * time.Microsecond},
+		},
+		Logs:   "ready for connections",
+		Errors: []string{"error reading container logs"},
+	})
+	require.NoError(t, err)
+
+	page := buf.String()
+	require.Contains(t, page, "<td>users</td><td class=\"num\">3</td>")
+	require.Contains(t, page, "SELECT &#39;&lt;b&gt;&#39;")
+	require.Contains(t, page, "1.5ms")
+	require.Contains(t, page, "ready for connections")
+	require.Contains(t, page, "error reading container logs")
+}
+
+func TestServeDebugUIReadOnly(t *testing.T) {
+	b := &MySQLBox{}
+
+	rec := httptest.NewRecorder()
+	b.serveDebugUI(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	rec = httptest.NewRecorder()
+	b.serveDebugUI(rec, httptest.NewRequest(http.MethodGet, "/tables", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
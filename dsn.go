@@ -0,0 +1,99 @@
+package mysqlbox
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// DSNFor returns a DSN for connecting to the MySQL service as the specified user and database. The params are added
+// to the DSN as driver parameters (e.g. "multiStatements": "true") or system variables, and override the defaults,
+// including parseTime=true. Values are escaped as needed.
+func (b *MySQLBox) DSNFor(user string, password string, database string, params map[string]string) (string, error) {
+	if b == nil {
+		return "", errors.New("mysqlbox is nil")
+	}
+
+	mysqlCfg := newMySQLConfig(b.host, b.port, database, password, b.tlsConfigName())
+	mysqlCfg.User = user
+	dsn := mysqlCfg.FormatDSN()
+
+	if len(params) > 0 {
+		keys := make([]string, 0, len(params))
+		for key := range params {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		pairs := make([]string, len(keys))
+		for n, key := range keys {
+			pairs[n] = key + "=" + url.QueryEscape(params[key])
+		}
+
+		sep := "?"
+		if strings.Contains(dsn, "?") {
+			sep = "&"
+		}
+		dsn += sep + strings.Join(pairs, "&")
+	}
+
+	// Parsing the DSN validates the params and applies the overrides.
+	parsed, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+
+	return parsed.FormatDSN(), nil
+}
+
+// MustDSNFor returns a DSN for connecting to the MySQL service as the specified user and database.
+func (b *MySQLBox) MustDSNFor(user string, password string, database string, params map[string]string) string {
+	dsn, err := b.DSNFor(user, password, database, params)
+	if err != nil {
+		panic(err)
+	}
+
+	return dsn
+}
+
+// ContainerDSN returns a DSN for connecting to the MySQL service as root from another container on Config.Network.
+// The DSN uses the first network alias of the container, or the container name if there are no aliases, and the
+// internal MySQL port 3306. If TLS is enabled, the DSN uses TLS without verifying the server certificate, since the
+// generated certificate is only valid for 127.0.0.1 and localhost.
+func (b *MySQLBox) ContainerDSN(database string) (string, error) {
+	if b == nil {
+		return "", errors.New("mysqlbox is nil")
+	}
+
+	if b.network == "" {
+		return "", errors.New("container is not connected to a network")
+	}
+
+	host := b.containerName
+	if len(b.networkAliases) > 0 {
+		host = b.networkAliases[0]
+	}
+
+	mysqlCfg := newMySQLConfig(b.host, b.port, database, b.rootPassword, "")
+	mysqlCfg.Addr = net.JoinHostPort(host, "3306")
+	if b.tls != nil {
+		mysqlCfg.TLSConfig = "skip-verify"
+	}
+
+	return mysqlCfg.FormatDSN(), nil
+}
+
+// MustContainerDSN returns a DSN for connecting to the MySQL service as root from another container on
+// Config.Network.
+func (b *MySQLBox) MustContainerDSN(database string) string {
+	dsn, err := b.ContainerDSN(database)
+	if err != nil {
+		panic(err)
+	}
+
+	return dsn
+}
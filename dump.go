@@ -0,0 +1,154 @@
+package mysqlbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// autoIncrementPattern matches the AUTO_INCREMENT table option of a CREATE TABLE statement, which contains the next
+// value of the counter.
+var autoIncrementPattern = regexp.MustCompile(`(?m)^(\) ENGINE=.*) AUTO_INCREMENT=\d+`)
+
+// DumpOptions contains settings for Dump.
+type DumpOptions struct {
+	// Databases contains the databases to dump. If empty, the Database of the box is dumped. The dump creates the
+	// databases and switches to them, so it can be restored without selecting a database.
+	Databases []string
+
+	// Tables contains the tables to dump. If set, only one database can be dumped, and the dump does not create the
+	// database, so it must be restored into a selected database.
+	Tables []string
+
+	// NoData dumps only the table definitions, without rows.
+	NoData bool
+
+	// LockTables dumps the databases while all tables are locked with FLUSH TABLES WITH READ LOCK, so that the dump
+	// is consistent for non-transactional tables too, e.g. MyISAM tables. By default, the dump is made in a single
+	// transaction, which is only consistent for InnoDB tables but does not block writes.
+	LockTables bool
+
+	// Args contains additional mysqldump options, e.g. "--skip-extended-insert" to write one INSERT per row.
+	Args []string
+}
+
+// Dump writes an SQL dump of the Database to w, made with mysqldump in the container. The dump includes the stored
+// routines, triggers, and events, and can be restored with the mysql client, e.g. to debug the state of a failing
+// test:
+//
+//	t.Cleanup(func() {
+//		if t.Failed() {
+//			f, _ := os.Create("failed-test.sql")
+//			defer f.Close()
+//			_ = box.Dump(context.Background(), f, nil)
+//		}
+//	})
+//
+// The dump is streamed to w while mysqldump runs, so a partial dump may have been written when an error is returned.
+func (b *MySQLBox) Dump(ctx context.Context, w io.Writer, opts *DumpOptions) error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	if opts == nil {
+		opts = &DumpOptions{}
+	}
+
+	args, err := opts.dumpArgs(b.databaseName)
+	if err != nil {
+		return err
+	}
+
+	cmd := append([]string{"mysqldump", "--user=root"}, args...)
+	stderr, exitCode, err := b.execInContainerTo(ctx, cmd, []string{"MYSQL_PWD=" + b.rootPassword}, nil, w)
+	if err != nil {
+		return fmt.Errorf("error dumping database: %w", err)
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("error dumping database: exit code %d: %s", exitCode, strings.TrimSpace(string(stderr)))
+	}
+
+	return nil
+}
+
+// MustDump writes an SQL dump of the Database to w.
+func (b *MySQLBox) MustDump(ctx context.Context, w io.Writer, opts *DumpOptions) {
+	err := b.Dump(ctx, w, opts)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// DumpSchema writes the definitions of the tables, views, stored routines, triggers, and events of the Database to w,
+// without rows, e.g. to compare the schema produced by the migrations with a checked-in golden file:
+//
+//	var schema bytes.Buffer
+//	box.MustDumpSchema(ctx, &schema)
+//	golden, _ := os.ReadFile("testdata/schema.golden.sql")
+//	require.Equal(t, string(golden), schema.String())
+//
+// The output only depends on the schema: the comments and dump date of mysqldump and the AUTO_INCREMENT counters of the
+// tables are left out.
+func (b *MySQLBox) DumpSchema(ctx context.Context, w io.Writer) error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	var schema bytes.Buffer
+	err := b.Dump(ctx, &schema, &DumpOptions{
+		NoData: true,
+		Args:   []string{"--skip-comments", "--skip-dump-date", "--no-create-db"},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(autoIncrementPattern.ReplaceAll(schema.Bytes(), []byte("$1")))
+	return err
+}
+
+// MustDumpSchema writes the definitions of the tables, views, stored routines, triggers, and events of the Database
+// to w.
+func (b *MySQLBox) MustDumpSchema(ctx context.Context, w io.Writer) {
+	err := b.DumpSchema(ctx, w)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// dumpArgs returns the mysqldump arguments of the options. database is dumped if no databases are set.
+func (o *DumpOptions) dumpArgs(database string) ([]string, error) {
+	databases := o.Databases
+	if len(databases) == 0 {
+		databases = []string{database}
+	}
+
+	if len(o.Tables) > 0 && len(databases) > 1 {
+		return nil, errors.New("tables can only be dumped from one database")
+	}
+
+	consistency := "--single-transaction"
+	if o.LockTables {
+		consistency = "--lock-all-tables"
+	}
+
+	// GTID statements would fail when the dump is restored into a server that has executed transactions.
+	args := []string{consistency, "--routines", "--triggers", "--events", "--set-gtid-purged=OFF"}
+	if o.NoData {
+		args = append(args, "--no-data")
+	}
+	args = append(args, o.Args...)
+
+	// The options end before the names, so that names starting with a dash are not read as options.
+	if len(o.Tables) > 0 {
+		args = append(args, "--", databases[0])
+		return append(args, o.Tables...), nil
+	}
+
+	args = append(args, "--databases", "--")
+	return append(args, databases...), nil
+}
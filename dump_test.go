@@ -0,0 +1,48 @@
+package mysqlbox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDumpArgs(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		args, err := (&DumpOptions{}).dumpArgs("testing")
+		require.NoError(t, err)
+		require.Equal(t, []string{"--single-transaction", "--routines", "--triggers", "--events",
+			"--set-gtid-purged=OFF", "--databases", "--", "testing"}, args)
+	})
+
+	t.Run("tables", func(t *testing.T) {
+		opts := &DumpOptions{
+			Tables:     []string{"users", "posts"},
+			NoData:     true,
+			LockTables: true,
+			Args:       []string{"--skip-extended-insert"},
+		}
+		args, err := opts.dumpArgs("testing")
+		require.NoError(t, err)
+		require.Equal(t, []string{"--lock-all-tables", "--routines", "--triggers", "--events",
+			"--set-gtid-purged=OFF", "--no-data", "--skip-extended-insert", "--", "testing", "users", "posts"}, args)
+	})
+
+	t.Run("tables_of_several_databases", func(t *testing.T) {
+		opts := &DumpOptions{Databases: []string{"a", "b"}, Tables: []string{"users"}}
+		_, err := opts.dumpArgs("testing")
+		require.Error(t, err)
+	})
+}
+
+func TestAutoIncrementPattern(t *testing.T) {
+	schema := "CREATE TABLE `users` (\n" +
+		"  `id` int NOT NULL AUTO_INCREMENT,\n" +
+		"  PRIMARY KEY (`id`)\n" +
+		") ENGINE=InnoDB AUTO_INCREMENT=42 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;\n"
+
+	require.Equal(t, "CREATE TABLE `users` (\n"+
+		"  `id` int NOT NULL AUTO_INCREMENT,\n"+
+		"  PRIMARY KEY (`id`)\n"+
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;\n",
+		autoIncrementPattern.ReplaceAllString(schema, "$1"))
+}
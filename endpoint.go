@@ -0,0 +1,178 @@
+package mysqlbox
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+var (
+	endpointsMu sync.Mutex
+
+	// endpoints contains the open endpoints by the name of their dial function. The MySQL driver cannot unregister
+	// dial functions, so the registered function looks up its endpoint here, and Close() releases the endpoint.
+	endpoints = map[string]*Endpoint{}
+)
+
+// Endpoint is a stable MySQL endpoint that routes connections to the current primary box, like an Aurora cluster
+// endpoint or ProxySQL. Application code can use a single DSN returned by DSN() and be failover-tested unchanged:
+// after Failover(), new connections go to the new primary and existing connections are closed. Close() the endpoint
+// when it is no longer used.
+type Endpoint struct {
+	// network is the name of the dial function registered in the MySQL driver.
+	network string
+
+	mu      sync.Mutex
+	primary *MySQLBox
+	conns   map[*endpointConn]bool
+}
+
+// endpointConn is a connection made through an Endpoint.
+type endpointConn struct {
+	net.Conn
+	endpoint *Endpoint
+}
+
+// Close closes the connection and removes it from the endpoint.
+func (c *endpointConn) Close() error {
+	c.endpoint.mu.Lock()
+	delete(c.endpoint.conns, c)
+	c.endpoint.mu.Unlock()
+
+	return c.Conn.Close()
+}
+
+// NewEndpoint returns an Endpoint that routes connections to the primary box. The boxes used with the endpoint
+// should have the same root password and databases, and must not have TLS enabled.
+func NewEndpoint(primary *MySQLBox) (*Endpoint, error) {
+	if primary == nil {
+		return nil, errors.New("mysqlbox is nil")
+	}
+
+	e := &Endpoint{
+		network: "mysqlbox-endpoint-" + randStr(12),
+		primary: primary,
+		conns:   map[*endpointConn]bool{},
+	}
+
+	endpointsMu.Lock()
+	endpoints[e.network] = e
+	endpointsMu.Unlock()
+
+	mysql.RegisterDialContext(e.network, dialEndpoint(e.network))
+
+	return e, nil
+}
+
+// MustNewEndpoint returns an Endpoint that routes connections to the primary box.
+func MustNewEndpoint(primary *MySQLBox) *Endpoint {
+	e, err := NewEndpoint(primary)
+	if err != nil {
+		panic(err)
+	}
+
+	return e
+}
+
+// DSN returns a DSN for connecting to the current primary of the endpoint as the specified user and database.
+func (e *Endpoint) DSN(user string, password string, database string) string {
+	mysqlCfg := mysql.NewConfig()
+	mysqlCfg.Net = e.network
+	mysqlCfg.Addr = "primary"
+	mysqlCfg.ParseTime = true
+	mysqlCfg.User = user
+	mysqlCfg.Passwd = password
+	mysqlCfg.DBName = database
+
+	return mysqlCfg.FormatDSN()
+}
+
+// Primary returns the current primary box of the endpoint.
+func (e *Endpoint) Primary() *MySQLBox {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.primary
+}
+
+// Failover makes the box the primary of the endpoint and closes the connections made to the previous primary, so
+// that clients reconnect to the new primary.
+func (e *Endpoint) Failover(primary *MySQLBox) error {
+	if primary == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	e.mu.Lock()
+	e.primary = primary
+	conns := e.conns
+	e.conns = map[*endpointConn]bool{}
+	e.mu.Unlock()
+
+	for conn := range conns {
+		_ = conn.Conn.Close()
+	}
+
+	return nil
+}
+
+// MustFailover makes the box the primary of the endpoint and closes the connections made to the previous primary.
+func (e *Endpoint) MustFailover(primary *MySQLBox) {
+	err := e.Failover(primary)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Close closes the connections made through the endpoint and releases it. New connections with the DSN of the
+// endpoint fail.
+func (e *Endpoint) Close() error {
+	endpointsMu.Lock()
+	delete(endpoints, e.network)
+	endpointsMu.Unlock()
+
+	e.mu.Lock()
+	conns := e.conns
+	e.conns = map[*endpointConn]bool{}
+	e.mu.Unlock()
+
+	for conn := range conns {
+		_ = conn.Conn.Close()
+	}
+
+	return nil
+}
+
+// dialEndpoint returns the dial function registered in the MySQL driver for the endpoint with the network name.
+func dialEndpoint(network string) mysql.DialContextFunc {
+	return func(ctx context.Context, addr string) (net.Conn, error) {
+		endpointsMu.Lock()
+		e := endpoints[network]
+		endpointsMu.Unlock()
+
+		if e == nil {
+			return nil, errors.New("endpoint is closed")
+		}
+
+		return e.dial(ctx, addr)
+	}
+}
+
+// dial connects to the current primary. The address in the DSN is ignored.
+func (e *Endpoint) dial(ctx context.Context, _ string) (net.Conn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", e.Primary().DBAddr())
+	if err != nil {
+		return nil, err
+	}
+
+	ec := &endpointConn{Conn: conn, endpoint: e}
+
+	e.mu.Lock()
+	e.conns[ec] = true
+	e.mu.Unlock()
+
+	return ec, nil
+}
@@ -0,0 +1,93 @@
+package mysqlbox
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+// listenBox returns a box whose DBAddr() is a local listener that writes its name to accepted connections.
+func listenBox(t *testing.T, name string) *MySQLBox {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = ln.Close()
+	})
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = conn.Write([]byte(name))
+		}
+	}()
+
+	return &MySQLBox{host: "127.0.0.1", port: ln.Addr().(*net.TCPAddr).Port}
+}
+
+func TestNewEndpoint(t *testing.T) {
+	first := listenBox(t, "first")
+	second := listenBox(t, "second")
+
+	e, err := NewEndpoint(first)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = e.Close()
+	})
+
+	cfg, err := mysql.ParseDSN(e.DSN("root", "secret", "app"))
+	require.NoError(t, err)
+	require.Equal(t, e.network, cfg.Net)
+	require.Equal(t, "app", cfg.DBName)
+
+	read := func(conn net.Conn) string {
+		buf := make([]byte, 6)
+		n, _ := io.ReadAtLeast(conn, buf, 5)
+		return string(buf[:n])
+	}
+
+	conn, err := e.dial(context.Background(), cfg.Addr)
+	require.NoError(t, err)
+	require.Equal(t, "first", read(conn))
+
+	require.NoError(t, e.Failover(second))
+	require.Same(t, second, e.Primary())
+
+	// Connections to the previous primary are closed.
+	_, err = conn.Read(make([]byte, 1))
+	require.Error(t, err)
+
+	conn, err = e.dial(context.Background(), cfg.Addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Equal(t, "second", read(conn))
+
+	require.Error(t, e.Failover(nil))
+
+	t.Run("close", func(t *testing.T) {
+		e, err := NewEndpoint(first)
+		require.NoError(t, err)
+
+		conn, err := dialEndpoint(e.network)(context.Background(), "primary")
+		require.NoError(t, err)
+
+		require.NoError(t, e.Close())
+
+		// Connections made through the endpoint are closed, and the endpoint is released.
+		_, err = conn.Read(make([]byte, 1))
+		require.Error(t, err)
+
+		endpointsMu.Lock()
+		require.NotContains(t, endpoints, e.network)
+		endpointsMu.Unlock()
+
+		_, err = dialEndpoint(e.network)(context.Background(), "primary")
+		require.EqualError(t, err, "endpoint is closed")
+	})
+}
@@ -0,0 +1,104 @@
+package mysqlbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// Exec runs a command inside the MySQL container and returns its standard output, standard error, and exit code. A
+// non-zero exit code is not an error. This can be used to check files in the container, run mysqladmin, or install
+// tools.
+//
+//	stdout, _, _, err := box.Exec(ctx, []string{"cat", "/var/lib/mysql/general-log.log"})
+func (b *MySQLBox) Exec(ctx context.Context, cmd []string) ([]byte, []byte, int, error) {
+	if b == nil {
+		return nil, nil, 0, errors.New("mysqlbox is nil")
+	}
+
+	if len(cmd) == 0 {
+		return nil, nil, 0, errors.New("command is empty")
+	}
+
+	return b.execInContainer(ctx, cmd, nil, nil)
+}
+
+// MustExec runs a command inside the MySQL container and returns its standard output, standard error, and exit code.
+func (b *MySQLBox) MustExec(ctx context.Context, cmd []string) ([]byte, []byte, int) {
+	stdout, stderr, exitCode, err := b.Exec(ctx, cmd)
+	if err != nil {
+		panic(err)
+	}
+
+	return stdout, stderr, exitCode
+}
+
+// execInContainer runs a command in the MySQL container with the environment variables and returns its output and
+// exit code. If stdin is not nil, it is copied to the standard input of the command.
+func (b *MySQLBox) execInContainer(ctx context.Context, cmd []string, env []string, stdin io.Reader) ([]byte, []byte,
+	int, error) {
+	var stdout bytes.Buffer
+	stderr, exitCode, err := b.execInContainerTo(ctx, cmd, env, stdin, &stdout)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	return stdout.Bytes(), stderr, exitCode, nil
+}
+
+// execInContainerTo runs a command in the MySQL container like execInContainer(), but writes its standard output to
+// stdout while it runs, so that large outputs are not buffered. It returns the standard error and exit code.
+func (b *MySQLBox) execInContainerTo(ctx context.Context, cmd []string, env []string, stdin io.Reader,
+	stdout io.Writer) ([]byte, int, error) {
+	created, err := b.cli.ContainerExecCreate(ctx, b.containerID, types.ExecConfig{
+		AttachStdin:  stdin != nil,
+		AttachStdout: true,
+		AttachStderr: true,
+		Env:          env,
+		Cmd:          cmd,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp, err := b.cli.ContainerExecAttach(ctx, created.ID, types.ExecStartCheck{})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Close()
+
+	stdinErr := make(chan error, 1)
+	if stdin != nil {
+		go func() {
+			_, err := io.Copy(resp.Conn, stdin)
+			if err == nil {
+				err = resp.CloseWrite()
+			}
+			stdinErr <- err
+		}()
+	} else {
+		stdinErr <- nil
+	}
+
+	var stderr bytes.Buffer
+	_, err = stdcopy.StdCopy(stdout, &stderr, resp.Reader)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = <-stdinErr
+	if err != nil {
+		return nil, 0, err
+	}
+
+	inspect, err := b.cli.ContainerExecInspect(ctx, created.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return stderr.Bytes(), inspect.ExitCode, nil
+}
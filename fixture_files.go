@@ -0,0 +1,326 @@
+package mysqlbox
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// csvNull is the CSV field value that represents NULL.
+const csvNull = `\N`
+
+// base64Prefix marks string values of binary columns that are base64 encoded.
+const base64Prefix = "base64:"
+
+// timeLayouts are the layouts used to parse string values of date and time columns.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// ReadFixtures reads fixtures from a file or from all fixture files in a directory. Each file contains the rows of
+// the table with the same name as the file (without the extension). The following formats are supported:
+//
+//   - YAML (.yml, .yaml): a list of maps from column names to values.
+//   - JSON (.json): an array of objects with column names as keys.
+//   - CSV (.csv): a header row with the column names followed by the rows. A field containing \N is NULL.
+//
+// Other files in a directory are ignored.
+func ReadFixtures(path string) ([]Fixture, error) {
+	files, err := fixtureFiles(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fixtures := make([]Fixture, 0, len(files))
+	for _, file := range files {
+		fixture, err := readFixtureFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading fixture file %s: %w", file, err)
+		}
+		fixtures = append(fixtures, fixture)
+	}
+
+	return fixtures, nil
+}
+
+// LoadFixtures reads fixtures from a file or directory (see ReadFixtures()) and inserts their rows into the Database.
+// Values are converted according to the column types: strings are parsed as timestamps for date and time columns,
+// strings prefixed with "base64:" are decoded for binary columns, and maps and lists are encoded for JSON columns.
+// The rows are inserted in a single transaction with foreign key checks disabled.
+func (b *MySQLBox) LoadFixtures(path string) error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	fixtures, err := ReadFixtures(path)
+	if err != nil {
+		return err
+	}
+
+	err = b.insertFixtures(fixtures)
+	if err != nil {
+		return err
+	}
+
+	files, err := fixtureFiles(path)
+	if err != nil {
+		return err
+	}
+
+	return b.addSeedFiles("fixtures", files)
+}
+
+// MustLoadFixtures reads fixtures from a file or directory and inserts their rows into the Database.
+func (b *MySQLBox) MustLoadFixtures(path string) {
+	err := b.LoadFixtures(path)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// fixtureFiles returns the path if it is a file, or the sorted fixture files in the path if it is a directory.
+func fixtureFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && fixtureFormat(entry.Name()) != "" {
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// insertFixtures inserts the fixture rows in a single transaction with foreign key checks disabled.
+func (b *MySQLBox) insertFixtures(fixtures []Fixture) error {
+	ctx := context.Background()
+
+	return b.withoutForeignKeyChecks(ctx, func(conn *sql.Conn) error {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = tx.Rollback()
+		}()
+
+		for _, fixture := range fixtures {
+			fixture, err := b.convertFixture(tx, fixture)
+			if err != nil {
+				return fmt.Errorf("table %s: %w", fixture.Table, err)
+			}
+
+			for _, row := range fixture.Rows {
+				query, args := insertQuery(fixture.Table, row)
+				_, err := tx.Exec(query, args...)
+				if err != nil {
+					return fmt.Errorf("table %s: %w", fixture.Table, constraintError(err))
+				}
+			}
+		}
+
+		return tx.Commit()
+	})
+}
+
+// convertFixture returns a copy of the fixture with its values converted according to the table column types.
+func (b *MySQLBox) convertFixture(q queryer, fixture Fixture) (Fixture, error) {
+	types, err := columnTypes(q, b.databaseName, fixture.Table)
+	if err != nil {
+		return fixture, err
+	}
+
+	rows := make([]map[string]interface{}, len(fixture.Rows))
+	for n, row := range fixture.Rows {
+		converted := make(map[string]interface{}, len(row))
+		for column, value := range row {
+			dataType, ok := types[column]
+			if !ok {
+				return fixture, fmt.Errorf("unknown column %s", column)
+			}
+
+			converted[column], err = convertValue(dataType, value)
+			if err != nil {
+				return fixture, fmt.Errorf("row %d column %s: %w", n+1, column, err)
+			}
+		}
+		rows[n] = converted
+	}
+
+	return Fixture{Table: fixture.Table, Rows: rows}, nil
+}
+
+// columnTypes returns the data types of the columns of a table.
+func columnTypes(q queryer, database string, table string) (map[string]string, error) {
+	query := "SELECT column_name, data_type FROM information_schema.columns WHERE table_schema = ? AND table_name = ?"
+	rows, err := q.Query(query, database, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	types := map[string]string{}
+	for rows.Next() {
+		var column, dataType string
+		err := rows.Scan(&column, &dataType)
+		if err != nil {
+			return nil, err
+		}
+		types[column] = strings.ToLower(dataType)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(types) == 0 {
+		return nil, errors.New("table does not exist")
+	}
+
+	return types, nil
+}
+
+// convertValue converts a fixture value to a value suitable for a column of the given data type.
+func convertValue(dataType string, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	switch dataType {
+	case "date", "datetime", "timestamp":
+		s, ok := value.(string)
+		if !ok {
+			return value, nil
+		}
+
+		for _, layout := range timeLayouts {
+			t, err := time.Parse(layout, s)
+			if err == nil {
+				return t, nil
+			}
+		}
+
+		return nil, fmt.Errorf("invalid time value %q", s)
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob":
+		s, ok := value.(string)
+		if !ok || !strings.HasPrefix(s, base64Prefix) {
+			return value, nil
+		}
+
+		return base64.StdEncoding.DecodeString(strings.TrimPrefix(s, base64Prefix))
+	case "json":
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+
+		return string(encoded), nil
+	}
+
+	return value, nil
+}
+
+// fixtureFormat returns the fixture format of a file based on its extension, or an empty string if the file is not
+// a fixture file.
+func fixtureFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yml", ".yaml":
+		return "yaml"
+	case ".json":
+		return "json"
+	case ".csv":
+		return "csv"
+	}
+
+	return ""
+}
+
+// readFixtureFile reads the fixture rows from a file.
+func readFixtureFile(filename string) (Fixture, error) {
+	base := filepath.Base(filename)
+	fixture := Fixture{
+		Table: strings.TrimSuffix(base, filepath.Ext(base)),
+	}
+
+	content, err := os.ReadFile(filename) // #nosec G304
+	if err != nil {
+		return fixture, err
+	}
+
+	switch fixtureFormat(filename) {
+	case "yaml":
+		err = yaml.Unmarshal(content, &fixture.Rows)
+	case "json":
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+		err = decoder.Decode(&fixture.Rows)
+	case "csv":
+		fixture.Rows, err = readCSVRows(bytes.NewReader(content))
+	default:
+		err = errors.New("unsupported fixture format")
+	}
+
+	return fixture, err
+}
+
+// readCSVRows reads rows from CSV data with a header row.
+func readCSVRows(r io.Reader) ([]map[string]interface{}, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	rows := make([]map[string]interface{}, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]interface{}, len(header))
+		for n, column := range header {
+			if record[n] == csvNull {
+				row[column] = nil
+			} else {
+				row[column] = record[n]
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,73 @@
+package mysqlbox
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFixtures(t *testing.T) {
+	t.Run("directory", func(t *testing.T) {
+		fixtures, err := ReadFixtures("./testdata/fixtures")
+		require.NoError(t, err)
+		require.Len(t, fixtures, 2)
+
+		require.Equal(t, "categories", fixtures[0].Table)
+		require.Len(t, fixtures[0].Rows, 2)
+		require.Equal(t, "Beta", fixtures[0].Rows[1]["name"])
+
+		require.Equal(t, "users", fixtures[1].Table)
+		require.Len(t, fixtures[1].Rows, 2)
+		require.Equal(t, "fixture2@example.com", fixtures[1].Rows[1]["email"])
+	})
+
+	t.Run("json_file", func(t *testing.T) {
+		fixtures, err := ReadFixtures("./testdata/categories.json")
+		require.NoError(t, err)
+		require.Len(t, fixtures, 1)
+		require.Equal(t, "categories", fixtures[0].Table)
+		require.Equal(t, "Gamma", fixtures[0].Rows[0]["name"])
+	})
+
+	t.Run("csv_null", func(t *testing.T) {
+		rows, err := readCSVRows(strings.NewReader("id,name\n1,\\N\n"))
+		require.NoError(t, err)
+		require.Equal(t, []map[string]interface{}{{"id": "1", "name": nil}}, rows)
+	})
+}
+
+func TestConvertValue(t *testing.T) {
+	t.Run("time", func(t *testing.T) {
+		value, err := convertValue("datetime", "2021-01-01 12:30:00")
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2021, 1, 1, 12, 30, 0, 0, time.UTC), value)
+
+		_, err = convertValue("datetime", "yesterday")
+		require.Error(t, err)
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		value, err := convertValue("varbinary", "base64:AAEC")
+		require.NoError(t, err)
+		require.Equal(t, []byte{0, 1, 2}, value)
+
+		value, err = convertValue("blob", "plain")
+		require.NoError(t, err)
+		require.Equal(t, "plain", value)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		value, err := convertValue("json", map[string]interface{}{"a": json.Number("1")})
+		require.NoError(t, err)
+		require.Equal(t, `{"a":1}`, value)
+	})
+
+	t.Run("null", func(t *testing.T) {
+		value, err := convertValue("datetime", nil)
+		require.NoError(t, err)
+		require.Nil(t, value)
+	})
+}
@@ -0,0 +1,438 @@
+package mysqlbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fixture contains rows that are loaded into a table.
+type Fixture struct {
+	// Table is the name of the table in the box Database.
+	Table string
+
+	// Rows contains the table rows. Each row maps a column name to its value.
+	Rows []map[string]interface{}
+}
+
+// rowChanges contains the changes needed to make the contents of a table match a fixture.
+type rowChanges struct {
+	inserts []map[string]interface{}
+	updates []rowUpdate
+	deletes [][]interface{}
+}
+
+// rowUpdate contains the changed columns of a row identified by its primary key values.
+type rowUpdate struct {
+	key    []interface{}
+	values map[string]interface{}
+}
+
+// Reseed makes the contents of the fixture tables match the fixture rows. Instead of truncating and reloading the
+// tables, it compares the current rows against the fixture rows by primary key and applies only the needed inserts,
+// updates, and deletes. Only the columns present in the fixture rows are compared. All fixture tables must have a
+// primary key, and every fixture row must contain the primary key columns. Values are converted according to the
+// column types like in LoadFixtures(). The changes are applied in a single transaction with foreign key checks
+// disabled.
+func (b *MySQLBox) Reseed(fixtures ...Fixture) error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	ctx := context.Background()
+
+	return b.withoutForeignKeyChecks(ctx, func(conn *sql.Conn) error {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = tx.Rollback()
+		}()
+
+		for _, fixture := range fixtures {
+			err := b.reseedTable(tx, fixture)
+			if err != nil {
+				return fmt.Errorf("reseed table %s: %w", fixture.Table, err)
+			}
+		}
+
+		return tx.Commit()
+	})
+}
+
+// MustReseed makes the contents of the fixture tables match the fixture rows.
+func (b *MySQLBox) MustReseed(fixtures ...Fixture) {
+	err := b.Reseed(fixtures...)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// reseedTable applies the changes needed to make a table match the fixture.
+func (b *MySQLBox) reseedTable(tx *sql.Tx, fixture Fixture) error {
+	fixture, err := b.convertFixture(tx, fixture)
+	if err != nil {
+		return err
+	}
+
+	pk, err := primaryKeyColumns(tx, b.databaseName, fixture.Table)
+	if err != nil {
+		return err
+	}
+
+	if len(pk) == 0 {
+		return errors.New("table has no primary key")
+	}
+
+	columns := fixtureColumns(pk, fixture.Rows)
+
+	current, decimals, err := selectRows(tx, fixture.Table, columns)
+	if err != nil {
+		return err
+	}
+
+	changes, err := diffRows(pk, decimals, current, fixture.Rows)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range changes.deletes {
+		query, args := deleteQuery(fixture.Table, pk, key)
+		_, err := tx.Exec(query, args...)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, update := range changes.updates {
+		query, args := updateQuery(fixture.Table, pk, update)
+		_, err := tx.Exec(query, args...)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, row := range changes.inserts {
+		query, args := insertQuery(fixture.Table, row)
+		_, err := tx.Exec(query, args...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// primaryKeyColumns returns the primary key columns of a table in key order.
+func primaryKeyColumns(q queryer, database string, table string) ([]string, error) {
+	query := `SELECT column_name FROM information_schema.key_column_usage
+		WHERE table_schema = ? AND table_name = ? AND constraint_name = 'PRIMARY'
+		ORDER BY ordinal_position`
+	rows, err := q.Query(query, database, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var column string
+		err := rows.Scan(&column)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+
+	return columns, rows.Err()
+}
+
+// fixtureColumns returns the primary key columns followed by the sorted list of other columns used in rows.
+func fixtureColumns(pk []string, rows []map[string]interface{}) []string {
+	seen := map[string]bool{}
+	for _, column := range pk {
+		seen[column] = true
+	}
+
+	var others []string
+	for _, row := range rows {
+		for column := range row {
+			if !seen[column] {
+				seen[column] = true
+				others = append(others, column)
+			}
+		}
+	}
+	sort.Strings(others)
+
+	return append(append([]string{}, pk...), others...)
+}
+
+// selectRows returns all rows of a table with only the specified columns, and the DECIMAL columns among them.
+func selectRows(q queryer, table string, columns []string) ([]map[string]interface{}, map[string]bool, error) {
+	quoted := make([]string, len(columns))
+	for n, column := range columns {
+		quoted[n] = quoteIdent(column)
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(quoted, ", "), quoteIdent(table))
+	rows, err := q.Query(query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	decimals, err := decimalColumns(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var result []map[string]interface{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for n := range values {
+			ptrs[n] = &values[n]
+		}
+
+		err := rows.Scan(ptrs...)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		row := make(map[string]interface{}, len(columns))
+		for n, column := range columns {
+			row[column] = values[n]
+		}
+		result = append(result, row)
+	}
+
+	return result, decimals, rows.Err()
+}
+
+// decimalColumns returns the names of the DECIMAL columns of a query result.
+func decimalColumns(rows *sql.Rows) (map[string]bool, error) {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	decimals := map[string]bool{}
+	for _, columnType := range types {
+		if columnType.DatabaseTypeName() == "DECIMAL" {
+			decimals[columnType.Name()] = true
+		}
+	}
+
+	return decimals, nil
+}
+
+// diffRows compares the current rows of a table with the wanted rows and returns the changes that make them equal.
+// The values of the decimal columns are compared as numbers.
+func diffRows(pk []string, decimals map[string]bool, current []map[string]interface{},
+	wanted []map[string]interface{}) (*rowChanges, error) {
+	currentByKey := make(map[string]map[string]interface{}, len(current))
+	for _, row := range current {
+		key, err := rowKey(pk, decimals, row)
+		if err != nil {
+			return nil, err
+		}
+		currentByKey[key] = row
+	}
+
+	changes := &rowChanges{}
+	wantedKeys := make(map[string]bool, len(wanted))
+	for _, row := range wanted {
+		key, err := rowKey(pk, decimals, row)
+		if err != nil {
+			return nil, err
+		}
+
+		if wantedKeys[key] {
+			return nil, fmt.Errorf("duplicate fixture row with key (%s)", strings.ReplaceAll(key, "\x00", ", "))
+		}
+		wantedKeys[key] = true
+
+		existing, ok := currentByKey[key]
+		if !ok {
+			changes.inserts = append(changes.inserts, row)
+			continue
+		}
+
+		changed := map[string]interface{}{}
+		for column, value := range row {
+			decimal := decimals[column]
+			if normalizeColumnValue(value, decimal) != normalizeColumnValue(existing[column], decimal) {
+				changed[column] = value
+			}
+		}
+
+		if len(changed) > 0 {
+			changes.updates = append(changes.updates, rowUpdate{
+				key:    keyValues(pk, row),
+				values: changed,
+			})
+		}
+	}
+
+	for _, row := range current {
+		key, _ := rowKey(pk, decimals, row)
+		if !wantedKeys[key] {
+			changes.deletes = append(changes.deletes, keyValues(pk, row))
+		}
+	}
+
+	return changes, nil
+}
+
+// rowKey returns a string that identifies a row by its primary key values.
+func rowKey(pk []string, decimals map[string]bool, row map[string]interface{}) (string, error) {
+	parts := make([]string, len(pk))
+	for n, column := range pk {
+		value, ok := normalizeColumnValue(row[column], decimals[column]).(string)
+		if !ok {
+			return "", fmt.Errorf("row is missing primary key column %s", column)
+		}
+		parts[n] = value
+	}
+
+	return strings.Join(parts, "\x00"), nil
+}
+
+// keyValues returns the primary key values of a row.
+func keyValues(pk []string, row map[string]interface{}) []interface{} {
+	values := make([]interface{}, len(pk))
+	for n, column := range pk {
+		values[n] = row[column]
+	}
+
+	return values
+}
+
+// decimalPattern matches the values of DECIMAL columns, e.g. "12.50".
+var decimalPattern = regexp.MustCompile(`^-?[0-9]+\.[0-9]+$`)
+
+// normalizeValue converts a column value to a form that can be compared with values read from MySQL.
+// It returns nil for NULL values and a string for everything else.
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		if v == nil {
+			return nil
+		}
+		return string(v)
+	case string:
+		return v
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.UTC().Format("2006-01-02 15:04:05.999999")
+	case *time.Time:
+		if v == nil {
+			return nil
+		}
+		return normalizeValue(*v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// normalizeColumnValue converts a value of a column like normalizeValue(). The values of DECIMAL columns are returned
+// without trailing zeros in the fraction, since MySQL pads them to the scale of the column, e.g. "12.50" becomes
+// "12.5" and "12.00" becomes "12".
+func normalizeColumnValue(value interface{}, decimal bool) interface{} {
+	normalized := normalizeValue(value)
+
+	s, ok := normalized.(string)
+	if !decimal || !ok || !decimalPattern.MatchString(s) {
+		return normalized
+	}
+
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
+
+// insertQuery returns an INSERT statement and its arguments for a row.
+func insertQuery(table string, row map[string]interface{}) (string, []interface{}) {
+	columns := make([]string, 0, len(row))
+	for column := range row {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	quoted := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	args := make([]interface{}, len(columns))
+	for n, column := range columns {
+		quoted[n] = quoteIdent(column)
+		placeholders[n] = "?"
+		args[n] = row[column]
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quoteIdent(table), strings.Join(quoted, ", "),
+		strings.Join(placeholders, ", "))
+
+	return query, args
+}
+
+// updateQuery returns an UPDATE statement and its arguments for a changed row.
+func updateQuery(table string, pk []string, update rowUpdate) (string, []interface{}) {
+	columns := make([]string, 0, len(update.values))
+	for column := range update.values {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	sets := make([]string, len(columns))
+	args := make([]interface{}, 0, len(columns)+len(pk))
+	for n, column := range columns {
+		sets[n] = fmt.Sprintf("%s = ?", quoteIdent(column))
+		args = append(args, update.values[column])
+	}
+	args = append(args, update.key...)
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s", quoteIdent(table), strings.Join(sets, ", "), keyCondition(pk))
+
+	return query, args
+}
+
+// deleteQuery returns a DELETE statement and its arguments for a row identified by its primary key values.
+func deleteQuery(table string, pk []string, key []interface{}) (string, []interface{}) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s", quoteIdent(table), keyCondition(pk))
+	return query, key
+}
+
+// keyCondition returns a WHERE condition that matches the primary key columns.
+func keyCondition(pk []string) string {
+	conds := make([]string, len(pk))
+	for n, column := range pk {
+		conds[n] = fmt.Sprintf("%s = ?", quoteIdent(column))
+	}
+
+	return strings.Join(conds, " AND ")
+}
+
+// queryer is implemented by sql.DB and sql.Tx.
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// quoteIdent quotes a MySQL identifier with backticks.
+func quoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
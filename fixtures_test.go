@@ -0,0 +1,75 @@
+package mysqlbox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffRows(t *testing.T) {
+	pk := []string{"id"}
+	created := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	current := []map[string]interface{}{
+		{"id": []byte("C-1"), "name": []byte("Alpha"), "created_at": created},
+		{"id": []byte("C-2"), "name": []byte("Beta"), "created_at": created},
+		{"id": []byte("C-3"), "name": []byte("Delta"), "created_at": created},
+	}
+
+	wanted := []map[string]interface{}{
+		{"id": "C-1", "name": "Alpha", "created_at": "2021-01-01 00:00:00"},
+		{"id": "C-2", "name": "Bravo", "created_at": created},
+		{"id": "C-4", "name": "Gamma", "created_at": created},
+	}
+
+	changes, err := diffRows(pk, nil, current, wanted)
+	require.NoError(t, err)
+
+	require.Len(t, changes.inserts, 1)
+	require.Equal(t, "C-4", changes.inserts[0]["id"])
+
+	require.Len(t, changes.updates, 1)
+	require.Equal(t, []interface{}{"C-2"}, changes.updates[0].key)
+	require.Equal(t, map[string]interface{}{"name": "Bravo"}, changes.updates[0].values)
+
+	require.Len(t, changes.deletes, 1)
+	require.Equal(t, []interface{}{[]byte("C-3")}, changes.deletes[0])
+
+	t.Run("decimal", func(t *testing.T) {
+		decimals := map[string]bool{"price": true, "total": true}
+		current := []map[string]interface{}{
+			{"id": []byte("1"), "price": []byte("12.50"), "total": []byte("3.00"), "code": []byte("1.50")},
+		}
+		wanted := []map[string]interface{}{
+			{"id": 1, "price": 12.5, "total": "3", "code": "1.5"},
+		}
+
+		// Only the values of the DECIMAL columns are compared as numbers.
+		changes, err := diffRows(pk, decimals, current, wanted)
+		require.NoError(t, err)
+		require.Len(t, changes.updates, 1)
+		require.Equal(t, map[string]interface{}{"code": "1.5"}, changes.updates[0].values)
+	})
+
+	t.Run("missing_key", func(t *testing.T) {
+		_, err := diffRows(pk, nil, nil, []map[string]interface{}{{"name": "Alpha"}})
+		require.Error(t, err)
+	})
+
+	t.Run("duplicate_key", func(t *testing.T) {
+		_, err := diffRows(pk, nil, nil, []map[string]interface{}{{"id": 1}, {"id": "1"}})
+		require.Error(t, err)
+	})
+}
+
+func TestNormalizeColumnValue(t *testing.T) {
+	require.Equal(t, "12.5", normalizeColumnValue([]byte("12.50"), true))
+	require.Equal(t, "12", normalizeColumnValue("12.00", true))
+	require.Equal(t, "100", normalizeColumnValue("100", true))
+	require.Equal(t, "-0.25", normalizeColumnValue(-0.25, true))
+	require.Nil(t, normalizeColumnValue([]byte(nil), true))
+
+	require.Equal(t, "12.50", normalizeColumnValue([]byte("12.50"), false))
+	require.Equal(t, "1000000", normalizeColumnValue(1e6, false))
+}
@@ -0,0 +1,100 @@
+package mysqlbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// generalLogFile is the file of the general query log in the container. The server is started with --general-log.
+const generalLogFile = "/var/lib/mysql/general-log.log"
+
+// GeneralLog returns the contents of the general query log of the MySQL server, which contains every statement
+// received by the server since it was started, e.g. to assert which statements were executed by the code under test:
+//
+//	log := box.MustGeneralLog(ctx)
+//	require.Contains(t, log, "DELETE FROM users WHERE id = 1")
+func (b *MySQLBox) GeneralLog(ctx context.Context) (string, error) {
+	if b == nil {
+		return "", errors.New("mysqlbox is nil")
+	}
+
+	content, err := b.readContainerFile(ctx, generalLogFile)
+	if err != nil {
+		return "", fmt.Errorf("error reading general log: %w", err)
+	}
+
+	return string(content), nil
+}
+
+// MustGeneralLog returns the contents of the general query log of the MySQL server.
+func (b *MySQLBox) MustGeneralLog(ctx context.Context) string {
+	log, err := b.GeneralLog(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	return log
+}
+
+// TailGeneralLog writes the general query log of the MySQL server to w, from the start, and then keeps writing the
+// new lines until the context is done or the box is stopped. It returns nil when the context is done.
+func (b *MySQLBox) TailGeneralLog(ctx context.Context, w io.Writer) error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	created, err := b.cli.ContainerExecCreate(ctx, b.containerID, types.ExecConfig{
+		AttachStdout: true,
+		AttachStderr: true,
+		Cmd:          []string{"tail", "-n", "+1", "-F", generalLogFile},
+	})
+	if err != nil {
+		return fmt.Errorf("error tailing general log: %w", err)
+	}
+
+	resp, err := b.cli.ContainerExecAttach(ctx, created.ID, types.ExecStartCheck{})
+	if err != nil {
+		return fmt.Errorf("error tailing general log: %w", err)
+	}
+	defer resp.Close()
+
+	// Closing the connection stops the copy when the context is done. The tail process ends with the container.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			resp.Close()
+		case <-done:
+		}
+	}()
+
+	var stderr bytes.Buffer
+	_, err = stdcopy.StdCopy(w, &stderr, resp.Reader)
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error tailing general log: %w", err)
+	}
+
+	if stderr.Len() > 0 {
+		return fmt.Errorf("error tailing general log: %s", bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	return nil
+}
+
+// MustTailGeneralLog writes the general query log of the MySQL server to w until the context is done.
+func (b *MySQLBox) MustTailGeneralLog(ctx context.Context, w io.Writer) {
+	err := b.TailGeneralLog(ctx, w)
+	if err != nil {
+		panic(err)
+	}
+}
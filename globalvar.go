@@ -0,0 +1,117 @@
+package mysqlbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+)
+
+const (
+	// adminPort is the port of the administrative connection interface in the container, see Config.AdminInterface.
+	adminPort = "33062/tcp"
+
+	// adminPortNumber is adminPort without the protocol.
+	adminPortNumber = "33062"
+)
+
+// SetGlobalVar sets a dynamic global system variable of the MySQL server, e.g. SetGlobalVar("max_connections", 10).
+// The value is passed as a statement argument, so booleans can be set with true and false, or with "ON" and "OFF".
+// The setting is lost when the server restarts.
+func (b *MySQLBox) SetGlobalVar(name string, value interface{}) error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	_, err := b.db.ExecContext(context.Background(), fmt.Sprintf("SET GLOBAL %s = ?", quoteIdent(name)), value)
+	if err != nil {
+		return fmt.Errorf("error setting %s to %v: %w", name, value, err)
+	}
+
+	return nil
+}
+
+// MustSetGlobalVar sets a dynamic global system variable of the MySQL server.
+func (b *MySQLBox) MustSetGlobalVar(name string, value interface{}) {
+	err := b.SetGlobalVar(name, value)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// SetOfflineMode sets the offline_mode variable of the MySQL server. In offline mode, the server rejects new
+// connections and closes existing connections, except those of users with the CONNECTION_ADMIN or SUPER privilege.
+// See StopAcceptingConnections().
+func (b *MySQLBox) SetOfflineMode(on bool) error {
+	return b.SetGlobalVar("offline_mode", on)
+}
+
+// MustSetOfflineMode sets the offline_mode variable of the MySQL server.
+func (b *MySQLBox) MustSetOfflineMode(on bool) {
+	err := b.SetOfflineMode(on)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// SetRequireSecureTransport sets the require_secure_transport variable of the MySQL server. When it is on, new
+// connections without TLS are rejected for all users. Existing connections are not closed.
+//
+// The box connects without TLS unless Config.EnableTLS is set, so turning it on returns an error for other boxes.
+// Boxes with Config.EnableTLS start with it on, and it can be turned off to test clients that do not use TLS.
+func (b *MySQLBox) SetRequireSecureTransport(on bool) error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	if on && b.tls == nil {
+		return errors.New("require_secure_transport can only be turned on with Config.EnableTLS")
+	}
+
+	return b.SetGlobalVar("require_secure_transport", on)
+}
+
+// MustSetRequireSecureTransport sets the require_secure_transport variable of the MySQL server.
+func (b *MySQLBox) MustSetRequireSecureTransport(on bool) {
+	err := b.SetRequireSecureTransport(on)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// AdminAddr returns the address of the administrative connection interface of the MySQL server, which only accepts
+// users with the SERVICE_CONNECTION_ADMIN privilege, like root. It is blank unless Config.AdminInterface is set.
+func (b *MySQLBox) AdminAddr() string {
+	if b.adminPort == 0 {
+		return ""
+	}
+
+	return net.JoinHostPort(b.host, fmt.Sprintf("%d", b.adminPort))
+}
+
+// AdminDSN returns the DSN for connecting to the Database as root through the administrative connection interface.
+// These connections are not limited by max_connections and are not closed in offline mode. Config.AdminInterface
+// must be set.
+func (b *MySQLBox) AdminDSN() (string, error) {
+	if b == nil {
+		return "", errors.New("mysqlbox is nil")
+	}
+
+	if b.adminPort == 0 {
+		return "", errors.New("admin interface is not enabled, see Config.AdminInterface")
+	}
+
+	mysqlCfg := newMySQLConfig(b.host, b.adminPort, b.databaseName, b.rootPassword, b.tlsConfigName())
+
+	return mysqlCfg.FormatDSN(), nil
+}
+
+// MustAdminDSN returns the DSN for connecting to the Database as root through the administrative connection interface.
+func (b *MySQLBox) MustAdminDSN() string {
+	dsn, err := b.AdminDSN()
+	if err != nil {
+		panic(err)
+	}
+
+	return dsn
+}
@@ -0,0 +1,64 @@
+package mysqlbox
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+)
+
+// dockerDesktopHost is the hostname that resolves to the host machine in Docker Desktop containers.
+const dockerDesktopHost = "host.docker.internal"
+
+// HostAddressForContainer returns the address that a process running inside the container can use to reach the host
+// running the tests, e.g. when MySQL triggers or sidecars need to call back into the test process. On Docker Desktop
+// (macOS, Windows, and Docker Desktop for Linux) it returns "host.docker.internal". Otherwise, it returns the gateway
+// IP address of the container network.
+func (b *MySQLBox) HostAddressForContainer() (string, error) {
+	if b == nil {
+		return "", errors.New("mysqlbox is nil")
+	}
+
+	ctx := context.Background()
+
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		return dockerDesktopHost, nil
+	}
+
+	info, err := b.cli.Info(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if strings.Contains(info.OperatingSystem, "Docker Desktop") {
+		return dockerDesktopHost, nil
+	}
+
+	cr, err := b.cli.ContainerInspect(ctx, b.containerID)
+	if err != nil {
+		return "", err
+	}
+
+	if cr.NetworkSettings.Gateway != "" {
+		return cr.NetworkSettings.Gateway, nil
+	}
+
+	for _, network := range cr.NetworkSettings.Networks {
+		if network.Gateway != "" {
+			return network.Gateway, nil
+		}
+	}
+
+	return "", errors.New("container network has no gateway")
+}
+
+// MustHostAddressForContainer returns the address that a process running inside the container can use to reach
+// the host running the tests.
+func (b *MySQLBox) MustHostAddressForContainer() string {
+	addr, err := b.HostAddressForContainer()
+	if err != nil {
+		panic(err)
+	}
+
+	return addr
+}
@@ -0,0 +1,61 @@
+package mysqlbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// maxIdleCheckInterval is the maximum time between the connection checks of the idle shutdown watcher.
+const maxIdleCheckInterval = 10 * time.Second
+
+// idleShutdownScript is the shell script of the idle shutdown watcher. It counts the client connections to the
+// MySQL server periodically and shuts the server down when there were none for the idle period. It exits when the
+// server cannot be reached, e.g. because the MySQL container was stopped.
+const idleShutdownScript = `idle=0
+while sleep %[1]d; do
+  n=$(mysql -h127.0.0.1 -uroot -N -e "SELECT COUNT(*) FROM information_schema.processlist WHERE id <> CONNECTION_ID() AND command <> 'Daemon'" 2>/dev/null) || exit 0
+  if [ "$n" = 0 ]; then idle=$((idle + %[1]d)); else idle=0; fi
+  if [ "$idle" -ge %[2]d ]; then
+    echo "no connections for $idle seconds, shutting down"
+    mysqladmin -h127.0.0.1 -uroot shutdown
+    exit 0
+  fi
+done`
+
+// startIdleWatcher starts a container that stops the MySQL server when no client has been connected to it for the
+// idle period (see Config.IdleShutdown). The watcher runs the mysql client of the MySQL image, and it does not depend
+// on the test process, so it also stops boxes that are left running after the process exits.
+func (b *MySQLBox) startIdleWatcher(ctx context.Context, image string, idle time.Duration) error {
+	interval := maxIdleCheckInterval
+	if idle < interval {
+		interval = idle
+	}
+
+	cfg := &container.Config{
+		Image:      image,
+		Entrypoint: []string{"sh", "-c"},
+		Cmd:        []string{fmt.Sprintf(idleShutdownScript, wholeSeconds(interval), wholeSeconds(idle))},
+		Env:        []string{"MYSQL_PWD=" + b.rootPassword},
+	}
+
+	id, err := b.createSidecar(ctx, cfg, true)
+	if err != nil {
+		return err
+	}
+
+	return b.retry.startContainer(ctx, b.cli, id)
+}
+
+// wholeSeconds returns the duration in seconds rounded up, and at least 1, since the watcher script only works with
+// whole seconds and a zero period would shut the server down at the first check.
+func wholeSeconds(d time.Duration) int {
+	secs := int((d + time.Second - 1) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+
+	return secs
+}
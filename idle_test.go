@@ -0,0 +1,16 @@
+package mysqlbox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWholeSeconds(t *testing.T) {
+	require.Equal(t, 1, wholeSeconds(500*time.Millisecond))
+	require.Equal(t, 1, wholeSeconds(time.Nanosecond))
+	require.Equal(t, 1, wholeSeconds(time.Second))
+	require.Equal(t, 2, wholeSeconds(1500*time.Millisecond))
+	require.Equal(t, 10, wholeSeconds(10*time.Second))
+}
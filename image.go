@@ -0,0 +1,78 @@
+package mysqlbox
+
+import "strings"
+
+// dockerHubRegistries are the registry hostnames of Docker Hub.
+var dockerHubRegistries = map[string]bool{
+	"docker.io":       true,
+	"index.docker.io": true,
+}
+
+// imageRewriter returns a function that rewrites the names of the images used by the box according to
+// Config.RegistryMirror and Config.RewriteImage. It returns nil if the names are not rewritten.
+func (c *Config) imageRewriter() func(string) string {
+	if c.RegistryMirror == "" && c.RewriteImage == nil {
+		return nil
+	}
+
+	mirror := c.RegistryMirror
+	rewrite := c.RewriteImage
+
+	return func(image string) string {
+		if mirror != "" {
+			image = mirrorImage(mirror, image)
+		}
+
+		if rewrite != nil {
+			image = rewrite(image)
+		}
+
+		return image
+	}
+}
+
+// imageName returns the name of an image after it is rewritten with RegistryMirror and RewriteImage.
+func (c *Config) imageName(image string) string {
+	rewrite := c.imageRewriter()
+	if rewrite == nil {
+		return image
+	}
+
+	return rewrite(image)
+}
+
+// imageName returns the name of an image after it is rewritten with Config.RegistryMirror and Config.RewriteImage.
+func (b *MySQLBox) imageName(image string) string {
+	if b.rewriteImage == nil {
+		return image
+	}
+
+	return b.rewriteImage(image)
+}
+
+// mirrorImage returns the name of a Docker Hub image in a registry mirror, e.g. "mysql:8" becomes
+// "mirror.example.com/library/mysql:8". Images of other registries are returned unchanged.
+func mirrorImage(mirror string, image string) string {
+	mirror = strings.TrimSuffix(mirror, "/")
+	for _, scheme := range []string{"https://", "http://"} {
+		mirror = strings.TrimPrefix(mirror, scheme)
+	}
+
+	path := image
+	if first, rest, ok := strings.Cut(image, "/"); ok {
+		switch {
+		case dockerHubRegistries[first]:
+			path = rest
+		case strings.ContainsAny(first, ".:") || first == "localhost":
+			// The image is in another registry.
+			return image
+		}
+	}
+
+	// Official images are in the library namespace.
+	if !strings.Contains(path, "/") {
+		path = "library/" + path
+	}
+
+	return mirror + "/" + path
+}
@@ -0,0 +1,48 @@
+package mysqlbox
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMirrorImage(t *testing.T) {
+	tests := []struct {
+		image    string
+		expected string
+	}{
+		{"mysql:8", "mirror.example.com/library/mysql:8"},
+		{"testcontainers/ryuk:0.5.1", "mirror.example.com/testcontainers/ryuk:0.5.1"},
+		{"docker.io/library/mysql:8.0.33", "mirror.example.com/library/mysql:8.0.33"},
+		{"index.docker.io/mysql", "mirror.example.com/library/mysql"},
+		{"ghcr.io/shopify/toxiproxy:2.5.0", "ghcr.io/shopify/toxiproxy:2.5.0"},
+		{"localhost:5000/mysql:8", "localhost:5000/mysql:8"},
+		{"localhost/mysql:8", "localhost/mysql:8"},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.expected, mirrorImage("https://mirror.example.com/", test.image), test.image)
+	}
+
+	require.Equal(t, "mirror.example.com/dockerhub/library/mysql:8", mirrorImage("mirror.example.com/dockerhub",
+		"mysql:8"))
+}
+
+func TestConfigImageName(t *testing.T) {
+	c := &Config{}
+	require.Nil(t, c.imageRewriter())
+	require.Equal(t, "mysql:8", c.imageName("mysql:8"))
+
+	c = &Config{
+		RegistryMirror: "mirror.example.com",
+		RewriteImage: func(image string) string {
+			return strings.Replace(image, ":8", ":8.0.33", 1)
+		},
+	}
+	require.Equal(t, "mirror.example.com/library/mysql:8.0.33", c.imageName("mysql:8"))
+
+	b := &MySQLBox{rewriteImage: c.imageRewriter()}
+	require.Equal(t, "ghcr.io/shopify/toxiproxy:2.5.0", b.imageName("ghcr.io/shopify/toxiproxy:2.5.0"))
+	require.Equal(t, "adminer:4", (&MySQLBox{}).imageName("adminer:4"))
+}
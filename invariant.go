@@ -0,0 +1,126 @@
+package mysqlbox
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// invariant is a query whose result must always equal the expected value.
+type invariant struct {
+	name   string
+	query  string
+	expect interface{}
+}
+
+// RegisterInvariant registers a cross-table invariant that is validated by CheckInvariants(). The query must return
+// a single value, which is compared with expect. Numeric values are compared numerically, so a SUM() returning
+// "0.00" matches an expected 0. For example, an orphan-free foreign key can be registered as:
+//
+//	box.RegisterInvariant("orphan books",
+//		"SELECT COUNT(*) FROM books LEFT JOIN authors ON authors.id = books.author_id WHERE authors.id IS NULL", 0)
+func (b *MySQLBox) RegisterInvariant(name string, query string, expect interface{}) error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	b.invariantsMu.Lock()
+	defer b.invariantsMu.Unlock()
+
+	b.invariants = append(b.invariants, invariant{name: name, query: query, expect: expect})
+
+	return nil
+}
+
+// MustRegisterInvariant registers a cross-table invariant that is validated by CheckInvariants().
+func (b *MySQLBox) MustRegisterInvariant(name string, query string, expect interface{}) {
+	err := b.RegisterInvariant(name, query, expect)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// VerifyInvariants runs the registered invariant queries and returns an error describing the violated invariants.
+func (b *MySQLBox) VerifyInvariants() error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	b.invariantsMu.Lock()
+	invariants := append([]invariant{}, b.invariants...)
+	b.invariantsMu.Unlock()
+
+	var violations []string
+	for _, inv := range invariants {
+		var got interface{}
+		err := b.db.QueryRow(inv.query).Scan(&got)
+		if err != nil {
+			violations = append(violations, fmt.Sprintf("invariant %q failed: %s", inv.name, err.Error()))
+			continue
+		}
+
+		if !invariantHolds(got, inv.expect) {
+			violations = append(violations, fmt.Sprintf("invariant %q violated: got %v, expected %v", inv.name,
+				normalizeValue(got), normalizeValue(inv.expect)))
+		}
+	}
+
+	if len(violations) > 0 {
+		return errors.New(strings.Join(violations, "; "))
+	}
+
+	return nil
+}
+
+// CheckInvariants runs the registered invariant queries and fails the test if an invariant is violated.
+func (b *MySQLBox) CheckInvariants(t testing.TB) {
+	t.Helper()
+
+	err := b.VerifyInvariants()
+	if err != nil {
+		t.Error(err.Error())
+	}
+}
+
+// CheckInvariantsOnCleanup registers a cleanup function that runs CheckInvariants() when the test finishes. Calling
+// it at the start of each test catches data corruption that the test itself does not assert on.
+func (b *MySQLBox) CheckInvariantsOnCleanup(t testing.TB) {
+	t.Helper()
+
+	t.Cleanup(func() {
+		b.CheckInvariants(t)
+	})
+}
+
+// invariantHolds reports whether the value returned by an invariant query matches the expected value.
+func invariantHolds(got interface{}, expect interface{}) bool {
+	gotValue := normalizeValue(got)
+	expectValue := normalizeValue(expect)
+	if gotValue == expectValue {
+		return true
+	}
+
+	gotString, ok := gotValue.(string)
+	if !ok {
+		return false
+	}
+
+	expectString, ok := expectValue.(string)
+	if !ok {
+		return false
+	}
+
+	gotNumber, err := strconv.ParseFloat(gotString, 64)
+	if err != nil {
+		return false
+	}
+
+	expectNumber, err := strconv.ParseFloat(expectString, 64)
+	if err != nil {
+		return false
+	}
+
+	return gotNumber == expectNumber
+}
@@ -0,0 +1,18 @@
+package mysqlbox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvariantHolds(t *testing.T) {
+	require.True(t, invariantHolds([]byte("0"), 0))
+	require.True(t, invariantHolds([]byte("0.00"), 0))
+	require.True(t, invariantHolds([]byte("12.5"), 12.5))
+	require.True(t, invariantHolds([]byte("active"), "active"))
+	require.True(t, invariantHolds(nil, nil))
+	require.False(t, invariantHolds([]byte("1"), 0))
+	require.False(t, invariantHolds(nil, 0))
+	require.False(t, invariantHolds([]byte("active"), "inactive"))
+}
@@ -0,0 +1,179 @@
+package mysqlbox
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// LintIssue is a schema problem found by a LintRule.
+type LintIssue struct {
+	// Rule is the name of the rule that found the issue.
+	Rule string
+
+	// Table is the name of the table with the issue.
+	Table string
+
+	// Message describes the issue.
+	Message string
+}
+
+// String returns the issue in the form "rule: table: message".
+func (i LintIssue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.Rule, i.Table, i.Message)
+}
+
+// LintRule is a schema check evaluated by LintSchema().
+type LintRule struct {
+	// Name identifies the rule in the issues it finds.
+	Name string
+
+	// Check returns the issues found in the schema of the database. The Rule field of the returned issues is set
+	// by LintSchema().
+	Check func(db *sql.DB, database string) ([]LintIssue, error)
+}
+
+var (
+	// LintMissingPrimaryKey finds tables without a primary key.
+	LintMissingPrimaryKey = LintRule{Name: "missing-primary-key", Check: lintMissingPrimaryKey}
+
+	// LintUTF8MB3 finds tables and columns that use the deprecated utf8mb3 (utf8) character set.
+	LintUTF8MB3 = LintRule{Name: "utf8mb3", Check: lintUTF8MB3}
+
+	// LintTextInIndex finds indexes that include TEXT or BLOB columns.
+	LintTextInIndex = LintRule{Name: "text-in-index", Check: lintTextInIndex}
+)
+
+// DefaultLintRules are the rules used by LintSchema() when no rules are specified. There is no rule for foreign key
+// columns without an index: InnoDB requires an index whose leading columns are the foreign key columns, creates one
+// when the constraint is added without it, and refuses to drop it while the constraint exists, so such a rule could
+// never find an issue.
+var DefaultLintRules = []LintRule{
+	LintMissingPrimaryKey,
+	LintUTF8MB3,
+	LintTextInIndex,
+}
+
+// LintSchema evaluates the rules against the schema of the Database and returns the issues found. If no rules are
+// specified, DefaultLintRules are used, which check for missing primary keys, the utf8mb3 character set, and TEXT or
+// BLOB columns in indexes. A schema without issues returns an empty list.
+func (b *MySQLBox) LintSchema(rules ...LintRule) ([]LintIssue, error) {
+	if b == nil {
+		return nil, errors.New("mysqlbox is nil")
+	}
+
+	if len(rules) == 0 {
+		rules = DefaultLintRules
+	}
+
+	var issues []LintIssue
+	for _, rule := range rules {
+		found, err := rule.Check(b.db, b.databaseName)
+		if err != nil {
+			return nil, fmt.Errorf("lint rule %s failed: %w", rule.Name, err)
+		}
+
+		for _, issue := range found {
+			issue.Rule = rule.Name
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues, nil
+}
+
+// MustLintSchema evaluates the rules against the schema of the Database and returns the issues found.
+func (b *MySQLBox) MustLintSchema(rules ...LintRule) []LintIssue {
+	issues, err := b.LintSchema(rules...)
+	if err != nil {
+		panic(err)
+	}
+
+	return issues
+}
+
+// lintMissingPrimaryKey returns an issue for each base table without a primary key.
+func lintMissingPrimaryKey(db *sql.DB, database string) ([]LintIssue, error) {
+	query := `SELECT t.table_name FROM information_schema.tables t
+		WHERE t.table_schema = ? AND t.table_type = 'BASE TABLE' AND NOT EXISTS (
+			SELECT 1 FROM information_schema.table_constraints c
+			WHERE c.table_schema = t.table_schema AND c.table_name = t.table_name
+			AND c.constraint_type = 'PRIMARY KEY')
+		ORDER BY t.table_name`
+
+	return lintQuery(db, query, database, func(rows *sql.Rows) (LintIssue, error) {
+		var table string
+		err := rows.Scan(&table)
+		return LintIssue{Table: table, Message: "table has no primary key"}, err
+	})
+}
+
+// lintUTF8MB3 returns an issue for each table and column using the utf8mb3 character set.
+func lintUTF8MB3(db *sql.DB, database string) ([]LintIssue, error) {
+	issues, err := lintQuery(db, `SELECT table_name, table_collation FROM information_schema.tables
+		WHERE table_schema = ? AND table_type = 'BASE TABLE'
+		AND (table_collation LIKE 'utf8\_%' OR table_collation LIKE 'utf8mb3\_%')
+		ORDER BY table_name`, database, func(rows *sql.Rows) (LintIssue, error) {
+		var table, collation string
+		err := rows.Scan(&table, &collation)
+		return LintIssue{Table: table, Message: fmt.Sprintf("table uses collation %s", collation)}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	columnIssues, err := lintQuery(db, `SELECT c.table_name, c.column_name, c.character_set_name
+		FROM information_schema.columns c
+		JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+		WHERE c.table_schema = ? AND t.table_type = 'BASE TABLE' AND c.character_set_name IN ('utf8', 'utf8mb3')
+		ORDER BY c.table_name, c.ordinal_position`, database, func(rows *sql.Rows) (LintIssue, error) {
+		var table, column, charset string
+		err := rows.Scan(&table, &column, &charset)
+		return LintIssue{Table: table, Message: fmt.Sprintf("column %s uses character set %s", column, charset)}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return append(issues, columnIssues...), nil
+}
+
+// lintTextInIndex returns an issue for each TEXT or BLOB column included in an index.
+func lintTextInIndex(db *sql.DB, database string) ([]LintIssue, error) {
+	query := `SELECT s.table_name, s.index_name, s.column_name, c.data_type
+		FROM information_schema.statistics s
+		JOIN information_schema.columns c ON c.table_schema = s.table_schema AND c.table_name = s.table_name
+			AND c.column_name = s.column_name
+		WHERE s.table_schema = ? AND c.data_type IN
+			('tinytext', 'text', 'mediumtext', 'longtext', 'tinyblob', 'blob', 'mediumblob', 'longblob')
+		ORDER BY s.table_name, s.index_name, s.seq_in_index`
+
+	return lintQuery(db, query, database, func(rows *sql.Rows) (LintIssue, error) {
+		var table, index, column, dataType string
+		err := rows.Scan(&table, &index, &column, &dataType)
+		return LintIssue{
+			Table:   table,
+			Message: fmt.Sprintf("index %s includes %s column %s", index, dataType, column),
+		}, err
+	})
+}
+
+// lintQuery runs a query with the database name as its argument and converts each result row to an issue.
+func lintQuery(db *sql.DB, query, database string, scan func(*sql.Rows) (LintIssue, error)) ([]LintIssue, error) {
+	rows, err := db.Query(query, database)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var issues []LintIssue
+	for rows.Next() {
+		issue, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, issue)
+	}
+
+	return issues, rows.Err()
+}
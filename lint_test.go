@@ -0,0 +1,12 @@
+package mysqlbox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintIssueString(t *testing.T) {
+	issue := LintIssue{Rule: "missing-primary-key", Table: "notes", Message: "table has no primary key"}
+	require.Equal(t, "missing-primary-key: notes: table has no primary key", issue.String())
+}
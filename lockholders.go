@@ -0,0 +1,94 @@
+package mysqlbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// killMetadataLockHolders kills the connections that hold metadata locks on tables of the Database, so that a
+// transaction leaked by a previous test does not block TRUNCATE or DROP forever. If tables is empty, the locks on all
+// tables of the Database are considered. The killed connections are added to KilledQueries() and logged.
+func (b *MySQLBox) killMetadataLockHolders(ctx context.Context, tables []string) error {
+	conn, err := b.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	holders, err := metadataLockHolders(ctx, conn, b.databaseName, tables)
+	if err != nil {
+		return fmt.Errorf("error finding metadata lock holders: %w", err)
+	}
+
+	for _, q := range holders {
+		_, err := conn.ExecContext(ctx, fmt.Sprintf("KILL %d", q.ID))
+		if err != nil {
+			// The connection may have ended after its locks were read.
+			continue
+		}
+
+		q.KilledAt = time.Now()
+		b.addKilledQuery(q)
+		b.logger.Printf("%s", q.String())
+	}
+
+	return nil
+}
+
+// metadataLockHolders returns the connections, other than conn, that hold metadata locks on tables of a database.
+// Each connection is returned once, with one of the locked tables. If tables is empty, the locks on all tables of the
+// database are returned.
+func metadataLockHolders(ctx context.Context, conn *sql.Conn, database string, tables []string) ([]KilledQuery,
+	error) {
+	query := `SELECT t.processlist_id, t.processlist_user, t.processlist_db, t.processlist_info, t.processlist_time,
+			ml.object_name
+		FROM performance_schema.metadata_locks ml
+		JOIN performance_schema.threads t ON t.thread_id = ml.owner_thread_id
+		WHERE ml.object_type = 'TABLE' AND ml.lock_status = 'GRANTED' AND ml.object_schema = ?
+			AND t.processlist_id IS NOT NULL AND t.processlist_id <> CONNECTION_ID()`
+	args := []interface{}{database}
+
+	if len(tables) > 0 {
+		query += " AND ml.object_name IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(tables)), ", ") + ")"
+		for _, table := range tables {
+			args = append(args, table)
+		}
+	}
+	query += " ORDER BY t.processlist_id, ml.object_name"
+
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seen := map[int64]bool{}
+	var holders []KilledQuery
+	for rows.Next() {
+		var q KilledQuery
+		var user, db, info sql.NullString
+		var running sql.NullInt64
+		var table string
+		err := rows.Scan(&q.ID, &user, &db, &info, &running, &table)
+		if err != nil {
+			return nil, err
+		}
+
+		if seen[q.ID] {
+			continue
+		}
+		seen[q.ID] = true
+
+		q.User = user.String
+		q.Database = db.String
+		q.Query = info.String
+		q.Duration = time.Duration(running.Int64) * time.Second
+		q.LockedTable = database + "." + table
+		holders = append(holders, q)
+	}
+
+	return holders, rows.Err()
+}
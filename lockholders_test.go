@@ -0,0 +1,26 @@
+package mysqlbox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKilledQueryString(t *testing.T) {
+	q := KilledQuery{ID: 12, Query: "SELECT SLEEP(60)", Duration: 3 * time.Second}
+	require.Equal(t, "killed query of connection 12 after 3s: SELECT SLEEP(60)", q.String())
+
+	q = KilledQuery{ID: 13, LockedTable: "testing.users", Duration: 5 * time.Second}
+	require.Equal(t, "killed connection 13 holding a metadata lock on testing.users for 5s: ", q.String())
+}
+
+func TestAddKilledQuery(t *testing.T) {
+	b := &MySQLBox{}
+	b.addKilledQuery(KilledQuery{ID: 1})
+	b.addKilledQuery(KilledQuery{ID: 2, LockedTable: "testing.users"})
+
+	killed := b.KilledQueries()
+	require.Len(t, killed, 2)
+	require.Equal(t, "testing.users", killed[1].LockedTable)
+}
@@ -0,0 +1,228 @@
+package mysqlbox
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// logErrorPattern matches the error lines printed by the mysql client, e.g.
+// "ERROR 1146 (42S02) at line 2: Table 'testing.sales' doesn't exist".
+var logErrorPattern = regexp.MustCompile(`^ERROR (\d+) \(([0-9A-Z]{5})\)(?: at line (\d+))?: (.*)$`)
+
+// serverLogErrorPattern matches the error lines of the MySQL server error log, e.g.
+// "2023-05-01T10:00:00.123456Z 0 [ERROR] [MY-010119] [Server] Aborting". MySQL 5.7 does not print the error code and
+// subsystem, and MariaDB prints the time without the T and Z.
+var serverLogErrorPattern = regexp.MustCompile(
+	`^(\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:\.\d+)?Z?)\s+\d+\s+\[ERROR\]\s+(?:\[MY-(\d+)\]\s+)?(?:\[(\w+)\]\s+)?(.*)$`)
+
+// LogError is an error in the container logs: an error printed by the mysql client, e.g. for a statement of an
+// initial SQL script, or an error of the MySQL server error log.
+type LogError struct {
+	// Time is the time of a server error. It is zero for errors printed by the mysql client.
+	Time time.Time
+
+	// Code is the MySQL error code, e.g. 1146, or the number of the MY- code of a server error, e.g. 10119 for
+	// MY-010119. It is zero if the code is not known.
+	Code int
+
+	// SQLState is the SQLSTATE value of an error printed by the mysql client, e.g. "42S02".
+	SQLState string
+
+	// Subsystem is the subsystem that logged a server error, e.g. "Server" or "InnoDB".
+	Subsystem string
+
+	// Message is the error message.
+	Message string
+
+	// Line is the line of the script that caused the error, or zero if the line is not known.
+	Line int
+}
+
+// Error returns the error in the format printed by the mysql client, or in the format of the server error log without
+// the time and thread ID.
+func (e LogError) Error() string {
+	if !e.Time.IsZero() {
+		var sb strings.Builder
+		sb.WriteString("[ERROR] ")
+		if e.Code != 0 {
+			fmt.Fprintf(&sb, "[MY-%06d] ", e.Code)
+		}
+		if e.Subsystem != "" {
+			fmt.Fprintf(&sb, "[%s] ", e.Subsystem)
+		}
+		sb.WriteString(e.Message)
+
+		return sb.String()
+	}
+
+	if e.Code == 0 {
+		return e.Message
+	}
+
+	if e.Line == 0 {
+		return fmt.Sprintf("ERROR %d (%s): %s", e.Code, e.SQLState, e.Message)
+	}
+
+	return fmt.Sprintf("ERROR %d (%s) at line %d: %s", e.Code, e.SQLState, e.Line, e.Message)
+}
+
+// ParseLogError parses an error line printed by the mysql client, like the lines collected in Config.LoggedErrors.
+// If the line does not have the format of a MySQL error, the returned error only has the Message set to the line.
+func ParseLogError(line string) LogError {
+	m := logErrorPattern.FindStringSubmatch(line)
+	if m == nil {
+		return LogError{Message: line}
+	}
+
+	code, _ := strconv.Atoi(m[1])
+	lineNum, _ := strconv.Atoi(m[3])
+
+	return LogError{Code: code, SQLState: m[2], Message: m[4], Line: lineNum}
+}
+
+// ParseServerLogError parses an error line of the MySQL server error log, e.g.
+// "2023-05-01T10:00:00.123456Z 0 [ERROR] [MY-010119] [Server] Aborting". It returns false if the line is not an error
+// of the server error log.
+func ParseServerLogError(line string) (LogError, bool) {
+	m := serverLogErrorPattern.FindStringSubmatch(line)
+	if m == nil {
+		return LogError{}, false
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, m[1])
+	if err != nil {
+		t, err = time.Parse("2006-01-02 15:04:05", m[1])
+		if err != nil {
+			return LogError{}, false
+		}
+	}
+
+	code, _ := strconv.Atoi(m[2])
+
+	return LogError{Time: t.UTC(), Code: code, Subsystem: m[3], Message: m[4]}, true
+}
+
+// Errors returns the errors in the container logs in the order they were logged: the errors printed by the mysql
+// client, e.g. by the initial SQL scripts, and the errors of the MySQL server error log, e.g. a crash recovery or an
+// aborted connection. Unlike Config.LoggedErrors, the errors are parsed, so that they can be checked without regular
+// expressions.
+func (b *MySQLBox) Errors() []LogError {
+	if b == nil || b.logErrors == nil {
+		return nil
+	}
+
+	return b.logErrors.allErrors()
+}
+
+// InitErrors returns the errors printed by the mysql client in the container logs, e.g. by the initial SQL scripts.
+func (b *MySQLBox) InitErrors() []LogError {
+	if b == nil || b.logErrors == nil {
+		return nil
+	}
+
+	return b.logErrors.errors()
+}
+
+// runInitScriptIgnoringErrors runs an initial SQL script with the mysql client in the container. The client continues
+// after failed statements, and the errors it prints are added to the collected log errors.
+func (b *MySQLBox) runInitScriptIgnoringErrors(ctx context.Context, script []byte) error {
+	res, err := b.runMySQLCLI(ctx, bytes.NewReader(script), []string{"--force"})
+	if err != nil {
+		return err
+	}
+
+	for _, line := range strings.Split(res.Stderr, "\n") {
+		if strings.HasPrefix(line, "ERROR") {
+			b.logErrors.add(line)
+		}
+	}
+
+	return nil
+}
+
+// logErrorCollector collects the error lines of the container logs.
+type logErrorCollector struct {
+	mu sync.Mutex
+
+	// lines is the optional list of Config.LoggedErrors.
+	lines *[]string
+
+	// onError is the optional callback of Config.OnError. Its calls are serialized with onErrorMu, and are made
+	// without holding mu, so that it can call Errors().
+	onError   func(line string)
+	onErrorMu sync.Mutex
+
+	// logErrors contains the errors printed by the mysql client, and all contains them with the server errors.
+	logErrors []LogError
+	all       []LogError
+}
+
+// addLine adds an error printed by the mysql client, or an error of the server error log. Other lines are ignored.
+func (c *logErrorCollector) addLine(line string) {
+	if strings.HasPrefix(line, "ERROR") {
+		c.add(line)
+		return
+	}
+
+	if e, ok := ParseServerLogError(line); ok {
+		c.addServerError(line, e)
+	}
+}
+
+// add adds an error line printed by the mysql client.
+func (c *logErrorCollector) add(line string) {
+	c.mu.Lock()
+	if c.lines != nil {
+		*c.lines = append(*c.lines, line)
+	}
+
+	e := ParseLogError(line)
+	c.logErrors = append(c.logErrors, e)
+	c.all = append(c.all, e)
+	c.mu.Unlock()
+
+	c.notify(line)
+}
+
+// addServerError adds an error line of the server error log.
+func (c *logErrorCollector) addServerError(line string, e LogError) {
+	c.mu.Lock()
+	c.all = append(c.all, e)
+	c.mu.Unlock()
+
+	c.notify(line)
+}
+
+// notify calls the error callback with an error line.
+func (c *logErrorCollector) notify(line string) {
+	if c.onError == nil {
+		return
+	}
+
+	c.onErrorMu.Lock()
+	defer c.onErrorMu.Unlock()
+
+	c.onError(line)
+}
+
+// errors returns a copy of the collected errors printed by the mysql client.
+func (c *logErrorCollector) errors() []LogError {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]LogError(nil), c.logErrors...)
+}
+
+// allErrors returns a copy of all collected errors.
+func (c *logErrorCollector) allErrors() []LogError {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]LogError(nil), c.all...)
+}
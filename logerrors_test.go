@@ -0,0 +1,138 @@
+package mysqlbox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLogError(t *testing.T) {
+	t.Run("at line", func(t *testing.T) {
+		line := "ERROR 1146 (42S02) at line 2: Table 'testing.sales' doesn't exist"
+		e := ParseLogError(line)
+		require.Equal(t, LogError{
+			Code:     1146,
+			SQLState: "42S02",
+			Message:  "Table 'testing.sales' doesn't exist",
+			Line:     2,
+		}, e)
+		require.Equal(t, line, e.Error())
+	})
+
+	t.Run("without line", func(t *testing.T) {
+		line := "ERROR 2002 (HY000): Can't connect to local MySQL server through socket '/var/run/mysqld/mysqld.sock' (2)"
+		e := ParseLogError(line)
+		require.Equal(t, 2002, e.Code)
+		require.Equal(t, "HY000", e.SQLState)
+		require.Equal(t, 0, e.Line)
+		require.Equal(t, line, e.Error())
+	})
+
+	t.Run("other", func(t *testing.T) {
+		e := ParseLogError("ERROR: unexpected failure")
+		require.Equal(t, LogError{Message: "ERROR: unexpected failure"}, e)
+		require.Equal(t, "ERROR: unexpected failure", e.Error())
+	})
+}
+
+func TestParseServerLogError(t *testing.T) {
+	t.Run("mysql 8", func(t *testing.T) {
+		e, ok := ParseServerLogError("2023-05-01T10:00:00.123456Z 0 [ERROR] [MY-010119] [Server] Aborting")
+		require.True(t, ok)
+		require.Equal(t, LogError{
+			Time:      time.Date(2023, 5, 1, 10, 0, 0, 123456000, time.UTC),
+			Code:      10119,
+			Subsystem: "Server",
+			Message:   "Aborting",
+		}, e)
+		require.Equal(t, "[ERROR] [MY-010119] [Server] Aborting", e.Error())
+	})
+
+	t.Run("mysql 5.7", func(t *testing.T) {
+		e, ok := ParseServerLogError("2023-05-01T10:00:00.123456Z 0 [ERROR] Can't open the mysql.plugin table.")
+		require.True(t, ok)
+		require.Equal(t, 0, e.Code)
+		require.Equal(t, "", e.Subsystem)
+		require.Equal(t, "Can't open the mysql.plugin table.", e.Message)
+		require.Equal(t, "[ERROR] Can't open the mysql.plugin table.", e.Error())
+	})
+
+	t.Run("mariadb", func(t *testing.T) {
+		e, ok := ParseServerLogError("2023-05-01 10:00:00 0 [ERROR] InnoDB: Plugin initialization aborted")
+		require.True(t, ok)
+		require.Equal(t, time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC), e.Time)
+		require.Equal(t, "InnoDB: Plugin initialization aborted", e.Message)
+	})
+
+	t.Run("not an error", func(t *testing.T) {
+		for _, line := range []string{
+			"2023-05-01T10:00:00.123456Z 0 [Warning] [MY-011810] [Server] Insecure configuration",
+			"2023-05-01T10:00:00.123456Z 0 [System] [MY-010931] [Server] ready for connections.",
+			"ERROR 1146 (42S02) at line 2: Table 'testing.sales' doesn't exist",
+			"2023-05-01 10:00:00+00:00 [Note] [Entrypoint]: Creating database testing",
+		} {
+			_, ok := ParseServerLogError(line)
+			require.False(t, ok, line)
+		}
+	})
+}
+
+func TestLogErrorCollector(t *testing.T) {
+	var lines []string
+	c := &logErrorCollector{lines: &lines}
+
+	c.add("ERROR 1064 (42000) at line 1: You have an error in your SQL syntax")
+	c.add("ERROR 1146 (42S02) at line 3: Table 'testing.sales' doesn't exist")
+
+	require.Len(t, lines, 2)
+
+	errs := c.errors()
+	require.Len(t, errs, 2)
+	require.Equal(t, 1064, errs[0].Code)
+	require.Equal(t, 3, errs[1].Line)
+
+	b := &MySQLBox{logErrors: c}
+	require.Equal(t, errs, b.InitErrors())
+	require.Nil(t, (&MySQLBox{}).InitErrors())
+}
+
+func TestLogErrorCollectorServerErrors(t *testing.T) {
+	var lines []string
+	c := &logErrorCollector{lines: &lines}
+
+	c.addLine("2023-05-01T10:00:00.123456Z 0 [System] [MY-010931] [Server] ready for connections.")
+	c.addLine("2023-05-01T10:00:01.000000Z 8 [ERROR] [MY-012592] [InnoDB] Operating system error number 2")
+	c.addLine("ERROR 1146 (42S02) at line 3: Table 'testing.sales' doesn't exist")
+
+	require.Equal(t, []string{"ERROR 1146 (42S02) at line 3: Table 'testing.sales' doesn't exist"}, lines)
+
+	b := &MySQLBox{logErrors: c}
+	require.Len(t, b.InitErrors(), 1)
+
+	errs := b.Errors()
+	require.Len(t, errs, 2)
+	require.Equal(t, 12592, errs[0].Code)
+	require.Equal(t, "InnoDB", errs[0].Subsystem)
+	require.Equal(t, 1146, errs[1].Code)
+	require.Nil(t, (&MySQLBox{}).Errors())
+}
+
+func TestLogErrorCollectorOnError(t *testing.T) {
+	var lines []string
+	var c *logErrorCollector
+	c = &logErrorCollector{onError: func(line string) {
+		// The callback can read the collected errors.
+		require.NotEmpty(t, c.allErrors())
+		lines = append(lines, line)
+	}}
+
+	c.addLine("ERROR 1146 (42S02) at line 3: Table 'testing.sales' doesn't exist")
+	c.addLine("2023-05-01T10:00:00.123456Z 0 [Warning] [MY-011810] [Server] Insecure configuration")
+	c.addLine("2023-05-01T10:00:01.000000Z 8 [ERROR] [MY-012592] [InnoDB] Operating system error number 2")
+
+	require.Equal(t, []string{
+		"ERROR 1146 (42S02) at line 3: Table 'testing.sales' doesn't exist",
+		"2023-05-01T10:00:01.000000Z 8 [ERROR] [MY-012592] [InnoDB] Operating system error number 2",
+	}, lines)
+}
@@ -2,7 +2,9 @@ package mysqlbox
 
 import (
 	"bytes"
+	"fmt"
 	"log"
+	"os"
 )
 
 type mysqlLogger struct {
@@ -23,3 +25,27 @@ func newMySQLLogger(buf *bytes.Buffer) *mysqlLogger {
 func (l *mysqlLogger) Print(args ...interface{}) {
 	l.lg.Print(args[0])
 }
+
+// Logger receives the messages of a box, e.g. about image pulls. It is implemented by *log.Logger.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// LoggerFunc is a function that implements Logger, e.g. mysqlbox.LoggerFunc(t.Logf) for logging to a test.
+type LoggerFunc func(format string, v ...interface{})
+
+// Printf calls f.
+func (f LoggerFunc) Printf(format string, v ...interface{}) {
+	f(format, v...)
+}
+
+// DiscardLogger is a Logger that discards all messages.
+var DiscardLogger Logger = LoggerFunc(func(string, ...interface{}) {})
+
+// stderrLogger is the default Logger. It prints messages to stderr, and image pulls also print their progress.
+type stderrLogger struct{}
+
+// Printf prints the message to stderr.
+func (stderrLogger) Printf(format string, v ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", v...)
+}
@@ -0,0 +1,40 @@
+package mysqlbox
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggerFunc(t *testing.T) {
+	var messages []string
+	var logger Logger = LoggerFunc(func(format string, v ...interface{}) {
+		messages = append(messages, fmt.Sprintf(format, v...))
+	})
+
+	logger.Printf("pulling Docker image %s...", "mysql:8")
+	require.Equal(t, []string{"pulling Docker image mysql:8..."}, messages)
+
+	require.NotPanics(t, func() {
+		DiscardLogger.Printf("discarded %d", 1)
+	})
+}
+
+func TestConfigPullOutput(t *testing.T) {
+	c := &Config{}
+	c.LoadDefaults()
+	require.Equal(t, os.Stderr, c.PullOutput)
+
+	c = &Config{Logger: DiscardLogger}
+	c.LoadDefaults()
+	require.Equal(t, io.Discard, c.PullOutput)
+
+	var buf bytes.Buffer
+	c = &Config{PullOutput: &buf}
+	c.LoadDefaults()
+	require.Equal(t, &buf, c.PullOutput)
+}
@@ -0,0 +1,208 @@
+package mysqlbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// serverVersion is a MySQL server version.
+type serverVersion struct {
+	major int
+	minor int
+	patch int
+}
+
+// atLeast reports whether the version is the same as or later than major.minor.patch.
+func (v serverVersion) atLeast(major int, minor int, patch int) bool {
+	if v.major != major {
+		return v.major > major
+	}
+	if v.minor != minor {
+		return v.minor > minor
+	}
+
+	return v.patch >= patch
+}
+
+// String returns the version in major.minor.patch format.
+func (v serverVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.major, v.minor, v.patch)
+}
+
+// parseServerVersion parses a version returned by VERSION(), e.g. "8.0.33" or "5.7.42-log".
+func parseServerVersion(s string) (serverVersion, error) {
+	var v serverVersion
+
+	base, _, _ := strings.Cut(s, "-")
+	parts := strings.SplitN(base, ".", 3)
+	if len(parts) != 3 {
+		return v, fmt.Errorf("invalid server version %s", s)
+	}
+
+	nums := make([]int, 3)
+	for n, part := range parts {
+		num, err := strconv.Atoi(part)
+		if err != nil {
+			return v, fmt.Errorf("invalid server version %s", s)
+		}
+		nums[n] = num
+	}
+
+	return serverVersion{major: nums[0], minor: nums[1], patch: nums[2]}, nil
+}
+
+// serverVersion returns the version of the MySQL server.
+func (b *MySQLBox) serverVersion(ctx context.Context) (serverVersion, error) {
+	var version string
+	err := b.db.QueryRowContext(ctx, "SELECT VERSION()").Scan(&version)
+	if err != nil {
+		return serverVersion{}, err
+	}
+
+	return parseServerVersion(version)
+}
+
+// PurgeBinaryLogs rotates the binary log and deletes all binary log files except the new one. It does nothing if
+// binary logging is disabled.
+func (b *MySQLBox) PurgeBinaryLogs() error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	ctx := context.Background()
+
+	var logBin int
+	err := b.db.QueryRowContext(ctx, "SELECT @@log_bin").Scan(&logBin)
+	if err != nil {
+		return err
+	}
+	if logBin == 0 {
+		return nil
+	}
+
+	_, err = b.db.ExecContext(ctx, "FLUSH BINARY LOGS")
+	if err != nil {
+		return fmt.Errorf("error rotating binary log: %w", err)
+	}
+
+	current, err := currentBinaryLog(ctx, b.db)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.db.ExecContext(ctx, fmt.Sprintf("PURGE BINARY LOGS TO %s", quoteString(current)))
+	if err != nil {
+		return fmt.Errorf("error purging binary logs: %w", err)
+	}
+
+	return nil
+}
+
+// MustPurgeBinaryLogs rotates the binary log and deletes all binary log files except the new one.
+func (b *MySQLBox) MustPurgeBinaryLogs() {
+	err := b.PurgeBinaryLogs()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// currentBinaryLog returns the name of the binary log file in use, which is the last one listed by SHOW BINARY LOGS.
+func currentBinaryLog(ctx context.Context, db *sql.DB) (string, error) {
+	rows, err := db.QueryContext(ctx, "SHOW BINARY LOGS")
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	// The number of columns depends on the server version.
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+
+	var current string
+	for rows.Next() {
+		values := make([]sql.RawBytes, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for n := range values {
+			ptrs[n] = &values[n]
+		}
+
+		err := rows.Scan(ptrs...)
+		if err != nil {
+			return "", err
+		}
+		current = string(values[0])
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	if current == "" {
+		return "", errors.New("no binary log files")
+	}
+
+	return current, nil
+}
+
+// ShrinkTempTablespace releases the disk space used by temporary tables of the box connections. Since MySQL 8.0.13,
+// temporary tables are stored in session temporary tablespaces that are truncated when the session disconnects, so
+// the idle connections of the box DBs are closed with FlushPool(). Earlier versions store temporary tables in the
+// global temporary tablespace, which only shrinks when the server restarts, and an error is returned.
+func (b *MySQLBox) ShrinkTempTablespace() error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	version, err := b.serverVersion(context.Background())
+	if err != nil {
+		return err
+	}
+
+	if !version.atLeast(8, 0, 13) {
+		return fmt.Errorf("MySQL %s cannot shrink the temporary tablespace without a restart", version)
+	}
+
+	return b.FlushPool()
+}
+
+// MustShrinkTempTablespace releases the disk space used by temporary tables of the box connections.
+func (b *MySQLBox) MustShrinkTempTablespace() {
+	err := b.ShrinkTempTablespace()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// maintainAfterClean runs the maintenance of Config.MaintenanceEvery after every n-th call of CleanAllTables().
+// The temporary tablespace is only shrunk when the server version supports it.
+func (b *MySQLBox) maintainAfterClean(ctx context.Context) error {
+	if b.maintenanceEvery <= 0 {
+		return nil
+	}
+
+	if atomic.AddInt64(&b.cleanCount, 1)%int64(b.maintenanceEvery) != 0 {
+		return nil
+	}
+
+	err := b.PurgeBinaryLogs()
+	if err != nil {
+		return err
+	}
+
+	version, err := b.serverVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	if version.atLeast(8, 0, 13) {
+		return b.ShrinkTempTablespace()
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package mysqlbox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseServerVersion(t *testing.T) {
+	v, err := parseServerVersion("8.0.33")
+	require.NoError(t, err)
+	require.Equal(t, serverVersion{major: 8, minor: 0, patch: 33}, v)
+
+	v, err = parseServerVersion("5.7.42-log")
+	require.NoError(t, err)
+	require.Equal(t, "5.7.42", v.String())
+
+	_, err = parseServerVersion("8.0")
+	require.Error(t, err)
+
+	_, err = parseServerVersion("8.x.1")
+	require.Error(t, err)
+}
+
+func TestServerVersionAtLeast(t *testing.T) {
+	v := serverVersion{major: 8, minor: 0, patch: 13}
+
+	require.True(t, v.atLeast(8, 0, 13))
+	require.True(t, v.atLeast(8, 0, 12))
+	require.True(t, v.atLeast(5, 7, 44))
+	require.False(t, v.atLeast(8, 0, 14))
+	require.False(t, v.atLeast(8, 1, 0))
+	require.False(t, v.atLeast(9, 0, 0))
+}
@@ -0,0 +1,111 @@
+package mysqlbox
+
+import (
+	"expvar"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Metrics contains counters and timings of the lifecycle of all boxes in the process, e.g. to find out where the
+// time of a test suite goes. Timings are totals, so that averages can be computed with the counters.
+type Metrics struct {
+	// BoxesStarted is the number of boxes that were started and became ready.
+	BoxesStarted int64
+
+	// BoxesStopped is the number of boxes whose containers were stopped and removed.
+	BoxesStopped int64
+
+	// ImagesPulled is the number of Docker images that were pulled, including those of the reaper and sidecars.
+	ImagesPulled int64
+
+	// PullTime is the total time spent pulling Docker images.
+	PullTime time.Duration
+
+	// ReadyTime is the total time from calling Start() until the MySQL server accepted connections. It includes
+	// pulling the image and running the initial scripts.
+	ReadyTime time.Duration
+
+	// MaxReadyTime is the longest time a box took to become ready.
+	MaxReadyTime time.Duration
+
+	// Cleans is the number of calls to CleanAllTables() and CleanAllTablesContext().
+	Cleans int64
+
+	// CleanTime is the total time spent in CleanAllTables() and CleanAllTablesContext().
+	CleanTime time.Duration
+}
+
+// metrics holds the counters of Metrics. Its fields are only accessed atomically.
+var metrics struct {
+	boxesStarted int64
+	boxesStopped int64
+	imagesPulled int64
+	pullTime     int64
+	readyTime    int64
+	maxReadyTime int64
+	cleans       int64
+	cleanTime    int64
+}
+
+// publishMetricsOnce publishes the metrics to expvar once.
+var publishMetricsOnce sync.Once
+
+// ReadMetrics returns the lifecycle metrics of all boxes started in the process.
+func ReadMetrics() Metrics {
+	return Metrics{
+		BoxesStarted: atomic.LoadInt64(&metrics.boxesStarted),
+		BoxesStopped: atomic.LoadInt64(&metrics.boxesStopped),
+		ImagesPulled: atomic.LoadInt64(&metrics.imagesPulled),
+		PullTime:     time.Duration(atomic.LoadInt64(&metrics.pullTime)),
+		ReadyTime:    time.Duration(atomic.LoadInt64(&metrics.readyTime)),
+		MaxReadyTime: time.Duration(atomic.LoadInt64(&metrics.maxReadyTime)),
+		Cleans:       atomic.LoadInt64(&metrics.cleans),
+		CleanTime:    time.Duration(atomic.LoadInt64(&metrics.cleanTime)),
+	}
+}
+
+// PublishMetrics publishes the lifecycle metrics with expvar under the name "mysqlbox", so that they are served by
+// the /debug/vars handler and can be collected at the end of a CI job. Durations are published in nanoseconds.
+// Calling it more than once has no effect.
+func PublishMetrics() {
+	publishMetricsOnce.Do(func() {
+		expvar.Publish("mysqlbox", expvar.Func(func() interface{} {
+			return ReadMetrics()
+		}))
+	})
+}
+
+// recordPull records a Docker image pull that took d.
+func recordPull(d time.Duration) {
+	atomic.AddInt64(&metrics.imagesPulled, 1)
+	atomic.AddInt64(&metrics.pullTime, int64(d))
+}
+
+// recordReady records a box that became ready d after Start() was called.
+func recordReady(d time.Duration) {
+	atomic.AddInt64(&metrics.readyTime, int64(d))
+
+	for {
+		max := atomic.LoadInt64(&metrics.maxReadyTime)
+		if int64(d) <= max || atomic.CompareAndSwapInt64(&metrics.maxReadyTime, max, int64(d)) {
+			return
+		}
+	}
+}
+
+// recordStarted records a box that was started.
+func recordStarted() {
+	atomic.AddInt64(&metrics.boxesStarted, 1)
+}
+
+// recordStopped records a box that was stopped.
+func recordStopped() {
+	atomic.AddInt64(&metrics.boxesStopped, 1)
+}
+
+// recordClean records a clean of all tables that took d.
+func recordClean(d time.Duration) {
+	atomic.AddInt64(&metrics.cleans, 1)
+	atomic.AddInt64(&metrics.cleanTime, int64(d))
+}
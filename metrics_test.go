@@ -0,0 +1,44 @@
+package mysqlbox
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	before := ReadMetrics()
+
+	recordPull(2 * time.Second)
+	recordReady(3 * time.Second)
+	recordReady(time.Second)
+	recordStarted()
+	recordStopped()
+	recordClean(50 * time.Millisecond)
+
+	after := ReadMetrics()
+	require.Equal(t, before.ImagesPulled+1, after.ImagesPulled)
+	require.Equal(t, before.PullTime+2*time.Second, after.PullTime)
+	require.Equal(t, before.ReadyTime+4*time.Second, after.ReadyTime)
+	require.GreaterOrEqual(t, after.MaxReadyTime, 3*time.Second)
+	require.Equal(t, before.BoxesStarted+1, after.BoxesStarted)
+	require.Equal(t, before.BoxesStopped+1, after.BoxesStopped)
+	require.Equal(t, before.Cleans+1, after.Cleans)
+	require.Equal(t, before.CleanTime+50*time.Millisecond, after.CleanTime)
+
+	t.Run("expvar", func(t *testing.T) {
+		PublishMetrics()
+		PublishMetrics()
+
+		v := expvar.Get("mysqlbox")
+		require.NotNil(t, v)
+
+		var published Metrics
+		err := json.Unmarshal([]byte(v.String()), &published)
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, published.BoxesStarted, after.BoxesStarted)
+	})
+}
@@ -0,0 +1,97 @@
+package mysqlbox
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// Migrator applies schema migrations to the Database. The mysqlboxmigrate package provides a Migrator that runs
+// golang-migrate migrations, and the mysqlboxgoose module provides one that runs goose migrations.
+type Migrator interface {
+	// Up applies all pending migrations to the database.
+	Up(db *sql.DB, database string) error
+
+	// MigrateTo migrates the database up or down to the specified version.
+	MigrateTo(db *sql.DB, database string, version uint) error
+
+	// Version returns the current migration version of the database. It returns 0 if no migrations are applied.
+	Version(db *sql.DB, database string) (uint, error)
+}
+
+// MigrateTo migrates the Database up or down to the specified version using Config.Migrations. It can be used to
+// test the up and down paths of migrations.
+func (b *MySQLBox) MigrateTo(version uint) error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	if b.migrator == nil {
+		return errors.New("no migrations configured")
+	}
+
+	db, err := b.migrationDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return b.migrator.MigrateTo(db, b.databaseName, version)
+}
+
+// MustMigrateTo migrates the Database up or down to the specified version using Config.Migrations.
+func (b *MySQLBox) MustMigrateTo(version uint) {
+	err := b.MigrateTo(version)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// MigrationVersion returns the current migration version of the Database using Config.Migrations. It returns 0 if
+// no migrations are applied.
+func (b *MySQLBox) MigrationVersion() (uint, error) {
+	if b == nil {
+		return 0, errors.New("mysqlbox is nil")
+	}
+
+	if b.migrator == nil {
+		return 0, errors.New("no migrations configured")
+	}
+
+	db, err := b.migrationDB()
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	return b.migrator.Version(db, b.databaseName)
+}
+
+// MustMigrationVersion returns the current migration version of the Database using Config.Migrations.
+func (b *MySQLBox) MustMigrationVersion() uint {
+	version, err := b.MigrationVersion()
+	if err != nil {
+		panic(err)
+	}
+
+	return version
+}
+
+// migrateUp applies all pending migrations of Config.Migrations.
+func (b *MySQLBox) migrateUp() error {
+	db, err := b.migrationDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return b.migrator.Up(db, b.databaseName)
+}
+
+// migrationDB opens a DB connection to the Database that allows multiple statements per query, which is needed to
+// run migration files.
+func (b *MySQLBox) migrationDB() (*sql.DB, error) {
+	mysqlCfg := newMySQLConfig(b.host, b.port, b.databaseName, b.rootPassword, b.tlsConfigName())
+	mysqlCfg.MultiStatements = true
+
+	return sql.Open("mysql", mysqlCfg.FormatDSN())
+}
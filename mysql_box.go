@@ -5,30 +5,39 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net"
+	"net/http"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strconv"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/errdefs"
-	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/go-connections/nat"
 	"github.com/go-sql-driver/mysql"
 )
 
 const startTimeout = time.Second * 90
+const defaultPullTimeout = time.Minute * 10
+const defaultCreateTimeout = time.Minute
+const defaultCleanWorkers = 4
 const waitBetweenPings = time.Millisecond * 500
 const defaultMySQLImage = "mysql:8"
+const defaultBindIP = "127.0.0.1"
+const stopKillGrace = time.Second * 10
 
 var (
 	// ErrTimeout represents a timeout in an operation.
@@ -37,12 +46,27 @@ var (
 
 // Config contains MySQLBox settings.
 type Config struct {
-	// ContainerName specifies the MySQL container name. If blank, it will be generated as "mysqlbox-<random name>".
+	// ContainerName specifies the MySQL container name. If blank, it will be generated as "mysqlbox-<ID>", where
+	// the ID is returned by IDGenerator.
 	ContainerName string
 
+	// IDGenerator is an optional function that returns the ID used in generated container names. The default
+	// generator returns IDs that start with a timestamp, so that container names sort by creation time.
+	IDGenerator func() string
+
 	// Image specifies what Docker image to use. If blank, it defaults to "mysql:8".
 	Image string
 
+	// RegistryMirror specifies a registry that mirrors Docker Hub, e.g. "mirror.example.com" or
+	// "mirror.example.com/dockerhub". The Docker Hub images used by the box, including the MySQL, reaper, and sidecar
+	// images, are pulled from and run as images of the mirror, e.g. "mysql:8" becomes
+	// "mirror.example.com/library/mysql:8". Images of other registries are not changed.
+	RegistryMirror string
+
+	// RewriteImage is an optional function that rewrites the names of the images used by the box before they are
+	// pulled and run, e.g. to redirect them to an internal registry. It is applied after RegistryMirror.
+	RewriteImage func(image string) string
+
 	// Database specifies the name of the database to create. If blank, it defaults to "testing".
 	Database string
 
@@ -52,29 +76,249 @@ type Config struct {
 	// MySQLPort specifies which port the MySQL server port (3306) will be bound to in the container.
 	MySQLPort int
 
+	// MySQLPortMax makes Start() try the next ports up to MySQLPortMax when MySQLPort is already taken on the host,
+	// e.g. by another box of a parallel CI job, instead of failing. The bound port is in the address returned by
+	// DBAddr(). It is ignored unless it is greater than MySQLPort.
+	MySQLPortMax int
+
+	// BindIP specifies the host IP address that the MySQL, X Protocol, and admin ports are bound to, e.g. "0.0.0.0"
+	// to reach the server from another machine or from outside a VM. The Toxiproxy and traffic capture proxies and the
+	// admin UI are bound to it as well. If blank, it defaults to 127.0.0.1, so the server can only be reached from the
+	// host. The box connects to BindIP, or to 127.0.0.1 if BindIP binds all interfaces.
+	BindIP string
+
+	// Network specifies the name of an existing Docker network that the container is connected to, so that other
+	// containers on the network can reach the MySQL server. See ContainerDSN().
+	Network string
+
+	// NetworkAliases specifies additional hostnames of the container on Network.
+	NetworkAliases []string
+
+	// EnableTLS generates a CA, server, and client certificate set, configures the MySQL server to use the server
+	// certificate, and requires secure transport for all connections. The DBs and DSNs returned by the box use TLS,
+	// and TLSConfig() returns a TLS config that verifies the server certificate.
+	EnableTLS bool
+
+	// UnixSocket bind-mounts the socket directory of the MySQL server to a temporary host directory, so that tests
+	// can connect through the unix socket with SocketDSN() or ConnectSocketDB(), e.g. to test the authentication of
+	// 'user'@'localhost' accounts or to avoid the TCP overhead. The other DBs of the box still connect through TCP.
+	// Sockets cannot be shared across the virtual machine of Docker Desktop, so this only works with a Docker daemon
+	// that runs on the same Linux host. It is not supported on Windows.
+	UnixSocket bool
+
+	// AdminInterface enables the administrative connection interface of MySQL 8.0.14 and later, a separate port that
+	// only accepts users with the SERVICE_CONNECTION_ADMIN privilege. Its connections are not limited by
+	// max_connections and are not closed in offline mode. See AdminAddr() and AdminDSN().
+	AdminInterface bool
+
 	// InitialSQL specifies an SQL script stored in a file or a buffer that will be run against the Database
 	// when the MySQL server container is started.
 	InitialSQL *Data
 
+	// InitialSQLs specifies additional SQL scripts that will be run against the Database when the MySQL server
+	// container is started. The scripts are run in order, after InitialSQL.
+	InitialSQLs []*Data
+
+	// InitScriptsDir specifies a host directory that will be mounted as the container's /docker-entrypoint-initdb.d
+	// directory. All .sql, .sql.gz, and .sh scripts in the directory are run by the MySQL image entrypoint in
+	// alphabetical order when the container is started. It cannot be combined with InitialSQL and InitialSQLs. The
+	// scripts are not replayed by Reset(), which returns an error for boxes that use InitScriptsDir.
+	InitScriptsDir string
+
+	// LintInitialSQL checks InitialSQL and InitialSQLs with LintScript() before the container is started, so that
+	// Start() fails fast with the lines of the issues when a script is obviously broken, e.g. has an unterminated
+	// string or drops the mysql database. If nil, the scripts are not checked.
+	LintInitialSQL *ScriptLintConfig
+
+	// IgnoreInitErrors runs the statements of InitialSQL and InitialSQLs after a failed statement, so that a partially
+	// incompatible legacy dump still produces a usable box. The scripts are run with the mysql client in the container
+	// after MySQL is ready to accept connections, instead of by the MySQL image entrypoint. The errors are collected
+	// in LoggedErrors and returned by InitErrors().
+	IgnoreInitErrors bool
+
+	// Migrations specifies schema migrations that are applied to the Database after MySQL is ready to accept
+	// connections, e.g. mysqlboxmigrate.Source("file://migrations"). The migrations are applied again by Reset().
+	Migrations Migrator
+
 	// DoNotCleanTables specifies a list of MySQL tables in Database that will not be cleaned when CleanAllTables()
 	// is called.
 	DoNotCleanTables []string
 
+	// ReadOnlyDatabase makes the Database read-only after the initial scripts are run, so that it can be shared as a
+	// fixture database by many tests. Tests that need to write can use ScratchDB(). This requires MySQL 8.0.22 or
+	// later.
+	ReadOnlyDatabase bool
+
+	// CleanWorkers specifies the number of connections used to truncate tables concurrently when CleanAllTables()
+	// is called. The default is 4.
+	CleanWorkers int
+
+	// VerifyEmptyTables makes AutoClean() fail the test if a table of the Database, except those in
+	// DoNotCleanTables, has rows at the start of the test. The failure shows the leftover rows, so that tests that do
+	// not clean up after themselves are found even when the tests run in a different order.
+	VerifyEmptyTables bool
+
+	// KillLockHolders kills the connections that hold metadata locks on the tables of the Database before the tables
+	// are truncated by CleanAllTables() or dropped by Reset(), so that a transaction leaked by a previous test does
+	// not make them wait forever. The killed connections are logged and returned by KilledQueries(). This requires
+	// the metadata lock instrumentation of the Performance Schema, which is enabled by default in MySQL 8.0.
+	KillLockHolders bool
+
 	// Stdout is an optional writer where the container log stdout will be sent to.
 	Stdout io.Writer
 	// Stderr is an optional writer where the container log stderr will be sent to.
 	Stderr io.Writer
 
+	// Logger receives the messages of the box, e.g. about image pulls. If nil, messages are printed to stderr along
+	// with the image pull progress. Use DiscardLogger to silence them.
+	Logger Logger
+
+	// PullOutput is an optional writer where the progress of Docker image pulls is written. If nil, the progress is
+	// written to stderr when Logger is not set, and discarded otherwise. Use io.Discard to hide the progress, e.g. in
+	// CI logs.
+	PullOutput io.Writer
+
+	// PullRetries specifies how many times a Docker image pull is retried after a transient registry error, e.g. a
+	// rate limit or a connection reset. The retries wait with exponential backoff starting at 1 second, within
+	// PullTimeout. If zero, it defaults to 3. Set it to a negative number to disable retries.
+	PullRetries int
+
+	// DockerRetries specifies how many times creating or starting a container is retried after a transient Docker
+	// daemon error, e.g. an unexpected EOF, a connection reset, or a conflict with a container of the same name that is
+	// being removed. The retries wait with exponential backoff starting at 500 milliseconds, within CreateTimeout. If
+	// zero, it defaults to 3. Set it to a negative number to disable retries.
+	DockerRetries int
+
 	// LoggedErrors is an optional list of strings that will contain error messages from the container stderr logs.
+	//
+	// Deprecated: The list is appended to by a goroutine that reads the logs, so reading it while the box runs is a
+	// data race. Use OnError, or Errors() and InitErrors(), instead.
 	LoggedErrors *[]string
 
+	// OnError is an optional function that is called with each error line of the container logs: the errors printed
+	// by the mysql client, e.g. for the initial SQL scripts, and the errors of the server error log. It is called
+	// from the goroutine that reads the logs, one line at a time, so it must not block. The parsed errors are also
+	// returned by Errors().
+	OnError func(line string)
+
 	// StartTimeout is the maximum time to wait for the container to start and MySQL ready to accept connections.
-	// The default is 30 seconds.
+	//
+	// Deprecated: Use ReadyTimeout instead. StartTimeout is only used when ReadyTimeout is not set.
 	StartTimeout time.Duration
 
+	// PullTimeout is the maximum time to wait for the Docker image to be pulled when it is not available locally.
+	// The default is 10 minutes.
+	PullTimeout time.Duration
+
+	// CreateTimeout is the maximum time to wait for the container to be created, and separately for it to be
+	// started. It does not include the time spent pulling the image. The default is 1 minute.
+	CreateTimeout time.Duration
+
+	// ReadyTimeout is the maximum time to wait for MySQL to accept connections after the container is started.
+	// The default is 90 seconds.
+	ReadyTimeout time.Duration
+
+	// WaitFor specifies when MySQL is ready after the container is started, e.g. mysqlbox.WaitForLog() to wait for a
+	// log line, or mysqlbox.WaitForSQL() to wait for a query result. If nil, the box waits for the container
+	// healthcheck, which runs mysqladmin ping, and then for a ping from the host.
+	WaitFor WaitStrategy
+
+	// MaintenanceEvery runs PurgeBinaryLogs() and ShrinkTempTablespace() after every MaintenanceEvery-th call of
+	// CleanAllTables(), so that the disk usage of long-lived boxes does not grow across many test runs. The
+	// temporary tablespace is only shrunk on MySQL 8.0.13 or later. If zero, no maintenance is run.
+	MaintenanceEvery int
+
+	// Profile selects a preset of server settings from ServerProfiles, e.g. "fast-ci" for fast, non-durable CI boxes
+	// or "prod-like" for production settings like a strict sql_mode. It is not related to the dataset profiles of
+	// LoadProfile(). If blank, the server uses the defaults of the image.
+	Profile string
+
+	// DisableAutoTuning disables the default tuning of the MySQL server for test workloads. By default, the
+	// InnoDB buffer pool size is set from the Memory limit of the container, or from the memory of the Docker host if
+	// there is no limit, and the thread counts are set from the CPUs of the Docker host. The redo log is flushed once
+	// per second instead of on every commit, the binary log is not synced, and client hostnames are not resolved. The
+	// options of the selected Profile override the tuning.
+	DisableAutoTuning bool
+
+	// Memory limits the memory of the MySQL container in bytes. If zero, the container has no memory limit.
+	Memory int64
+
+	// UnsafeFast trades all durability for the speed of write-heavy tests: InnoDB does not flush the redo log on
+	// commit and does not use the doublewrite buffer, and the binary log is disabled. Data can be lost or corrupted
+	// when the server crashes, which does not matter for throwaway databases, but features that rely on the binary
+	// log do not work. The options of the selected Profile override it.
+	UnsafeFast bool
+
+	// SlowQueryLog enables the slow query log of the MySQL server with the settings, so that the slow or unindexed
+	// queries run by a test can be checked with SlowQueries(). If nil, the slow query log is disabled.
+	SlowQueryLog *SlowQueryLogConfig
+
+	// QueryWatchdog kills the queries that run longer than the specified duration with KILL QUERY, so that a wedged
+	// test does not hold locks that make other tests sharing the box fail. The killed queries are returned by
+	// KilledQueries(). MySQL reports the running time of queries in whole seconds, so the duration is rounded up to
+	// seconds. If zero, queries are not killed.
+	QueryWatchdog time.Duration
+
+	// IdleShutdown stops the MySQL server when no client has been connected to it for the specified duration, so
+	// that boxes that are left running, e.g. after the test process is killed, do not run indefinitely. The
+	// connections are checked by a watcher container every 10 seconds, or every IdleShutdown if it is shorter. The
+	// idle connections of DBs returned by the box count as connected clients. The duration is rounded up to whole
+	// seconds. If zero, the server is not stopped.
+	IdleShutdown time.Duration
+
 	// StopTimeout is the amount of time to wait for the container to gracefully stop when Stop() is called.
-	// When the timeout is reached, the container is forcefully stopped.
+	// When the timeout is reached, the container is forcefully stopped. If the container is still not stopped and
+	// removed 10 seconds after the timeout, e.g. because the Docker daemon does not respond, it is killed and
+	// force-removed, so that Stop() does not hang.
 	StopTimeout time.Duration
+
+	// RetainFor keeps the container after Stop() for the duration instead of removing it, so that the container of
+	// a failed test can be inspected in CI, e.g. with docker logs or docker cp. A retained container is removed by
+	// Cleanup() (or Config.Cleanup) once the duration has passed since it stopped. It is not removed by the reaper
+	// when the process exits, and its name stays taken until it is removed, so a fixed ContainerName cannot be reused
+	// by the next box.
+	RetainFor time.Duration
+
+	// Cleanup specifies settings for removing orphaned MySQLBox containers before the container is created.
+	// If nil, orphaned containers are not removed. See the Cleanup() function.
+	Cleanup *CleanupConfig
+
+	// Reaper starts a reaper container that removes the MySQLBox containers of this process when the process exits,
+	// even if it is killed and cannot run any cleanup code. The reaper is started once per process.
+	Reaper bool
+
+	// ReaperImage specifies the Docker image of the reaper container. If blank, it defaults to
+	// "testcontainers/ryuk:0.5.1".
+	ReaperImage string
+
+	// ReaperDockerSocket specifies the path of the Docker socket on the Docker host that is mounted in the reaper
+	// container. If blank, it is the path of the Docker client host when it is a unix socket, e.g. of DOCKER_HOST.
+	// Set it when the path differs on the Docker host, e.g. "/var/run/docker.sock" for Colima. If the path is not
+	// known, e.g. for a remote tcp:// host, the reaper is skipped.
+	ReaperDockerSocket string
+
+	// Toxiproxy starts a Toxiproxy container in front of the MySQL server, so that network faults can be injected
+	// into the connections made through it. See Proxy(). The DBs returned by the box connect to MySQL directly.
+	Toxiproxy bool
+
+	// ToxiproxyImage specifies the Docker image of the Toxiproxy container. If blank, it defaults to
+	// "ghcr.io/shopify/toxiproxy:2.5.0".
+	ToxiproxyImage string
+
+	// CaptureTraffic starts a proxy in front of the MySQL server that captures the MySQL protocol packets of the
+	// connections made through it, for debugging driver-level issues like split packets or auth switch requests. See
+	// Capture(). The DBs returned by the box connect to MySQL directly.
+	CaptureTraffic bool
+
+	// InstrumentSQL is an optional function that wraps the driver connector of the DBs returned by DB() and
+	// ConnectDB(). It can be used to add tracing to every query, e.g. with otelsql.WrapConnector.
+	InstrumentSQL func(driver.Connector) driver.Connector
+
+	// DebugUIAddr starts a read-only HTTP server on the address, e.g. "127.0.0.1:0", that serves a page with the
+	// tables of the Database and their row counts, the recent statements of the MySQL server, and the container logs.
+	// It is meant for looking at the state of a box while debugging a test. The server address is returned by
+	// DebugUIAddr(). If blank, the server is not started.
+	DebugUIAddr string
 }
 
 // LoadDefaults initializes some blank attributes of Config to default values.
@@ -83,16 +327,60 @@ func (c *Config) LoadDefaults() {
 		c.Image = defaultMySQLImage
 	}
 
+	if c.ReaperImage == "" {
+		c.ReaperImage = defaultReaperImage
+	}
+
 	if c.Database == "" {
 		c.Database = "testing"
 	}
 
 	if c.ContainerName == "" {
-		c.ContainerName = fmt.Sprintf("mysqlbox-%s", randomID())
+		generateID := c.IDGenerator
+		if generateID == nil {
+			generateID = randomID
+		}
+		c.ContainerName = fmt.Sprintf("mysqlbox-%s", generateID())
+	}
+
+	if c.ReadyTimeout == 0 {
+		c.ReadyTimeout = c.StartTimeout
+	}
+
+	if c.ReadyTimeout == 0 {
+		c.ReadyTimeout = startTimeout
+	}
+
+	if c.PullTimeout == 0 {
+		c.PullTimeout = defaultPullTimeout
+	}
+
+	if c.CreateTimeout == 0 {
+		c.CreateTimeout = defaultCreateTimeout
+	}
+
+	if c.CleanWorkers <= 0 {
+		c.CleanWorkers = defaultCleanWorkers
 	}
 
-	if c.StartTimeout == 0 {
-		c.StartTimeout = startTimeout
+	if c.Logger == nil {
+		c.Logger = stderrLogger{}
+	}
+
+	if c.PullOutput == nil {
+		c.PullOutput = defaultPullOutput(c.Logger)
+	}
+
+	if c.PullRetries == 0 {
+		c.PullRetries = defaultPullRetries
+	}
+
+	if c.BindIP == "" {
+		c.BindIP = defaultBindIP
+	}
+
+	if c.DockerRetries == 0 {
+		c.DockerRetries = defaultDockerRetries
 	}
 }
 
@@ -106,29 +394,138 @@ type MySQLBox struct {
 	cli           *client.Client
 	containerName string
 	containerID   string
-	schemaFile    *os.File
+	schemaFiles   []*os.File
+
+	// network is the Docker network the container is connected to, and networkAliases are its aliases on it.
+	network        string
+	networkAliases []string
+
+	// attached is set when the box was returned by Attach(). The container of an attached box is not stopped by
+	// Stop().
+	attached bool
+
+	// proxy is the Toxiproxy proxy when Config.Toxiproxy is set.
+	proxy *Proxy
+
+	// capture is the capturing proxy when Config.CaptureTraffic is set.
+	capture *TrafficCapture
+
+	// debugUI is the debug UI HTTP server when Config.DebugUIAddr is set, and debugUIAddr is its address.
+	debugUI     *http.Server
+	debugUIAddr string
+
+	// adminUI is the Adminer container started by StartAdminUI().
+	adminUI   *adminUI
+	adminUIMu sync.Mutex
+
+	// tls contains the generated certificates when Config.EnableTLS is set.
+	tls *tlsCerts
+
+	// socketDir is the host directory containing the mounted mysqld socket directory when Config.UnixSocket is set.
+	socketDir string
+
+	// initialSQLs contains the initial SQL scripts in the order they are run. They are replayed by Reset().
+	initialSQLs [][]byte
+
+	// initScriptsDir is the host directory of Config.InitScriptsDir. Its scripts cannot be replayed by Reset().
+	initScriptsDir string
+
+	// migrator applies the migrations of Config.Migrations.
+	migrator Migrator
 
 	// stoppedCh receives the signal when the container is stopped.
 	stoppedCh chan bool
 
 	containerStopTimeout time.Duration
 
+	// retainFor is the time that the container is kept after it stops. If zero, the container is removed when it
+	// stops.
+	retainFor time.Duration
+
 	// logBuf is where the mysql logs are stored (these are logs coming from the client library and are not the server logs)
 	logBuf *bytes.Buffer
 	cout   io.Writer
 	cerr   io.Writer
 
+	// logger receives the messages of the box.
+	logger Logger
+
+	// waitFor decides when the MySQL server is ready. If nil, the default strategy is used.
+	waitFor WaitStrategy
+
+	// pull contains the settings of Docker image pulls.
+	pull pullConfig
+
+	// retry contains the settings of retrying container creates and starts.
+	retry dockerRetry
+
+	// rewriteImage rewrites the names of the images of sidecar containers. It is nil if they are not rewritten.
+	rewriteImage func(string) string
+
+	// logErrors collects the error messages from the container stderr logs.
+	logErrors *logErrorCollector
+
+	// xPort is the assigned port to the container that maps to the mysqld X Protocol port
+	xPort int
+
+	// adminPort is the assigned port to the container that maps to the mysqld admin port. It is zero if
+	// Config.AdminInterface is not set.
+	adminPort int
+
+	// host is the host address that the box connects to.
+	host string
+
+	// bindIP is the host IP address that the ports of the box are bound to.
+	bindIP string
+
 	// port is the assigned port to the container that maps to the mysqld port
-	port             int
-	doNotCleanTables []string
+	port              int
+	doNotCleanTables  []string
+	readOnly          bool
+	cleanWorkers      int
+	killLockHolders   bool
+	verifyEmptyTables bool
+
+	// maintenanceEvery is the number of CleanAllTables() calls between maintenance runs.
+	maintenanceEvery int
+
+	// cleanCount is the number of CleanAllTables() calls. It must be accessed atomically.
+	cleanCount int64
+
+	// instrumentSQL wraps the driver connector of the DBs created by the box.
+	instrumentSQL func(driver.Connector) driver.Connector
+
+	// derivedDBs contains the DB connections returned by ConnectDB().
+	derivedDBs   []*sql.DB
+	derivedDBsMu sync.Mutex
+
+	// slowQueryLog is set when the slow query log is enabled with Config.SlowQueryLog.
+	slowQueryLog bool
+
+	// stopQueryWatchdog stops the query watchdog of Config.QueryWatchdog.
+	stopQueryWatchdog context.CancelFunc
+
+	// killedQueries contains the queries killed by the query watchdog.
+	killedQueries   []KilledQuery
+	killedQueriesMu sync.Mutex
+
+	// seedSources contains the fixtures and dataset profiles loaded into the Database, for SeedReport().
+	seedSources   []SeedSource
+	seedSourcesMu sync.Mutex
+
+	// invariants contains the invariants registered with RegisterInvariant().
+	invariants   []invariant
+	invariantsMu sync.Mutex
 }
 
 // Start creates a Docker container that runs an instance of MySQL server. The passed Config object contains settings
 // for the container, the MySQL service, and initial data. To stop the created container, call the Stop() method.
-// Start() returns an ErrTimeout if the container MySQL service cannot accept connections within the timeout period
-// (see Config.StartTimeout). When ErrTimeout is returned, the container is still running and an instance of MySQLBox
-// is returned along with the error.
+// Start() returns an error wrapping ErrTimeout if a lifecycle phase does not complete within its timeout period
+// (see Config.PullTimeout, Config.CreateTimeout, and Config.ReadyTimeout). When the MySQL service cannot accept
+// connections within Config.ReadyTimeout, the container is still running and an instance of MySQLBox is returned
+// along with the error.
 func Start(c *Config) (*MySQLBox, error) {
+	started := time.Now()
 	var envVars []string
 
 	// Load config
@@ -138,47 +535,145 @@ func Start(c *Config) (*MySQLBox, error) {
 
 	c.LoadDefaults()
 
+	if net.ParseIP(c.BindIP) == nil {
+		return nil, fmt.Errorf("invalid bind IP %q", c.BindIP)
+	}
+	host := connectHost(c.BindIP)
+
+	// Server profile
+	var profile ServerProfile
+	if c.Profile != "" {
+		var err error
+		profile, err = serverProfile(c.Profile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// mysql log buffer
 	logbuf := bytes.NewBuffer(nil)
 	mylog := newMySQLLogger(logbuf)
 
-	// Initial schema - write to file so it can be passed to docker
-	var schemaFile *os.File
-	if c.InitialSQL != nil && (c.InitialSQL.reader != nil || c.InitialSQL.buf != nil) {
+	// Initial scripts directory
+	var initScriptsDir string
+	if c.InitScriptsDir != "" {
+		if c.InitialSQL != nil || len(c.InitialSQLs) > 0 {
+			return nil, errors.New("InitScriptsDir cannot be combined with InitialSQL or InitialSQLs")
+		}
+
 		var err error
-		schemaFile, err = ioutil.TempFile(os.TempDir(), "schema-*.sql")
+		initScriptsDir, err = filepath.Abs(c.InitScriptsDir)
 		if err != nil {
-			return nil, fmt.Errorf("error creating schema file: %w", err)
+			return nil, fmt.Errorf("error resolving init scripts directory: %w", err)
 		}
 
-		// Make the schema file readable by others
-		err = os.Chmod(schemaFile.Name(), 0644)
+		info, err := os.Stat(initScriptsDir)
 		if err != nil {
-			return nil, fmt.Errorf("error setting schema file permissions: %w", err)
+			return nil, fmt.Errorf("error reading init scripts directory: %w", err)
+		}
+
+		if !info.IsDir() {
+			return nil, fmt.Errorf("init scripts path %s is not a directory", initScriptsDir)
+		}
+	}
+
+	// Initial scripts - write to files so they can be passed to docker
+	var scripts []*Data
+	if c.InitialSQL != nil {
+		scripts = append(scripts, c.InitialSQL)
+	}
+	scripts = append(scripts, c.InitialSQLs...)
+
+	for n, script := range scripts {
+		if script == nil {
+			return nil, fmt.Errorf("initial SQL script %d is nil", n+1)
+		}
+	}
+
+	// The temporary files are removed if Start fails, unless the box is returned, which removes them when stopped.
+	var schemaFiles []*os.File
+	var certs *tlsCerts
+	var socketDir string
+	keepFiles := false
+	defer func() {
+		if keepFiles {
+			return
+		}
+
+		removeSchemaFiles(schemaFiles)
+		if certs != nil {
+			certs.cleanup()
+		}
+		if socketDir != "" {
+			_ = os.RemoveAll(socketDir)
 		}
+	}()
 
-		var src io.Reader
+	var initialSQLs [][]byte
+	for _, script := range scripts {
+		schemaFile, content, err := writeSchemaFile(script)
+		if err != nil {
+			return nil, err
+		}
 
-		if c.InitialSQL.reader != nil {
-			src = c.InitialSQL.reader
-		} else if c.InitialSQL.buf != nil {
-			src = c.InitialSQL.buf
+		if schemaFile != nil {
+			schemaFiles = append(schemaFiles, schemaFile)
+			initialSQLs = append(initialSQLs, content)
 		}
+	}
 
-		_, err = io.Copy(schemaFile, src)
+	if c.LintInitialSQL != nil {
+		err := lintInitialSQLs(initialSQLs, c.LintInitialSQL)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	// TLS certificates
+	if c.EnableTLS {
+		var err error
+		certs, err = generateTLSCerts(net.ParseIP(host))
+		if err != nil {
+			return nil, fmt.Errorf("error generating TLS certificates: %w", err)
+		}
+	}
+
+	// Unix socket directory
+	if c.UnixSocket {
+		if runtime.GOOS == "windows" {
+			return nil, errors.New("unix socket is not supported on Windows")
+		}
+
+		var err error
+		socketDir, _, err = createSocketDir()
+		if err != nil {
+			return nil, fmt.Errorf("error creating unix socket directory: %w", err)
+		}
+	}
+
 	// Create docker client
-	cli, err := client.NewClientWithOpts(client.FromEnv)
+	cli, err := newDockerClient()
 	if err != nil {
 		return nil, err
 	}
 
 	ctx := context.Background()
-	cli.NegotiateAPIVersion(ctx)
+
+	// Remove orphaned containers
+	if c.Cleanup != nil {
+		_, err := cleanupContainers(ctx, cli, c.Cleanup)
+		if err != nil {
+			return nil, fmt.Errorf("error cleaning up orphaned containers: %w", err)
+		}
+	}
+
+	// Start reaper
+	if c.Reaper {
+		err := startReaper(ctx, cli, c)
+		if err != nil {
+			return nil, fmt.Errorf("error starting reaper: %w", err)
+		}
+	}
 
 	// Load container env vars
 	envVars = append(envVars, fmt.Sprintf("MYSQL_DATABASE=%s", c.Database))
@@ -191,25 +686,84 @@ func Start(c *Config) (*MySQLBox, error) {
 		rootPassword = c.RootPassword
 	}
 
+	hostname, _ := os.Hostname()
+
 	// Container config
 	cfg := &container.Config{
-		Image: c.Image,
-		Env:   envVars,
+		Image:       c.imageName(c.Image),
+		Env:         envVars,
+		Healthcheck: mysqlHealthcheck(),
 		Cmd: []string{
 			"--default-authentication-plugin=mysql_native_password",
 			"--general-log=1",
 			"--general-log-file=/var/lib/mysql/general-log.log",
 		},
 		ExposedPorts: map[nat.Port]struct{}{
-			"3306/tcp": {},
+			"3306/tcp":  {},
+			"33060/tcp": {},
 		},
 		Labels: map[string]string{
-			"com.github.virgild.mysqlbox": "1",
+			containerLabel: "1",
+			pidLabel:       strconv.Itoa(os.Getpid()),
+			hostnameLabel:  hostname,
+			sessionLabel:   sessionID,
 		},
 	}
 
+	// The reaper removes the containers with the session label when the process exits.
+	if c.RetainFor > 0 {
+		delete(cfg.Labels, sessionLabel)
+		cfg.Labels[retainLabel] = c.RetainFor.String()
+	}
+
+	// The Toxiproxy container shares the network namespace of the MySQL container, which publishes its ports.
+	if c.Toxiproxy {
+		cfg.ExposedPorts[toxiproxyAPIPort] = struct{}{}
+		cfg.ExposedPorts[toxiproxyListenPort] = struct{}{}
+	}
+
+	if c.AdminInterface {
+		cfg.Cmd = append(cfg.Cmd, "--admin-address=0.0.0.0", "--admin-port="+adminPortNumber)
+		cfg.ExposedPorts[adminPort] = struct{}{}
+	}
+
+	if c.SlowQueryLog != nil {
+		cfg.Cmd = append(cfg.Cmd, c.SlowQueryLog.slowQueryLogArgs()...)
+	}
+
+	// The tuning options are added before the profile options, which override them.
+	if !c.DisableAutoTuning {
+		memory := c.Memory
+		var cpus int
+		info, err := cli.Info(ctx)
+		if err == nil {
+			if memory == 0 {
+				memory = info.MemTotal
+			}
+			cpus = info.NCPU
+		} else {
+			c.Logger.Printf("error reading Docker host resources, using default tuning: %s", err.Error())
+		}
+		cfg.Cmd = append(cfg.Cmd, autoTuningArgs(memory, cpus)...)
+	}
+
+	if c.UnsafeFast {
+		cfg.Cmd = append(cfg.Cmd, unsafeFastArgs...)
+	}
+
+	cfg.Cmd = append(cfg.Cmd, profile.Args...)
+
+	if certs != nil {
+		cfg.Cmd = append(cfg.Cmd,
+			fmt.Sprintf("--ssl-ca=%s/ca.pem", containerCertsDir),
+			fmt.Sprintf("--ssl-cert=%s/server-cert.pem", containerCertsDir),
+			fmt.Sprintf("--ssl-key=%s/server-key.pem", containerCertsDir),
+			"--require-secure-transport=ON",
+		)
+	}
+
 	portBinding := nat.PortBinding{
-		HostIP:   "127.0.0.1",
+		HostIP:   c.BindIP,
 		HostPort: "0",
 	}
 
@@ -217,41 +771,125 @@ func Start(c *Config) (*MySQLBox, error) {
 		portBinding.HostPort = fmt.Sprintf("%d", c.MySQLPort)
 	}
 
+	// The entrypoint runs the scripts in /docker-entrypoint-initdb.d sorted by name. With IgnoreInitErrors, the
+	// scripts are run by the box after MySQL is ready.
 	var mounts []mount.Mount
-	if schemaFile != nil {
+	if !c.IgnoreInitErrors {
+		for n, schemaFile := range schemaFiles {
+			mounts = append(mounts, mount.Mount{
+				Type:     mount.TypeBind,
+				Source:   schemaFile.Name(),
+				Target:   fmt.Sprintf("/docker-entrypoint-initdb.d/%03d-schema.sql", n),
+				ReadOnly: true,
+			})
+		}
+	}
+
+	if initScriptsDir != "" {
+		mounts = append(mounts, mount.Mount{
+			Type:     mount.TypeBind,
+			Source:   initScriptsDir,
+			Target:   "/docker-entrypoint-initdb.d",
+			ReadOnly: true,
+		})
+	}
+
+	if certs != nil {
 		mounts = append(mounts, mount.Mount{
 			Type:     mount.TypeBind,
-			Source:   schemaFile.Name(),
-			Target:   "/docker-entrypoint-initdb.d/schema.sql",
+			Source:   certs.dir,
+			Target:   containerCertsDir,
 			ReadOnly: true,
 		})
 	}
 
+	if socketDir != "" {
+		mounts = append(mounts, mount.Mount{
+			Type:   mount.TypeBind,
+			Source: filepath.Join(socketDir, "mysqld"),
+			Target: containerSocketDir,
+		})
+	}
+
 	// Host config
 	hostCfg := &container.HostConfig{
-		AutoRemove: true,
+		AutoRemove: c.RetainFor <= 0,
 		PortBindings: map[nat.Port][]nat.PortBinding{
 			"3306/tcp": {
 				portBinding,
 			},
+			"33060/tcp": {
+				{HostIP: c.BindIP, HostPort: "0"},
+			},
 		},
 		Mounts: mounts,
+		Resources: container.Resources{
+			Memory: c.Memory,
+		},
+	}
+
+	if profile.Tmpfs {
+		hostCfg.Tmpfs = map[string]string{"/var/lib/mysql": "rw"}
+	}
+
+	if c.AdminInterface {
+		hostCfg.PortBindings[adminPort] = []nat.PortBinding{{HostIP: c.BindIP, HostPort: "0"}}
+	}
+
+	if c.Toxiproxy {
+		hostCfg.PortBindings[toxiproxyAPIPort] = []nat.PortBinding{{HostIP: c.BindIP, HostPort: "0"}}
+		hostCfg.PortBindings[toxiproxyListenPort] = []nat.PortBinding{{HostIP: c.BindIP, HostPort: "0"}}
+	}
+
+	// Network config
+	var networkCfg *network.NetworkingConfig
+	if c.Network != "" {
+		hostCfg.NetworkMode = container.NetworkMode(c.Network)
+		networkCfg = &network.NetworkingConfig{
+			EndpointsConfig: map[string]*network.EndpointSettings{
+				c.Network: {Aliases: c.NetworkAliases},
+			},
+		}
 	}
 
 	// Create container
-	created, createErr := cli.ContainerCreate(ctx, cfg, hostCfg, nil, nil, c.ContainerName)
+	createCtx, cancelCreate := context.WithTimeout(ctx, c.CreateTimeout)
+	defer cancelCreate()
+
+	created, createErr := c.dockerRetry().createContainer(createCtx, cli, cfg, hostCfg, networkCfg, c.ContainerName)
 	if client.IsErrNotFound(createErr) {
-		err := pullImage(ctx, cli, c.Image)
+		pullCtx, cancelPull := context.WithTimeout(ctx, c.PullTimeout)
+		err := pullImage(pullCtx, cli, cfg.Image, c.pullConfig())
+		cancelPull()
+		if errors.Is(pullCtx.Err(), context.DeadlineExceeded) {
+			return nil, phaseTimeout("image pull", c.PullTimeout)
+		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to pull image: %w", err)
 		}
 
-		created, createErr = cli.ContainerCreate(ctx, cfg, hostCfg, nil, nil, c.ContainerName)
+		// The pull does not use up the create timeout.
+		cancelCreate()
+		createCtx, cancelCreate = context.WithTimeout(ctx, c.CreateTimeout)
+		defer cancelCreate()
+
+		created, createErr = c.dockerRetry().createContainer(createCtx, cli, cfg, hostCfg, networkCfg, c.ContainerName)
+	}
+	if errors.Is(createCtx.Err(), context.DeadlineExceeded) {
+		return nil, phaseTimeout("container create", c.CreateTimeout)
 	}
 	if createErr != nil {
 		return nil, fmt.Errorf("error creating container: %w", createErr)
 	}
 
+	// The container is removed if Start fails before the box is ready to remove it.
+	containerID := created.ID
+	defer func() {
+		if containerID != "" {
+			_ = cli.ContainerRemove(context.Background(), containerID, types.ContainerRemoveOptions{Force: true})
+		}
+	}()
+
 	// Create stopped channel
 	stoppedCh := make(chan bool, 1)
 
@@ -262,7 +900,32 @@ func Start(c *Config) (*MySQLBox, error) {
 	_ = mysql.SetLogger(mylog)
 
 	// Start container
-	err = cli.ContainerStart(ctx, created.ID, types.ContainerStartOptions{})
+	startCtx, cancelStart := context.WithTimeout(ctx, c.CreateTimeout)
+	defer cancelStart()
+
+	err = c.dockerRetry().startContainer(startCtx, cli, created.ID)
+
+	// The container of a taken port is removed and created again with the next port.
+	for hostPort := c.MySQLPort; err != nil && isPortConflict(err) && hostPort < c.MySQLPortMax; {
+		_ = cli.ContainerRemove(ctx, created.ID, types.ContainerRemoveOptions{Force: true})
+		containerID = ""
+
+		hostPort++
+		c.Logger.Printf("MySQL port %d is already taken, trying port %d", hostPort-1, hostPort)
+		portBinding.HostPort = strconv.Itoa(hostPort)
+		hostCfg.PortBindings["3306/tcp"] = []nat.PortBinding{portBinding}
+
+		created, err = c.dockerRetry().createContainer(startCtx, cli, cfg, hostCfg, networkCfg, c.ContainerName)
+		if err != nil {
+			return nil, fmt.Errorf("error creating container: %w", err)
+		}
+		containerID = created.ID
+
+		err = c.dockerRetry().startContainer(startCtx, cli, created.ID)
+	}
+	if errors.Is(startCtx.Err(), context.DeadlineExceeded) {
+		return nil, phaseTimeout("container start", c.CreateTimeout)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -270,7 +933,8 @@ func Start(c *Config) (*MySQLBox, error) {
 	// Get container logs
 	cout := c.Stdout
 	cerr := c.Stderr
-	go readContainerLogs(ctx, cli, created.ID, cout, cerr, c.LoggedErrors, containerClosed)
+	logErrors := &logErrorCollector{lines: c.LoggedErrors, onError: c.OnError}
+	go readContainerLogs(ctx, cli, created.ID, "", cout, cerr, logErrors, containerClosed)
 
 	// Get port binding
 	port, err := containerMySQLPort(ctx, cli, created.ID)
@@ -278,8 +942,26 @@ func Start(c *Config) (*MySQLBox, error) {
 		return nil, err
 	}
 
+	xPort, err := containerHostPort(ctx, cli, created.ID, "33060/tcp")
+	if err != nil {
+		return nil, err
+	}
+
+	var adminHostPort int
+	if c.AdminInterface {
+		adminHostPort, err = containerHostPort(ctx, cli, created.ID, adminPort)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Connect to DB
-	db, dsn, err := connectDB(port, c.Database, c.RootPassword)
+	var tlsConfigName string
+	if certs != nil {
+		tlsConfigName = certs.configName
+	}
+
+	db, dsn, err := connectDB(host, port, c.Database, c.RootPassword, tlsConfigName, c.InstrumentSQL)
 	if err != nil {
 		return nil, err
 	}
@@ -288,29 +970,133 @@ func Start(c *Config) (*MySQLBox, error) {
 		db:                   db,
 		dsn:                  dsn,
 		rootPassword:         rootPassword,
+		host:                 host,
+		bindIP:               c.BindIP,
 		port:                 port,
+		xPort:                xPort,
+		adminPort:            adminHostPort,
 		logBuf:               logbuf,
 		cli:                  cli,
 		containerID:          created.ID,
 		containerName:        c.ContainerName,
-		schemaFile:           schemaFile,
+		network:              c.Network,
+		networkAliases:       c.NetworkAliases,
+		schemaFiles:          schemaFiles,
+		tls:                  certs,
+		socketDir:            socketDir,
+		initialSQLs:          initialSQLs,
+		initScriptsDir:       initScriptsDir,
+		migrator:             c.Migrations,
+		instrumentSQL:        c.InstrumentSQL,
 		databaseName:         c.Database,
 		doNotCleanTables:     c.DoNotCleanTables,
+		cleanWorkers:         c.CleanWorkers,
+		killLockHolders:      c.KillLockHolders,
+		verifyEmptyTables:    c.VerifyEmptyTables,
+		slowQueryLog:         c.SlowQueryLog != nil,
+		logger:               c.Logger,
+		pull:                 c.pullConfig(),
+		retry:                c.dockerRetry(),
+		rewriteImage:         c.imageRewriter(),
+		waitFor:              c.WaitFor,
+		maintenanceEvery:     c.MaintenanceEvery,
+		readOnly:             c.ReadOnlyDatabase,
 		cout:                 cout,
 		cerr:                 cerr,
+		logErrors:            logErrors,
 		stoppedCh:            stoppedCh,
 		containerStopTimeout: c.StopTimeout,
+		retainFor:            c.RetainFor,
 	}
 
 	// Wait for db
-	err = b.waitForDB(c.StartTimeout, containerClosed)
+	err = b.waitForDB(c.ReadyTimeout, containerClosed)
 	if errors.Is(err, ErrTimeout) {
+		keepFiles = true
+		containerID = ""
 		return b, err
 	}
 	if err != nil {
 		return nil, err
 	}
 
+	// The box removes the container from now on.
+	containerID = ""
+	recordReady(time.Since(started))
+
+	// Run initial scripts that may fail
+	if c.IgnoreInitErrors {
+		for n, script := range initialSQLs {
+			err := b.runInitScriptIgnoringErrors(ctx, script)
+			if err != nil {
+				_ = b.Stop()
+				return nil, fmt.Errorf("error running initial SQL script %d: %w", n+1, err)
+			}
+		}
+	}
+
+	// Apply migrations
+	if c.Migrations != nil {
+		err := b.migrateUp()
+		if err != nil {
+			_ = b.Stop()
+			return nil, fmt.Errorf("error running migrations: %w", err)
+		}
+	}
+
+	// Start Toxiproxy
+	if c.Toxiproxy {
+		b.proxy, err = b.startToxiproxy(ctx, c.ToxiproxyImage, c.ReadyTimeout)
+		if err != nil {
+			_ = b.Stop()
+			return nil, fmt.Errorf("error starting toxiproxy: %w", err)
+		}
+	}
+
+	// Start traffic capture
+	if c.CaptureTraffic {
+		b.capture, err = b.startCapture()
+		if err != nil {
+			_ = b.Stop()
+			return nil, fmt.Errorf("error starting traffic capture: %w", err)
+		}
+	}
+
+	// Start query watchdog
+	if c.QueryWatchdog > 0 {
+		b.startQueryWatchdog(c.QueryWatchdog)
+	}
+
+	// Start idle shutdown watcher
+	if c.IdleShutdown > 0 {
+		err := b.startIdleWatcher(ctx, c.Image, c.IdleShutdown)
+		if err != nil {
+			_ = b.Stop()
+			return nil, fmt.Errorf("error starting idle shutdown watcher: %w", err)
+		}
+	}
+
+	// Start debug UI
+	if c.DebugUIAddr != "" {
+		err := b.startDebugUI(c.DebugUIAddr)
+		if err != nil {
+			_ = b.Stop()
+			return nil, fmt.Errorf("error starting debug UI: %w", err)
+		}
+	}
+
+	// Lock the fixture database
+	if c.ReadOnlyDatabase {
+		err := b.setDatabaseReadOnly(ctx, true)
+		if err != nil {
+			_ = b.Stop()
+			return nil, fmt.Errorf("error making database read-only: %w", err)
+		}
+	}
+
+	recordStarted()
+
+	keepFiles = true
 	return b, nil
 }
 
@@ -324,7 +1110,7 @@ func (b *MySQLBox) MustStart(c *Config) *MySQLBox {
 	return box
 }
 
-// Stop stops the MySQL container.
+// Stop stops the MySQL container. The container of a box returned by Attach() is left running.
 func (b *MySQLBox) Stop() error {
 	if b == nil {
 		return errors.New("mysqlbox is nil")
@@ -333,6 +1119,39 @@ func (b *MySQLBox) Stop() error {
 	// Clean up files
 	defer b.cleanupFiles()
 
+	// Stop query watchdog
+	if b.stopQueryWatchdog != nil {
+		b.stopQueryWatchdog()
+	}
+
+	// Stop Toxiproxy
+	if b.proxy != nil {
+		b.proxy.stop()
+	}
+
+	// Stop traffic capture
+	if b.capture != nil {
+		b.capture.stop()
+	}
+
+	// Stop debug UI
+	if b.debugUI != nil {
+		_ = b.debugUI.Close()
+	}
+
+	// Stop admin UI
+	b.adminUIMu.Lock()
+	if b.adminUI != nil {
+		b.stopAdminUI(b.adminUI)
+		b.adminUI = nil
+	}
+	b.adminUIMu.Unlock()
+
+	// Leave the container of an attached box running
+	if b.attached {
+		return b.db.Close()
+	}
+
 	// Stop container
 	err := b.stopContainer()
 	if err != nil {
@@ -340,21 +1159,17 @@ func (b *MySQLBox) Stop() error {
 	}
 
 	// Wait for container to be removed
-	msgCh, errCh := b.cli.ContainerWait(context.Background(), b.containerID, container.WaitConditionRemoved)
-Wait:
-	for {
-		select {
-		case <-msgCh:
-			break Wait
-		case err := <-errCh:
-			if errdefs.IsNotFound(err) {
-				break Wait
-			} else {
-				return err
-			}
+	if b.retainFor > 0 {
+		b.logger.Printf("container %s is retained for %s for inspection", b.containerName, b.retainFor)
+	} else {
+		err = b.waitContainerRemoved()
+		if err != nil {
+			return err
 		}
 	}
 
+	recordStopped()
+
 	return nil
 }
 
@@ -366,17 +1181,65 @@ func (b *MySQLBox) MustStop() {
 	}
 }
 
+// stopContainer stops the container gracefully within the stop timeout. If the stop does not complete within
+// stopKillGrace after the timeout, the container is killed. A container that no longer exists is already stopped.
 func (b *MySQLBox) stopContainer() error {
 	var timeout time.Duration
 	if b.containerStopTimeout != 0 {
 		timeout = b.containerStopTimeout
 	}
 	timeoutSecs := int(timeout.Seconds())
-	err := b.cli.ContainerStop(context.Background(), b.containerID, container.StopOptions{
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout+stopKillGrace)
+	defer cancel()
+
+	err := b.cli.ContainerStop(ctx, b.containerID, container.StopOptions{
 		Timeout: &timeoutSecs,
 	})
-	if err != nil {
-		return err
+	if err == nil || errdefs.IsNotFound(err) {
+		return nil
+	}
+
+	b.logger.Printf("stopping container %s failed, killing it: %s", b.containerName, err.Error())
+
+	killCtx, cancelKill := context.WithTimeout(context.Background(), stopKillGrace)
+	defer cancelKill()
+
+	killErr := b.cli.ContainerKill(killCtx, b.containerID, "KILL")
+	if killErr != nil && !errdefs.IsNotFound(killErr) {
+		return fmt.Errorf("error killing container after failed stop (%s): %w", err.Error(), killErr)
+	}
+
+	return nil
+}
+
+// waitContainerRemoved waits for the stopped container to be removed by Docker. If it is not removed within
+// stopKillGrace, it is force-removed.
+func (b *MySQLBox) waitContainerRemoved() error {
+	ctx, cancel := context.WithTimeout(context.Background(), stopKillGrace)
+	defer cancel()
+
+	msgCh, errCh := b.cli.ContainerWait(ctx, b.containerID, container.WaitConditionRemoved)
+	select {
+	case <-msgCh:
+		return nil
+	case err := <-errCh:
+		if errdefs.IsNotFound(err) {
+			return nil
+		}
+		if ctx.Err() == nil {
+			return err
+		}
+	}
+
+	b.logger.Printf("container %s was not removed after it stopped, removing it", b.containerName)
+
+	removeCtx, cancelRemove := context.WithTimeout(context.Background(), stopKillGrace)
+	defer cancelRemove()
+
+	err := b.cli.ContainerRemove(removeCtx, b.containerID, types.ContainerRemoveOptions{Force: true})
+	if err != nil && !errdefs.IsNotFound(err) {
+		return fmt.Errorf("error removing container: %w", err)
 	}
 
 	return nil
@@ -439,132 +1302,195 @@ func (b *MySQLBox) MustContainerName() string {
 	return name
 }
 
-// CleanAllTables truncates all tables in the Database, except those provided in Config.DoNotCleanTables.
-func (b *MySQLBox) CleanAllTables() error {
+// ContainerID returns the ID of the created container.
+func (b *MySQLBox) ContainerID() (string, error) {
 	if b == nil {
-		return errors.New("mysqlbox is nil")
+		return "", errors.New("mysqlbox is nil")
 	}
 
-	query := "SELECT table_name FROM information_schema.tables WHERE table_schema = ?"
-	rows, err := b.db.Query(query, b.databaseName)
+	return b.containerID, nil
+}
+
+// MustContainerID returns the ID of the created container.
+func (b *MySQLBox) MustContainerID() string {
+	id, err := b.ContainerID()
 	if err != nil {
 		panic(err)
 	}
-	defer func() {
-		rows.Close()
-	}()
 
-	excludedTables := map[string]bool{}
-	for _, table := range b.doNotCleanTables {
-		excludedTables[table] = true
-	}
-
-	for rows.Next() {
-		var table string
-		err := rows.Scan(&table)
-		if err != nil {
-			panic(err)
-		}
-
-		if excludedTables[table] {
-			continue
-		}
+	return id
+}
 
-		query := fmt.Sprintf("TRUNCATE TABLE `%s`", table)
-		_, err = b.db.Exec(query)
-		if err != nil {
-			panic(err)
-		}
+// DockerClient returns the Docker client used by the box. It can be used for container operations that MySQLBox
+// does not provide. The client is owned by the box and must not be closed.
+func (b *MySQLBox) DockerClient() (*client.Client, error) {
+	if b == nil {
+		return nil, errors.New("mysqlbox is nil")
 	}
 
-	return nil
+	return b.cli, nil
 }
 
-// MustCleanAllTables truncates all tables in the Database, except those provided in Config.DoNotCleanTables.
-func (b *MySQLBox) MustCleanAllTables() {
-	err := b.CleanAllTables()
+// MustDockerClient returns the Docker client used by the box.
+func (b *MySQLBox) MustDockerClient() *client.Client {
+	cli, err := b.DockerClient()
 	if err != nil {
 		panic(err)
 	}
+
+	return cli
 }
 
-// CleanTables truncates the specified tables in the Database.
-func (b *MySQLBox) CleanTables(tables ...string) error {
-	if b == nil {
-		return errors.New("mysqlbox is nil")
+// writeSchemaFile writes the contents of an initial SQL script to a temporary file that can be mounted in the
+// container. It returns the file and the script contents. If the script has no data, it returns a nil file.
+func writeSchemaFile(script *Data) (*os.File, []byte, error) {
+	if script.reader == nil && script.buf == nil {
+		return nil, nil, nil
 	}
 
-	for _, table := range tables {
-		query := fmt.Sprintf("TRUNCATE TABLE `%s`", table)
-		_, err := b.db.Exec(query)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "truncate table failed (%s): %s\n", table, err.Error())
-		}
+	content, err := script.content()
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return nil
-}
+	schemaFile, err := ioutil.TempFile(os.TempDir(), "schema-*.sql")
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating schema file: %w", err)
+	}
 
-// MustCleanTables truncates the specified tables in the Database.
-func (b *MySQLBox) MustCleanTables(tables ...string) {
-	err := b.CleanTables(tables...)
+	// Make the schema file readable by others
+	err = os.Chmod(schemaFile.Name(), 0644)
+	if err == nil {
+		_, err = schemaFile.Write(content)
+	}
 	if err != nil {
-		panic(err)
+		removeSchemaFiles([]*os.File{schemaFile})
+		return nil, nil, fmt.Errorf("error writing schema file: %w", err)
+	}
+
+	return schemaFile, content, nil
+}
+
+// removeSchemaFiles closes and removes the temporary files of the initial scripts.
+func removeSchemaFiles(schemaFiles []*os.File) {
+	for _, schemaFile := range schemaFiles {
+		schemaFile.Close()
+		os.Remove(schemaFile.Name())
 	}
 }
 
 // cleanupFiles removes all temporary files created in the host space.
 func (b *MySQLBox) cleanupFiles() {
 	// Delete the schema file
-	if b.schemaFile != nil {
-		b.schemaFile.Close()
-		os.Remove(b.schemaFile.Name())
+	removeSchemaFiles(b.schemaFiles)
+
+	// Delete the TLS certificates
+	if b.tls != nil {
+		b.tls.cleanup()
+	}
+
+	// Delete the unix socket directory
+	if b.socketDir != "" {
+		_ = os.RemoveAll(b.socketDir)
 	}
 }
 
 // DBAddr returns the container's MySQL address.
 func (b *MySQLBox) DBAddr() string {
-	addr := net.JoinHostPort("127.0.0.1", fmt.Sprintf("%d", b.port))
+	addr := net.JoinHostPort(b.host, fmt.Sprintf("%d", b.port))
 	return addr
 }
 
+// XProtocolAddr returns the container's MySQL X Protocol address. It can be used by clients of the X DevAPI, e.g.
+// "mysqlx://root@" + XProtocolAddr() + "/testing". The X Plugin is enabled by default in MySQL 8.0 and later.
+func (b *MySQLBox) XProtocolAddr() string {
+	return net.JoinHostPort(b.host, fmt.Sprintf("%d", b.xPort))
+}
+
 // RootPassword returns the MySQL root user password.
 func (b *MySQLBox) RootPassword() string {
 	return b.rootPassword
 }
 
+// DoNotCleanTables returns the tables that are not emptied by the clean functions (Config.DoNotCleanTables).
+func (b *MySQLBox) DoNotCleanTables() []string {
+	return append([]string(nil), b.doNotCleanTables...)
+}
+
 // ConnectDB returns a DB connection and the DSN for the specified database.
 func (b *MySQLBox) ConnectDB(dbname string) (*sql.DB, string, error) {
-	return connectDB(b.port, dbname, b.rootPassword)
+	db, dsn, err := connectDB(b.host, b.port, dbname, b.rootPassword, b.tlsConfigName(), b.instrumentSQL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	b.derivedDBsMu.Lock()
+	b.derivedDBs = append(b.derivedDBs, db)
+	b.derivedDBsMu.Unlock()
+
+	return db, dsn, nil
 }
 
 // connectDB returns a DB connection and the DSN to the MySQL server.
-func connectDB(port int, dbName string, rootPass string) (*sql.DB, string, error) {
+// If instrument is not nil, it is used to wrap the driver connector of the returned DB.
+func connectDB(host string, port int, dbName string, rootPass string, tlsConfigName string,
+	instrument func(driver.Connector) driver.Connector) (*sql.DB, string, error) {
+	return openDB(newMySQLConfig(host, port, dbName, rootPass, tlsConfigName), instrument)
+}
+
+// openDB returns a DB connection and the DSN for the MySQL driver config.
+// If instrument is not nil, it is used to wrap the driver connector of the returned DB.
+func openDB(mysqlCfg *mysql.Config, instrument func(driver.Connector) driver.Connector) (*sql.DB, string, error) {
+	connector, err := mysql.NewConnector(mysqlCfg)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if instrument != nil {
+		connector = instrument(connector)
+	}
+
+	return sql.OpenDB(connector), mysqlCfg.FormatDSN(), nil
+}
+
+// newMySQLConfig returns the MySQL driver config for connecting to the MySQL server on the host and port as root. If
+// tlsConfigName is not blank, the connection uses the registered TLS config with that name.
+func newMySQLConfig(host string, port int, dbName string, rootPass string, tlsConfigName string) *mysql.Config {
 	mysqlCfg := mysql.NewConfig()
 	mysqlCfg.Net = "tcp"
 	mysqlCfg.ParseTime = true
-	mysqlCfg.Addr = net.JoinHostPort("127.0.0.1", fmt.Sprintf("%d", port))
+	mysqlCfg.Addr = net.JoinHostPort(host, fmt.Sprintf("%d", port))
 	mysqlCfg.DBName = dbName
 	mysqlCfg.User = "root"
 	mysqlCfg.Passwd = rootPass
+	mysqlCfg.TLSConfig = tlsConfigName
 
-	dsn := mysqlCfg.FormatDSN()
-	db, err := sql.Open("mysql", dsn)
-	if err != nil {
-		return nil, "", err
+	return mysqlCfg
+}
+
+// connectHost returns the host address for connecting to ports bound to the bind IP. Ports bound to all interfaces
+// are reached through the loopback address.
+func connectHost(bindIP string) string {
+	if ip := net.ParseIP(bindIP); ip == nil || ip.IsUnspecified() {
+		return defaultBindIP
 	}
 
-	return db, dsn, nil
+	return bindIP
 }
 
 // containerMYSQLPort returns the MySQL port number of the running container.
 func containerMySQLPort(ctx context.Context, cli *client.Client, containerID string) (int, error) {
+	return containerHostPort(ctx, cli, containerID, "3306/tcp")
+}
+
+// containerHostPort returns the host port number bound to a container port of the running container.
+func containerHostPort(ctx context.Context, cli *client.Client, containerID string, containerPort nat.Port) (int, error) {
 	cr, err := cli.ContainerInspect(ctx, containerID)
 	if err != nil {
 		return 0, err
 	}
 
-	ports := cr.NetworkSettings.Ports["3306/tcp"]
+	ports := cr.NetworkSettings.Ports[containerPort]
 	if len(ports) == 0 {
 		return 0, errors.New("no port bindings")
 	}
@@ -579,13 +1505,16 @@ func containerMySQLPort(ctx context.Context, cli *client.Client, containerID str
 
 // readContainerLogs starts reading a container log's two streams (stdout and stderr), and copies
 // them to the provider cout and cerr writers. While the stderr is being read, it also scanned
-// line by line. If a line starts with "ERROR", it is copied to the passed errors list.
+// line by line. The errors printed by the mysql client and the errors of the server error log are added to the passed
+// errors collector. If since is not blank, only
+// the logs after that time (e.g. a Unix timestamp) are read.
 func readContainerLogs(ctx context.Context,
 	cli *client.Client,
 	containerID string,
+	since string,
 	cout io.Writer,
 	cerr io.Writer,
-	errors *[]string,
+	errors *logErrorCollector,
 	containerExit chan<- bool) {
 	if cout == nil {
 		cout = io.Discard
@@ -600,6 +1529,7 @@ func readContainerLogs(ctx context.Context,
 		ShowStdout: true,
 		ShowStderr: true,
 		Follow:     true,
+		Since:      since,
 	})
 	if err != nil {
 		return
@@ -612,11 +1542,8 @@ func readContainerLogs(ctx context.Context,
 	go func() {
 		scanner := bufio.NewScanner(pr)
 		for scanner.Scan() {
-			line := scanner.Text()
-			if strings.HasPrefix(line, "ERROR") {
-				if errors != nil {
-					*errors = append(*errors, line)
-				}
+			if errors != nil {
+				errors.addLine(scanner.Text())
 			}
 		}
 	}()
@@ -638,51 +1565,50 @@ func readContainerLogs(ctx context.Context,
 	containerExit <- true
 }
 
-// waitForDB periodically sends a DB ping to the MySQL server until (a) it is successful,
-// (b) the timeout is reached, or (c) a signal is received from the containerClosed channel.
+// waitForDB waits for the MySQL server to be ready with the wait strategy of the box (see Config.WaitFor) until
+// (a) it is successful, (b) the timeout is reached, or (c) a signal is received from the containerClosed channel.
 func (b *MySQLBox) waitForDB(timeout time.Duration, containerClosed <-chan bool) error {
 	if b == nil {
 		return errors.New("mysqlbox is nil")
 	}
 
-	timer := time.NewTimer(timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-	for {
-		err := b.db.Ping()
-		if err == nil {
-			break
-		}
-		time.Sleep(waitBetweenPings)
-
-		select {
-		case <-timer.C:
-			return ErrTimeout
-		case <-containerClosed:
-			return errors.New("container closed")
-		default:
-		}
+	strategy := b.waitFor
+	if strategy == nil {
+		strategy = defaultWaitStrategy()
 	}
 
-	return nil
-}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- strategy.WaitUntilReady(ctx, b)
+	}()
 
-func pullImage(ctx context.Context, cli *client.Client, image string) error {
-	if image == "" {
-		return errors.New("image is blank")
+	var err error
+	select {
+	case err = <-errCh:
+	case <-containerClosed:
+		// Stop the strategy and wait for it to return.
+		cancel()
+		<-errCh
+		return errors.New("container closed")
 	}
 
-	fmt.Printf("pulling Docker image %s...\n", image)
-	reader, err := cli.ImagePull(ctx, image, types.ImagePullOptions{})
-	if err != nil {
-		return fmt.Errorf("docker image pull error: %w", err)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return withLastErrors(phaseTimeout("readiness", timeout), err)
 	}
-	defer reader.Close()
 
-	err = jsonmessage.DisplayJSONMessagesStream(reader, os.Stderr, 0, false, nil)
-	if err != nil {
-		return fmt.Errorf("docker image pull stream error: %w", err)
-	}
-	fmt.Printf("Docker image %s pulled.\n", image)
+	return err
+}
 
-	return nil
+// pingUntilReady sends DB pings with the backoff of the default Poller until a ping is successful or the context is
+// done. When the context is done, a *PollError with the errors of the last pings is returned.
+func pingUntilReady(ctx context.Context, db *sql.DB) error {
+	return Poller{}.Poll(ctx, db.PingContext)
+}
+
+// phaseTimeout returns an error wrapping ErrTimeout for a lifecycle phase that did not complete within its timeout.
+func phaseTimeout(phase string, timeout time.Duration) error {
+	return fmt.Errorf("%s did not complete within %s: %w", phase, timeout, ErrTimeout)
 }
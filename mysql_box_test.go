@@ -1,14 +1,29 @@
 package mysqlbox_test
 
 import (
+	"archive/tar"
+	"bufio"
 	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
 	"fmt"
+	"io"
 	"log"
 	"net"
+	"net/http"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
 	"github.com/stretchr/testify/require"
 
 	"github.com/virgild/mysqlbox"
@@ -56,6 +71,238 @@ func TestMySQLBoxNilError(t *testing.T) {
 		})
 	})
 
+	t.Run("dsn_for", func(t *testing.T) {
+		_, err := b.DSNFor("root", "", "testing", nil)
+		require.Error(t, err)
+	})
+
+	t.Run("container_dsn", func(t *testing.T) {
+		_, err := b.ContainerDSN("testing")
+		require.Error(t, err)
+	})
+
+	t.Run("set_replication_delay", func(t *testing.T) {
+		err := b.SetReplicationDelay(time.Second)
+		require.Error(t, err)
+	})
+
+	t.Run("pause", func(t *testing.T) {
+		require.Error(t, b.Pause())
+		require.Error(t, b.Unpause())
+	})
+
+	t.Run("connections", func(t *testing.T) {
+		_, err := b.Connections()
+		require.Error(t, err)
+
+		_, err = b.DSNForTest(t, "testing")
+		require.Error(t, err)
+	})
+
+	t.Run("restart", func(t *testing.T) {
+		require.Error(t, b.Restart(context.Background()))
+	})
+
+	t.Run("proxy", func(t *testing.T) {
+		_, err := b.Proxy()
+		require.Error(t, err)
+	})
+
+	t.Run("capture", func(t *testing.T) {
+		_, err := b.Capture()
+		require.Error(t, err)
+	})
+
+	t.Run("mysql_cli", func(t *testing.T) {
+		_, err := b.MySQLCLI("-e", "SELECT 1")
+		require.Error(t, err)
+
+		_, err = b.RunSQLFile("testdata/cli-script.sql")
+		require.Error(t, err)
+	})
+
+	t.Run("init_errors", func(t *testing.T) {
+		require.Nil(t, b.InitErrors())
+		require.Nil(t, b.Errors())
+	})
+
+	t.Run("exec", func(t *testing.T) {
+		_, _, _, err := b.Exec(context.Background(), []string{"true"})
+		require.Error(t, err)
+	})
+
+	t.Run("copy", func(t *testing.T) {
+		ctx := context.Background()
+
+		require.Error(t, b.CopyToContainer(ctx, "/tmp", bytes.NewReader(nil)))
+		require.Error(t, b.CopyFileToContainer(ctx, "testdata/schema.sql", "/tmp/schema.sql"))
+		require.Error(t, b.CopyFileFromContainer(ctx, "/tmp/schema.sql", filepath.Join(t.TempDir(), "schema.sql")))
+
+		_, err := b.CopyFromContainer(ctx, "/tmp")
+		require.Error(t, err)
+	})
+
+	t.Run("effective_variables", func(t *testing.T) {
+		_, err := b.EffectiveVariables("autocommit")
+		require.Error(t, err)
+
+		_, err = b.CompareVariables("testdata/variables.json")
+		require.Error(t, err)
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		_, err := b.Stats(context.Background())
+		require.Error(t, err)
+
+		err = b.WatchStats(context.Background(), time.Second, func(mysqlbox.Stats) {})
+		require.Error(t, err)
+	})
+
+	t.Run("killed_queries", func(t *testing.T) {
+		require.Nil(t, b.KilledQueries())
+	})
+
+	t.Run("debug_ui_addr", func(t *testing.T) {
+		require.Empty(t, b.DebugUIAddr())
+	})
+
+	t.Run("start_admin_ui", func(t *testing.T) {
+		_, err := b.StartAdminUI(context.Background())
+		require.Error(t, err)
+	})
+
+	t.Run("seed_report", func(t *testing.T) {
+		_, err := b.SeedReport()
+		require.Error(t, err)
+	})
+
+	t.Run("commit_to_image", func(t *testing.T) {
+		_, err := b.CommitToImage(context.Background(), "mysqlbox-nil", nil)
+		require.Error(t, err)
+	})
+
+	t.Run("clean_all_tables_context", func(t *testing.T) {
+		err := b.CleanAllTablesContext(context.Background(), nil)
+		require.Error(t, err)
+	})
+
+	t.Run("general_log", func(t *testing.T) {
+		_, err := b.GeneralLog(context.Background())
+		require.Error(t, err)
+		require.Error(t, b.TailGeneralLog(context.Background(), io.Discard))
+	})
+
+	t.Run("slow_queries", func(t *testing.T) {
+		_, err := b.SlowQueries(context.Background())
+		require.Error(t, err)
+	})
+
+	t.Run("recording_db", func(t *testing.T) {
+		_, _, err := b.RecordingDB()
+		require.Error(t, err)
+	})
+
+	t.Run("create_database_from_template", func(t *testing.T) {
+		_, _, err := b.CreateDatabaseFromTemplate("tenant_1", "testing", nil)
+		require.Error(t, err)
+	})
+
+	t.Run("stop_accepting_connections", func(t *testing.T) {
+		err := b.StopAcceptingConnections()
+		require.Error(t, err)
+
+		err = b.AcceptConnections()
+		require.Error(t, err)
+	})
+
+	t.Run("set_global_var", func(t *testing.T) {
+		err := b.SetGlobalVar("max_connections", 10)
+		require.Error(t, err)
+
+		err = b.SetRequireSecureTransport(false)
+		require.Error(t, err)
+
+		_, err = b.AdminDSN()
+		require.Error(t, err)
+	})
+
+	t.Run("dump", func(t *testing.T) {
+		err := b.Dump(context.Background(), io.Discard, nil)
+		require.Error(t, err)
+	})
+
+	t.Run("create_table", func(t *testing.T) {
+		err := b.CreateTable("CREATE TABLE events (id INT)", nil)
+		require.Error(t, err)
+	})
+
+	t.Run("convert_table_charset", func(t *testing.T) {
+		_, err := b.ConvertTableCharset("categories", "utf8mb4", "", nil)
+		require.Error(t, err)
+	})
+
+	t.Run("dump_schema", func(t *testing.T) {
+		err := b.DumpSchema(context.Background(), io.Discard)
+		require.Error(t, err)
+	})
+
+	t.Run("diff_schema", func(t *testing.T) {
+		_, err := b.DiffSchema(mysqlbox.DataFromBuffer([]byte("CREATE TABLE t (id INT)")))
+		require.Error(t, err)
+	})
+
+	t.Run("run_sidecar", func(t *testing.T) {
+		_, err := b.RunSidecar(context.Background(), "alpine", nil, nil)
+		require.Error(t, err)
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		err := b.Restore(context.Background(), strings.NewReader(""))
+		require.Error(t, err)
+	})
+
+	t.Run("connection_string", func(t *testing.T) {
+		_, err := b.ConnectionString(mysqlbox.JDBC)
+		require.Error(t, err)
+	})
+
+	t.Run("seed", func(t *testing.T) {
+		err := b.Seed("users", nil)
+		require.Error(t, err)
+	})
+
+	t.Run("seed_tables", func(t *testing.T) {
+		err := b.SeedTables()
+		require.Error(t, err)
+	})
+
+	t.Run("auto_clean", func(t *testing.T) {
+		ft := &fatalRecorder{TB: t}
+		runRecorded(func() { b.AutoClean(ft) })
+		require.Equal(t, "mysqlbox is nil", ft.fatal)
+	})
+
+	t.Run("register_invariant", func(t *testing.T) {
+		err := b.RegisterInvariant("none", "SELECT 0", 0)
+		require.Error(t, err)
+	})
+
+	t.Run("tls_config", func(t *testing.T) {
+		_, err := b.TLSConfig()
+		require.Error(t, err)
+	})
+
+	t.Run("unix_socket", func(t *testing.T) {
+		_, err := b.SocketPath()
+		require.Error(t, err)
+
+		_, err = b.SocketDSN("testing")
+		require.Error(t, err)
+
+		_, _, err = b.ConnectSocketDB("testing")
+		require.Error(t, err)
+	})
+
 	t.Run("db", func(t *testing.T) {
 		_, err := b.DB()
 		require.Error(t, err)
@@ -89,6 +336,28 @@ func TestMySQLBoxNilError(t *testing.T) {
 		})
 	})
 
+	t.Run("container_id", func(t *testing.T) {
+		_, err := b.ContainerID()
+		require.Error(t, err)
+	})
+
+	t.Run("must_container_id", func(t *testing.T) {
+		require.Panics(t, func() {
+			b.MustContainerID()
+		})
+	})
+
+	t.Run("docker_client", func(t *testing.T) {
+		_, err := b.DockerClient()
+		require.Error(t, err)
+	})
+
+	t.Run("must_docker_client", func(t *testing.T) {
+		require.Panics(t, func() {
+			b.MustDockerClient()
+		})
+	})
+
 	t.Run("clean_tables", func(t *testing.T) {
 		err := b.CleanTables("testing")
 		require.Error(t, err)
@@ -110,6 +379,26 @@ func TestMySQLBoxNilError(t *testing.T) {
 			b.MustCleanAllTables()
 		})
 	})
+
+	t.Run("flush_pool", func(t *testing.T) {
+		err := b.FlushPool()
+		require.Error(t, err)
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		err := b.Reset()
+		require.Error(t, err)
+	})
+
+	t.Run("create_database", func(t *testing.T) {
+		_, _, err := b.CreateDatabase("testing")
+		require.Error(t, err)
+	})
+
+	t.Run("drop_database", func(t *testing.T) {
+		err := b.DropDatabase("testing")
+		require.Error(t, err)
+	})
 }
 
 func TestMySQLBoxDefaultConfig(t *testing.T) {
@@ -152,6 +441,16 @@ func TestMySQLBoxDefaultConfig(t *testing.T) {
 		require.NotEmpty(t, containerName)
 	})
 
+	containerID, err := b.ContainerID()
+	require.NoError(t, err)
+	require.NotEmpty(t, containerID)
+
+	cli, err := b.DockerClient()
+	require.NoError(t, err)
+	inspected, err := cli.ContainerInspect(context.Background(), containerID)
+	require.NoError(t, err)
+	require.Equal(t, "/"+containerName, inspected.Name)
+
 	row := db.QueryRow("SELECT NOW()")
 	var now time.Time
 	err = row.Scan(&now)
@@ -592,6 +891,34 @@ func TestErrorLogs(t *testing.T) {
 	require.Error(t, err)
 	require.Len(t, loggedErrors, 1)
 	require.Equal(t, "ERROR 1146 (42S02) at line 2: Table 'testing.sales' doesn't exist", loggedErrors[0])
+
+	logErr := mysqlbox.ParseLogError(loggedErrors[0])
+	require.Equal(t, 1146, logErr.Code)
+	require.Equal(t, "42S02", logErr.SQLState)
+	require.Equal(t, 2, logErr.Line)
+}
+
+func TestOnError(t *testing.T) {
+	initialSQL := `
+		SELECT * FROM sales WHERE id = 1;
+	`
+
+	var mu sync.Mutex
+	var lines []string
+	_, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromBuffer([]byte(initialSQL)),
+		OnError: func(line string) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			lines = append(lines, line)
+		},
+	})
+	require.Error(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Contains(t, lines, "ERROR 1146 (42S02) at line 2: Table 'testing.sales' doesn't exist")
 }
 
 func TestMultipleDatabases(t *testing.T) {
@@ -666,4 +993,2614 @@ func TestStartTimeout(t *testing.T) {
 			require.NotNil(t, box)
 		})
 	})
+
+	t.Run("trigger_ready_timeout", func(t *testing.T) {
+		box, err := mysqlbox.Start(&mysqlbox.Config{
+			ReadyTimeout: time.Second * 5,
+			StopTimeout:  time.Second * 1,
+		})
+		require.NotNil(t, box)
+		t.Cleanup(box.MustStop)
+
+		require.ErrorIs(t, err, mysqlbox.ErrTimeout)
+		require.Contains(t, err.Error(), "readiness")
+	})
+
+	t.Run("trigger_pull_timeout", func(t *testing.T) {
+		box, err := mysqlbox.Start(&mysqlbox.Config{
+			// This image is not expected to be available locally.
+			Image:       "mysql:5.7.1",
+			PullTimeout: time.Millisecond,
+		})
+		require.Nil(t, box)
+		require.ErrorIs(t, err, mysqlbox.ErrTimeout)
+		require.Contains(t, err.Error(), "image pull")
+	})
+}
+
+func TestReseed(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromFile("./testdata/schema.sql"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	db := box.MustDB()
+	created := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	err = box.Reseed(mysqlbox.Fixture{
+		Table: "categories",
+		Rows: []map[string]interface{}{
+			{"id": "C-TEST1", "name": "Alpha", "created_at": created, "updated_at": created},
+			{"id": "C-TEST2", "name": "Bravo", "created_at": created, "updated_at": created},
+			{"id": "C-TEST6", "name": "Zeta", "created_at": created, "updated_at": created},
+		},
+	})
+	require.NoError(t, err)
+
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM categories").Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+
+	var name string
+	err = db.QueryRow("SELECT name FROM categories WHERE id = 'C-TEST2'").Scan(&name)
+	require.NoError(t, err)
+	require.Equal(t, "Bravo", name)
+
+	err = db.QueryRow("SELECT name FROM categories WHERE id = 'C-TEST6'").Scan(&name)
+	require.NoError(t, err)
+	require.Equal(t, "Zeta", name)
+
+	t.Run("missing_primary_key_column", func(t *testing.T) {
+		err := box.Reseed(mysqlbox.Fixture{
+			Table: "categories",
+			Rows:  []map[string]interface{}{{"name": "Eta"}},
+		})
+		require.Error(t, err)
+	})
+}
+
+func TestCleanTablesWithForeignKeys(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromFile("./testdata/fk-schema.sql"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	db := box.MustDB()
+
+	t.Run("clean_all_tables", func(t *testing.T) {
+		err := box.CleanAllTables()
+		require.NoError(t, err)
+
+		var count int
+		err = db.QueryRow("SELECT COUNT(*) FROM authors").Scan(&count)
+		require.NoError(t, err)
+		require.Equal(t, 0, count)
+	})
+
+	t.Run("foreign_key_checks_restored", func(t *testing.T) {
+		_, err := db.Exec("INSERT INTO books (author_id, title) VALUES (99, 'Orphan')")
+		require.Error(t, err)
+	})
+
+	t.Run("clean_tables", func(t *testing.T) {
+		_, err := db.Exec("INSERT INTO authors (id, name) VALUES (1, 'N. K. Jemisin')")
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO books (author_id, title) VALUES (1, 'The Fifth Season')")
+		require.NoError(t, err)
+
+		err = box.CleanTables("authors")
+		require.NoError(t, err)
+
+		var count int
+		err = db.QueryRow("SELECT COUNT(*) FROM authors").Scan(&count)
+		require.NoError(t, err)
+		require.Equal(t, 0, count)
+	})
+}
+
+func TestFlushPool(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	db := box.MustDB()
+	db.SetMaxOpenConns(1)
+
+	var before, after int64
+	err = db.QueryRow("SELECT CONNECTION_ID()").Scan(&before)
+	require.NoError(t, err)
+
+	err = box.FlushPool()
+	require.NoError(t, err)
+
+	err = db.QueryRow("SELECT CONNECTION_ID()").Scan(&after)
+	require.NoError(t, err)
+	require.NotEqual(t, before, after)
+}
+
+func TestReaper(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		Reaper: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	require.NoError(t, box.MustDB().Ping())
+}
+
+func TestCleanAllTablesConcurrently(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL:       mysqlbox.DataFromFile("./testdata/many-tables.sql"),
+		DoNotCleanTables: []string{"t10"},
+		CleanWorkers:     3,
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	err = box.CleanAllTables()
+	require.NoError(t, err)
+
+	db := box.MustDB()
+	for n := 1; n <= 10; n++ {
+		var count int
+		err := db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM t%02d", n)).Scan(&count)
+		require.NoError(t, err)
+
+		if n == 10 {
+			require.Equal(t, 1, count)
+		} else {
+			require.Equal(t, 0, count)
+		}
+	}
+}
+
+func TestReset(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromFile("./testdata/fk-schema.sql"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	db := box.MustDB()
+	_, err = db.Exec("INSERT INTO authors (name) VALUES ('N. K. Jemisin')")
+	require.NoError(t, err)
+
+	err = box.Reset()
+	require.NoError(t, err)
+
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM authors").Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+
+	// AUTO_INCREMENT starts over from the initial script.
+	res, err := db.Exec("INSERT INTO authors (name) VALUES ('N. K. Jemisin')")
+	require.NoError(t, err)
+	id, err := res.LastInsertId()
+	require.NoError(t, err)
+	require.EqualValues(t, 3, id)
+}
+
+func TestHostAddressForContainer(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	addr, err := box.HostAddressForContainer()
+	require.NoError(t, err)
+	require.NotEmpty(t, addr)
+}
+
+type countingConnector struct {
+	driver.Connector
+	connects *int32
+}
+
+func (c countingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	atomic.AddInt32(c.connects, 1)
+	return c.Connector.Connect(ctx)
+}
+
+func TestInstrumentSQL(t *testing.T) {
+	var connects int32
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InstrumentSQL: func(c driver.Connector) driver.Connector {
+			return countingConnector{Connector: c, connects: &connects}
+		},
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	require.Greater(t, atomic.LoadInt32(&connects), int32(0))
+
+	db, _, err := box.ConnectDB("testing")
+	require.NoError(t, err)
+
+	before := atomic.LoadInt32(&connects)
+	require.NoError(t, db.Ping())
+	require.Greater(t, atomic.LoadInt32(&connects), before)
+}
+
+func TestCloneDatabase(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromFile("./testdata/fk-schema.sql"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	t.Run("clone", func(t *testing.T) {
+		db, dsn, err := box.CloneDatabase("testing_clone")
+		require.NoError(t, err)
+		require.NotEmpty(t, dsn)
+
+		var count int
+		err = db.QueryRow("SELECT COUNT(*) FROM books").Scan(&count)
+		require.NoError(t, err)
+		require.Equal(t, 2, count)
+
+		// Foreign keys reference the cloned tables.
+		_, err = db.Exec("INSERT INTO books (author_id, title) VALUES (99, 'Orphan')")
+		require.Error(t, err)
+	})
+
+	t.Run("from template", func(t *testing.T) {
+		db, _, err := box.CreateDatabaseFromTemplate("tenant_schema", "testing", nil)
+		require.NoError(t, err)
+
+		var count int
+		err = db.QueryRow("SELECT COUNT(*) FROM books").Scan(&count)
+		require.NoError(t, err)
+		require.Equal(t, 0, count)
+
+		db, _, err = box.CreateDatabaseFromTemplate("tenant_data", "testing", &mysqlbox.TemplateOptions{
+			WithData: true,
+			Workers:  2,
+		})
+		require.NoError(t, err)
+
+		err = db.QueryRow("SELECT COUNT(*) FROM books").Scan(&count)
+		require.NoError(t, err)
+		require.Equal(t, 2, count)
+
+		// Foreign keys reference the tables of the new database.
+		_, err = db.Exec("INSERT INTO books (author_id, title) VALUES (99, 'Orphan')")
+		require.Error(t, err)
+
+		_, _, err = box.CreateDatabaseFromTemplate("tenant_missing", "missing", nil)
+		require.EqualError(t, err, "template database missing does not exist")
+	})
+
+	t.Run("isolated", func(t *testing.T) {
+		for n := 0; n < 3; n++ {
+			t.Run(fmt.Sprintf("test_%d", n), func(t *testing.T) {
+				t.Parallel()
+				db := box.IsolatedDB(t)
+
+				_, err := db.Exec("DELETE FROM books")
+				require.NoError(t, err)
+
+				var count int
+				err = box.MustDB().QueryRow("SELECT COUNT(*) FROM books").Scan(&count)
+				require.NoError(t, err)
+				require.Equal(t, 2, count)
+			})
+		}
+	})
+}
+
+func TestCreateDatabase(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	db, dsn, err := box.CreateDatabase("inventory")
+	require.NoError(t, err)
+	require.Contains(t, dsn, "/inventory")
+
+	_, err = db.Exec("CREATE TABLE items (id int NOT NULL PRIMARY KEY)")
+	require.NoError(t, err)
+
+	var schema string
+	err = db.QueryRow("SELECT DATABASE()").Scan(&schema)
+	require.NoError(t, err)
+	require.Equal(t, "inventory", schema)
+
+	t.Run("already_exists", func(t *testing.T) {
+		_, _, err := box.CreateDatabase("inventory")
+		require.Error(t, err)
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		err := box.DropDatabase("inventory")
+		require.NoError(t, err)
+
+		_, err = db.Exec("SELECT * FROM items")
+		require.Error(t, err)
+	})
+}
+
+func TestSeedTx(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromFile("./testdata/fk-schema.sql"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	err = box.SeedTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec("INSERT INTO authors (id, name) VALUES (3, 'N. K. Jemisin')")
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("INSERT INTO books (author_id, title) VALUES (99, 'Orphan')")
+		return err
+	})
+
+	var cerr *mysqlbox.ConstraintError
+	require.ErrorAs(t, err, &cerr)
+	require.Equal(t, "books", cerr.Table)
+	require.Equal(t, "books_author_fk", cerr.Constraint)
+
+	// The transaction was rolled back.
+	var count int
+	err = box.MustDB().QueryRow("SELECT COUNT(*) FROM authors").Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+}
+
+func TestSeed(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromFile("./testdata/schema.sql"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	db := box.MustDB()
+
+	t.Run("rows", func(t *testing.T) {
+		box.MustSeed("users", []map[string]interface{}{
+			{"id": "U-1", "email": "o'brien@example.com", "created_at": "2021-01-01 00:00:00",
+				"updated_at": time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)},
+			{"id": "U-2", "email": "bob@example.com", "created_at": "2021-01-02", "updated_at": "2021-01-02"},
+		})
+
+		var email string
+		var updatedAt time.Time
+		err := db.QueryRow("SELECT email, updated_at FROM users WHERE id = 'U-1'").Scan(&email, &updatedAt)
+		require.NoError(t, err)
+		require.Equal(t, "o'brien@example.com", email)
+		require.Equal(t, time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC), updatedAt)
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		err := box.Seed("users", []map[string]interface{}{
+			{"id": "U-3", "email": "carol@example.com", "created_at": "2021-01-03", "updated_at": "2021-01-03"},
+			{"id": "U-4", "email": "bob@example.com", "created_at": "2021-01-04", "updated_at": "2021-01-04"},
+		})
+
+		var cerr *mysqlbox.ConstraintError
+		require.ErrorAs(t, err, &cerr)
+		require.Equal(t, "users_email_uindex", cerr.Constraint)
+
+		// No rows were inserted.
+		var count int
+		err = db.QueryRow("SELECT COUNT(*) FROM users").Scan(&count)
+		require.NoError(t, err)
+		require.Equal(t, 2, count)
+	})
+
+	t.Run("tables", func(t *testing.T) {
+		err := box.SeedTables(
+			mysqlbox.Fixture{Table: "categories", Rows: []map[string]interface{}{
+				{"id": "C-1", "name": "Zeta", "created_at": "2021-01-01", "updated_at": "2021-01-01"},
+			}},
+			mysqlbox.Fixture{Table: "users", Rows: []map[string]interface{}{
+				{"id": "U-5", "email": "dave@example.com", "created_at": "2021-01-05", "updated_at": "2021-01-05"},
+			}},
+		)
+		require.NoError(t, err)
+
+		var count int
+		err = db.QueryRow("SELECT COUNT(*) FROM categories WHERE id = 'C-1'").Scan(&count)
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+	})
+
+	t.Run("unknown_column", func(t *testing.T) {
+		err := box.Seed("users", []map[string]interface{}{{"nickname": "eve"}})
+		require.ErrorContains(t, err, "unknown column nickname")
+	})
+}
+
+func TestMultipleInitialScripts(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromFile("./testdata/schema.sql"),
+		InitialSQLs: []*mysqlbox.Data{
+			// seed.sql inserts into the users table created by schema.sql.
+			mysqlbox.DataFromFile("./testdata/seed.sql"),
+			mysqlbox.DataFromBuffer([]byte("UPDATE users SET email = 'updated@example.com' WHERE id = 'U-SEED1';")),
+		},
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	var email string
+	err = box.MustDB().QueryRow("SELECT email FROM users WHERE id = 'U-SEED1'").Scan(&email)
+	require.NoError(t, err)
+	require.Equal(t, "updated@example.com", email)
+}
+
+func TestInitScriptsDir(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		// initdb contains a .sql schema script and a .sh script that inserts a user.
+		InitScriptsDir: "./testdata/initdb",
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	var email string
+	err = box.MustDB().QueryRow("SELECT email FROM users WHERE id = 'U-SCRIPT1'").Scan(&email)
+	require.NoError(t, err)
+	require.Equal(t, "script1@example.com", email)
+
+	t.Run("reset", func(t *testing.T) {
+		err := box.Reset()
+		require.EqualError(t, err, "reset is not supported with InitScriptsDir")
+
+		// The database is not changed.
+		err = box.MustDB().QueryRow("SELECT email FROM users WHERE id = 'U-SCRIPT1'").Scan(&email)
+		require.NoError(t, err)
+	})
+
+	t.Run("combined_with_initial_sql", func(t *testing.T) {
+		_, err := mysqlbox.Start(&mysqlbox.Config{
+			InitScriptsDir: "./testdata/initdb",
+			InitialSQL:     mysqlbox.DataFromFile("./testdata/seed.sql"),
+		})
+		require.Error(t, err)
+	})
+}
+
+func TestReadOnlyDatabase(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL:       mysqlbox.DataFromFile("./testdata/schema.sql"),
+		ReadOnlyDatabase: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	_, err = box.MustDB().Exec("DELETE FROM categories")
+	require.Error(t, err)
+
+	t.Run("scratch_db", func(t *testing.T) {
+		db := box.ScratchDB(t)
+
+		_, err := db.Exec("CREATE TABLE notes (id int NOT NULL PRIMARY KEY, category_id varchar(128) NOT NULL)")
+		require.NoError(t, err)
+
+		_, err = db.Exec("INSERT INTO notes (id, category_id) SELECT 1, id FROM testing.categories LIMIT 1")
+		require.NoError(t, err)
+	})
+}
+
+func TestLoadFixtures(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromFile("./testdata/schema.sql"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	err = box.LoadFixtures("./testdata/fixtures")
+	require.NoError(t, err)
+
+	db := box.MustDB()
+
+	var updatedAt time.Time
+	err = db.QueryRow("SELECT updated_at FROM users WHERE id = 'U-FIXTURE1'").Scan(&updatedAt)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2021, 1, 1, 12, 30, 0, 0, time.UTC), updatedAt)
+
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM categories").Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, 7, count)
+
+	t.Run("unknown_table", func(t *testing.T) {
+		err := box.LoadFixtures("./testdata/initdb/01-schema.sql")
+		require.Error(t, err)
+	})
+}
+
+func TestTempTable(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	db := box.MustDB()
+	var table string
+
+	t.Run("create", func(t *testing.T) {
+		table = box.TempTable(t, "id int NOT NULL PRIMARY KEY, name varchar(64) NOT NULL")
+		require.NotEmpty(t, table)
+
+		_, err := db.Exec(fmt.Sprintf("INSERT INTO `%s` (id, name) VALUES (1, 'one')", table))
+		require.NoError(t, err)
+
+		other := box.TempTable(t, "id int NOT NULL PRIMARY KEY")
+		require.NotEqual(t, table, other)
+	})
+
+	t.Run("dropped_after_test", func(t *testing.T) {
+		_, err := db.Exec(fmt.Sprintf("SELECT * FROM `%s`", table))
+		require.Error(t, err)
+	})
+}
+
+func TestLintSchema(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromFile("./testdata/lint-schema.sql"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	issues, err := box.LintSchema()
+	require.NoError(t, err)
+
+	rules := map[string]string{}
+	for _, issue := range issues {
+		require.Equal(t, "notes", issue.Table, issue.String())
+		rules[issue.Rule] = issue.Message
+	}
+	require.Contains(t, rules, mysqlbox.LintMissingPrimaryKey.Name)
+	require.Contains(t, rules, mysqlbox.LintUTF8MB3.Name)
+	require.Contains(t, rules, mysqlbox.LintTextInIndex.Name)
+
+	t.Run("custom_rule", func(t *testing.T) {
+		rule := mysqlbox.LintRule{
+			Name: "custom",
+			Check: func(db *sql.DB, database string) ([]mysqlbox.LintIssue, error) {
+				return []mysqlbox.LintIssue{{Table: "accounts", Message: "custom issue"}}, nil
+			},
+		}
+
+		issues := box.MustLintSchema(rule)
+		require.Equal(t, []mysqlbox.LintIssue{{Rule: "custom", Table: "accounts", Message: "custom issue"}}, issues)
+	})
+}
+
+func TestPlanBaseline(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromFile("./testdata/schema.sql"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	path := filepath.Join(t.TempDir(), "plans.json")
+	query := mysqlbox.PlanQuery{
+		Name:  "users_by_email",
+		Query: "SELECT id FROM users WHERE email = ?",
+		Args:  []interface{}{"user@example.com"},
+	}
+
+	// The first run records the baseline.
+	changes, err := box.ComparePlanBaseline(path, query)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+	require.FileExists(t, path)
+
+	box.CheckPlanBaseline(t, path, query)
+
+	// Dropping the index changes the plan.
+	_, err = box.MustDB().Exec("ALTER TABLE users DROP INDEX users_email_uindex")
+	require.NoError(t, err)
+
+	changes, err = box.ComparePlanBaseline(path, query)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	require.Equal(t, "users_email_uindex", changes[0].Baseline[0].Key)
+	require.Equal(t, "ALL", changes[0].Current[0].AccessType)
+}
+
+func TestDiffSnapshot(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromFile("./testdata/schema.sql"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	box.MustSnapshot("before")
+
+	db := box.MustDB()
+	_, err = db.Exec("INSERT INTO users VALUES ('U-SNAP1', 'snap1@example.com', NOW(), NOW())")
+	require.NoError(t, err)
+	_, err = db.Exec("UPDATE categories SET name = 'Gamma' WHERE id = 'C-TEST5'")
+	require.NoError(t, err)
+	_, err = db.Exec("DELETE FROM categories WHERE id = 'C-TEST1'")
+	require.NoError(t, err)
+
+	box.MustSnapshot("after")
+
+	diffs := box.MustDiffSnapshot("before", "after")
+	require.Len(t, diffs, 2)
+
+	require.Equal(t, "categories", diffs[0].Table)
+	require.Empty(t, diffs[0].Inserted)
+	require.Len(t, diffs[0].Updated, 1)
+	require.Equal(t, "Gamme", diffs[0].Updated[0].Before["name"])
+	require.Equal(t, "Gamma", diffs[0].Updated[0].After["name"])
+	require.Len(t, diffs[0].Deleted, 1)
+	require.Equal(t, "C-TEST1", diffs[0].Deleted[0]["id"])
+
+	require.Equal(t, "users", diffs[1].Table)
+	require.Len(t, diffs[1].Inserted, 1)
+	require.Equal(t, "U-SNAP1", diffs[1].Inserted[0]["id"])
+
+	t.Run("unknown_snapshot", func(t *testing.T) {
+		_, err := box.DiffSnapshot("before", "missing")
+		require.Error(t, err)
+	})
+}
+
+func TestInvariants(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromFile("./testdata/fk-schema.sql"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	box.MustRegisterInvariant("orphan books",
+		"SELECT COUNT(*) FROM books LEFT JOIN authors ON authors.id = books.author_id WHERE authors.id IS NULL", 0)
+
+	t.Run("holds", func(t *testing.T) {
+		box.CheckInvariantsOnCleanup(t)
+		require.NoError(t, box.VerifyInvariants())
+	})
+
+	t.Run("violated", func(t *testing.T) {
+		// Truncating authors with foreign key checks disabled leaves orphan books.
+		err := box.CleanTables("authors")
+		require.NoError(t, err)
+
+		err = box.VerifyInvariants()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "orphan books")
+	})
+}
+
+func TestRunScenario(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromFile("./testdata/fk-schema.sql"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	// Session b blocks on the row lock held by session a until a commits.
+	box.RunScenario(t, mysqlbox.Scenario{
+		Sessions: []string{"a", "b"},
+		Steps: []mysqlbox.Step{
+			{Session: "a", SQL: "BEGIN"},
+			{Session: "a", SQL: "UPDATE authors SET name = 'A' WHERE id = 1"},
+			{Session: "b", SQL: "BEGIN"},
+			{Session: "b", SQL: "UPDATE authors SET name = CONCAT(name, 'B') WHERE id = 1", Async: true},
+			{Session: "a", SQL: "COMMIT"},
+			{Wait: "b"},
+			{Session: "b", SQL: "COMMIT"},
+			{
+				Session: "a",
+				SQL:     "SELECT name FROM authors WHERE id = ?",
+				Args:    []interface{}{1},
+				Check: func(t testing.TB, result mysqlbox.StepResult) {
+					require.Equal(t, [][]interface{}{{"AB"}}, result.Rows)
+				},
+			},
+		},
+	})
+
+	t.Run("lock_wait_timeout", func(t *testing.T) {
+		box.RunScenario(t, mysqlbox.Scenario{
+			Sessions: []string{"a", "b"},
+			Steps: []mysqlbox.Step{
+				{Session: "a", SQL: "BEGIN"},
+				{Session: "a", SQL: "SELECT * FROM authors WHERE id = 2 FOR UPDATE"},
+				{Session: "b", SQL: "SET SESSION innodb_lock_wait_timeout = 1"},
+				{Session: "b", SQL: "UPDATE authors SET name = 'B' WHERE id = 2", ExpectError: true},
+				{Session: "a", SQL: "ROLLBACK"},
+			},
+		})
+	})
+
+	t.Run("open_transaction", func(t *testing.T) {
+		// The session connection is discarded, which ends its transaction and releases the row lock.
+		box.RunScenario(t, mysqlbox.Scenario{
+			Sessions: []string{"a"},
+			Steps: []mysqlbox.Step{
+				{Session: "a", SQL: "BEGIN"},
+				{Session: "a", SQL: "SELECT * FROM authors WHERE id = 2 FOR UPDATE"},
+			},
+		})
+
+		require.Eventually(t, func() bool {
+			var count int
+			err := box.MustDB().QueryRow("SELECT COUNT(*) FROM information_schema.innodb_trx").Scan(&count)
+			return err == nil && count == 0
+		}, 5*time.Second, 100*time.Millisecond)
+	})
+}
+
+func TestLoadProfile(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	db := box.MustDB()
+	var count int
+
+	t.Run("sbtest", func(t *testing.T) {
+		box.MustLoadProfile("sbtest", 2)
+
+		err := db.QueryRow("SELECT COUNT(*) FROM sbtest1").Scan(&count)
+		require.NoError(t, err)
+		require.Equal(t, 2000, count)
+	})
+
+	t.Run("tpcc", func(t *testing.T) {
+		box.MustLoadProfile("tpcc", 1)
+
+		err := db.QueryRow("SELECT COUNT(*) FROM customer").Scan(&count)
+		require.NoError(t, err)
+		require.Equal(t, 300, count)
+
+		err = db.QueryRow("SELECT COUNT(*) FROM stock").Scan(&count)
+		require.NoError(t, err)
+		require.Equal(t, 1000, count)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		err := box.LoadProfile("tpch", 1)
+		require.Error(t, err)
+	})
+}
+
+func TestCreateUser(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromFile("./testdata/schema.sql"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	dsn := box.MustCreateUser("reader", "it's secret", "SELECT ON testing.*")
+
+	db, err := sql.Open("mysql", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM categories").Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, 5, count)
+
+	_, err = db.Exec("DELETE FROM categories")
+	require.Error(t, err)
+
+	t.Run("invalid_grant", func(t *testing.T) {
+		_, err := box.CreateUser("writer", "secret", "FLY ON testing.*")
+		require.Error(t, err)
+	})
+}
+
+func TestStopAcceptingConnections(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromFile("./testdata/schema.sql"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	dsn := box.MustCreateUser("app", "secret", "SELECT ON testing.*")
+
+	db, err := sql.Open("mysql", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.Close()
+	})
+	db.SetMaxOpenConns(1)
+
+	err = db.Ping()
+	require.NoError(t, err)
+
+	box.MustStopAcceptingConnections()
+
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM categories").Scan(&count)
+	require.Error(t, err)
+
+	// The box DB connects as root and is not affected.
+	err = box.MustDB().QueryRow("SELECT COUNT(*) FROM categories").Scan(&count)
+	require.NoError(t, err)
+
+	box.MustAcceptConnections()
+
+	err = db.QueryRow("SELECT COUNT(*) FROM categories").Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, 5, count)
+}
+
+func TestSetGlobalVar(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{AdminInterface: true})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	box.MustSetGlobalVar("max_connections", 42)
+
+	vars := box.MustEffectiveVariables("max_connections")
+	require.Equal(t, "42", vars["max_connections"])
+
+	t.Run("unknown_variable", func(t *testing.T) {
+		err := box.SetGlobalVar("no_such_variable", 1)
+		require.Error(t, err)
+	})
+
+	t.Run("offline_mode", func(t *testing.T) {
+		box.MustSetOfflineMode(true)
+		t.Cleanup(func() {
+			box.MustSetOfflineMode(false)
+		})
+
+		vars := box.MustEffectiveVariables("offline_mode")
+		require.Equal(t, "ON", vars["offline_mode"])
+	})
+
+	t.Run("require_secure_transport", func(t *testing.T) {
+		err := box.SetRequireSecureTransport(true)
+		require.Error(t, err)
+
+		box.MustSetRequireSecureTransport(false)
+	})
+
+	t.Run("admin_interface", func(t *testing.T) {
+		require.NotEmpty(t, box.AdminAddr())
+
+		db, err := sql.Open("mysql", box.MustAdminDSN())
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			db.Close()
+		})
+
+		var port int
+		err = db.QueryRow("SELECT @@admin_port").Scan(&port)
+		require.NoError(t, err)
+		require.Equal(t, 33062, port)
+	})
+}
+
+func TestDump(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromFile("./testdata/schema.sql"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	var dump bytes.Buffer
+	box.MustDump(context.Background(), &dump, nil)
+	require.Contains(t, dump.String(), "CREATE DATABASE")
+	require.Contains(t, dump.String(), "CREATE TABLE `categories`")
+	require.Contains(t, dump.String(), "INSERT INTO `categories`")
+
+	t.Run("restore", func(t *testing.T) {
+		target, err := mysqlbox.Start(&mysqlbox.Config{})
+		require.NoError(t, err)
+		t.Cleanup(target.MustStop)
+
+		_, stderr, exitCode, err := target.Exec(context.Background(), []string{"mysql", "--user=root", "-e",
+			dump.String()})
+		require.NoError(t, err)
+		require.Equal(t, 0, exitCode, string(stderr))
+
+		var count int
+		err = target.MustDB().QueryRow("SELECT COUNT(*) FROM categories").Scan(&count)
+		require.NoError(t, err)
+		require.Equal(t, 5, count)
+	})
+
+	t.Run("no_data", func(t *testing.T) {
+		var dump bytes.Buffer
+		box.MustDump(context.Background(), &dump, &mysqlbox.DumpOptions{
+			Tables: []string{"categories"},
+			NoData: true,
+		})
+		require.Contains(t, dump.String(), "CREATE TABLE `categories`")
+		require.NotContains(t, dump.String(), "INSERT INTO")
+		require.NotContains(t, dump.String(), "CREATE DATABASE")
+	})
+
+	t.Run("unknown_table", func(t *testing.T) {
+		err := box.Dump(context.Background(), io.Discard, &mysqlbox.DumpOptions{Tables: []string{"missing"}})
+		require.Error(t, err)
+	})
+}
+
+func TestCreateTable(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	box.MustCreateTable("CREATE TABLE events (id INT PRIMARY KEY, body TEXT)",
+		&mysqlbox.TableOptions{RowFormat: "COMPRESSED"})
+
+	var rowFormat string
+	err = box.MustDB().QueryRow("SELECT row_format FROM information_schema.tables " +
+		"WHERE table_schema = 'testing' AND table_name = 'events'").Scan(&rowFormat)
+	require.NoError(t, err)
+	require.Equal(t, "Compressed", rowFormat)
+
+	t.Run("encryption_without_keyring", func(t *testing.T) {
+		err := box.CreateTable("CREATE TABLE secrets (id INT PRIMARY KEY)", &mysqlbox.TableOptions{Encryption: true})
+		require.ErrorContains(t, err, "keyring")
+	})
+
+	t.Run("invalid_ddl", func(t *testing.T) {
+		err := box.CreateTable("CREATE TABLE broken (", nil)
+		require.Error(t, err)
+	})
+}
+
+func TestConvertTableCharset(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	db := box.MustDB()
+	_, err = db.Exec(`CREATE TABLE comments (
+		id INT PRIMARY KEY,
+		author VARCHAR(255) NOT NULL,
+		body TEXT,
+		KEY author (author)
+	) CHARACTER SET utf8mb3 ROW_FORMAT=COMPACT`)
+	require.NoError(t, err)
+
+	_, err = db.Exec("INSERT INTO comments VALUES (1, 'ana', 'déjà vu'), (2, 'bob', 'naïve')")
+	require.NoError(t, err)
+
+	t.Run("dry_run", func(t *testing.T) {
+		report := box.MustConvertTableCharset("comments", "utf8mb4", "",
+			&mysqlbox.ConvertCharsetOptions{DryRun: true})
+		require.Equal(t, "utf8mb4_0900_ai_ci", report.Collation)
+		require.Len(t, report.Columns, 2)
+		require.Equal(t, "author", report.Columns[0].Name)
+		require.Zero(t, report.Columns[0].LossyRows)
+		require.Len(t, report.Warnings, 1, report.String())
+		require.Contains(t, report.Warnings[0], "index author")
+
+		var charset string
+		err := db.QueryRow("SELECT character_set_name FROM information_schema.columns " +
+			"WHERE table_schema = 'testing' AND table_name = 'comments' AND column_name = 'body'").Scan(&charset)
+		require.NoError(t, err)
+		require.Equal(t, "utf8mb3", charset)
+	})
+
+	t.Run("lossy", func(t *testing.T) {
+		report := box.MustConvertTableCharset("comments", "ascii", "", &mysqlbox.ConvertCharsetOptions{DryRun: true})
+		require.False(t, report.Safe())
+		require.Equal(t, int64(2), report.Columns[1].LossyRows)
+	})
+
+	t.Run("convert", func(t *testing.T) {
+		_, err := db.Exec("ALTER TABLE comments ROW_FORMAT=DYNAMIC")
+		require.NoError(t, err)
+
+		var calls []int64
+		report := box.MustConvertTableCharset("comments", "utf8mb4", "utf8mb4_unicode_ci",
+			&mysqlbox.ConvertCharsetOptions{
+				Progress: func(done int64, total int64) {
+					calls = append(calls, done, total)
+				},
+			})
+		require.True(t, report.Safe(), report.String())
+		require.NotEmpty(t, calls)
+		require.Equal(t, calls[len(calls)-2], calls[len(calls)-1])
+
+		var collation, body string
+		err = db.QueryRow("SELECT collation_name FROM information_schema.columns " +
+			"WHERE table_schema = 'testing' AND table_name = 'comments' AND column_name = 'body'").Scan(&collation)
+		require.NoError(t, err)
+		require.Equal(t, "utf8mb4_unicode_ci", collation)
+
+		err = db.QueryRow("SELECT body FROM comments WHERE id = 1").Scan(&body)
+		require.NoError(t, err)
+		require.Equal(t, "déjà vu", body)
+	})
+
+	t.Run("unknown_charset", func(t *testing.T) {
+		_, err := box.ConvertTableCharset("comments", "klingon", "", nil)
+		require.ErrorContains(t, err, "unknown character set")
+	})
+
+	t.Run("unknown_table", func(t *testing.T) {
+		_, err := box.ConvertTableCharset("missing", "utf8mb4", "", nil)
+		require.ErrorContains(t, err, "does not exist")
+	})
+}
+
+func TestDumpSchema(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromFile("./testdata/schema.sql"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	ctx := context.Background()
+
+	var schema bytes.Buffer
+	box.MustDumpSchema(ctx, &schema)
+	require.Contains(t, schema.String(), "CREATE TABLE `categories`")
+	require.NotContains(t, schema.String(), "INSERT INTO")
+	require.NotContains(t, schema.String(), "AUTO_INCREMENT=")
+	require.NotContains(t, schema.String(), "Dump completed")
+
+	// Rows do not change the schema dump.
+	_, err = box.MustDB().Exec("DELETE FROM categories")
+	require.NoError(t, err)
+
+	var again bytes.Buffer
+	box.MustDumpSchema(ctx, &again)
+	require.Equal(t, schema.String(), again.String())
+}
+
+func TestDiffSchema(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromFile("./testdata/schema.sql"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	diffs := box.MustDiffSchema(mysqlbox.DataFromFile("./testdata/schema.sql"))
+	require.Empty(t, diffs)
+
+	t.Run("dump_schema", func(t *testing.T) {
+		var schema bytes.Buffer
+		box.MustDumpSchema(context.Background(), &schema)
+
+		diffs := box.MustDiffSchema(mysqlbox.DataFromBuffer(schema.Bytes()))
+		require.Empty(t, diffs)
+	})
+
+	t.Run("drift", func(t *testing.T) {
+		db := box.MustDB()
+		_, err := db.Exec("ALTER TABLE users MODIFY email VARCHAR(255) NOT NULL, ADD COLUMN name VARCHAR(64)")
+		require.NoError(t, err)
+		_, err = db.Exec("CREATE TABLE audit (id INT PRIMARY KEY)")
+		require.NoError(t, err)
+
+		diffs := box.MustDiffSchema(mysqlbox.DataFromFile("./testdata/schema.sql"))
+		require.Len(t, diffs, 3, "%v", diffs)
+
+		require.Equal(t, mysqlbox.SchemaTable, diffs[0].Object)
+		require.Equal(t, "audit", diffs[0].Table)
+		require.Empty(t, diffs[0].Expected)
+
+		require.Equal(t, mysqlbox.SchemaColumn, diffs[1].Object)
+		require.Equal(t, "email", diffs[1].Name)
+		require.Contains(t, diffs[1].Expected, "varchar(128)")
+		require.Contains(t, diffs[1].Actual, "varchar(255)")
+
+		require.Equal(t, "name", diffs[2].Name)
+		require.Empty(t, diffs[2].Expected)
+	})
+
+	t.Run("invalid_schema", func(t *testing.T) {
+		_, err := box.DiffSchema(mysqlbox.DataFromBuffer([]byte("CREATE TABLE broken (")))
+		require.Error(t, err)
+	})
+}
+
+func TestRunSidecar(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromFile("./testdata/schema.sql"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	ctx := context.Background()
+	script := `MYSQL_PWD="$MYSQL_PASSWORD" mysql -h "$MYSQL_HOST" -P "$MYSQL_PORT" -u "$MYSQL_USER" -N ` +
+		`-e "SELECT COUNT(*) FROM categories" "$MYSQL_DATABASE" && echo "$GREETING" >&2`
+
+	result := box.MustRunSidecar(ctx, "mysql:8", map[string]string{"GREETING": "hello"},
+		&mysqlbox.SidecarOptions{Entrypoint: []string{"sh", "-c"}, Cmd: []string{script}})
+	require.Equal(t, int64(0), result.ExitCode, result.Stderr)
+	require.Equal(t, "5", strings.TrimSpace(result.Stdout))
+	require.Contains(t, result.Stderr, "hello")
+
+	t.Run("exit_code", func(t *testing.T) {
+		result := box.MustRunSidecar(ctx, "mysql:8", nil,
+			&mysqlbox.SidecarOptions{Entrypoint: []string{"sh", "-c"}, Cmd: []string{"exit 3"}})
+		require.Equal(t, int64(3), result.ExitCode)
+	})
+}
+
+func TestRestore(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromFile("./testdata/schema.sql"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	ctx := context.Background()
+	db := box.MustDB()
+
+	var saved bytes.Buffer
+	box.MustDump(ctx, &saved, nil)
+
+	_, err = db.Exec("DELETE FROM categories")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE leftover (id INT)")
+	require.NoError(t, err)
+
+	box.MustRestore(ctx, &saved)
+
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM categories").Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, 5, count)
+
+	err = db.QueryRow("SELECT COUNT(*) FROM information_schema.tables " +
+		"WHERE table_schema = 'testing' AND table_name = 'leftover'").Scan(&count)
+	require.NoError(t, err)
+	require.Zero(t, count)
+
+	t.Run("invalid_dump", func(t *testing.T) {
+		err := box.Restore(ctx, strings.NewReader("CREATE TABLE broken ("))
+		require.Error(t, err)
+	})
+}
+
+func TestEnableTLS(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		EnableTLS: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	var name, cipher string
+	err = box.MustDB().QueryRow("SHOW SESSION STATUS LIKE 'Ssl_cipher'").Scan(&name, &cipher)
+	require.NoError(t, err)
+	require.NotEmpty(t, cipher)
+
+	tlsConfig := box.MustTLSConfig()
+	require.NotNil(t, tlsConfig.RootCAs)
+
+	t.Run("insecure_connection", func(t *testing.T) {
+		dsn := box.MustDSNFor("root", "", "testing", map[string]string{"tls": "false"})
+		db, err := sql.Open("mysql", dsn)
+		require.NoError(t, err)
+		defer db.Close()
+
+		require.Error(t, db.Ping())
+	})
+}
+
+func TestRunSysbench(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	result, err := box.RunSysbench(context.Background(), mysqlbox.SysbenchOptions{
+		TableSize: 1000,
+		Duration:  2 * time.Second,
+	})
+	require.NoError(t, err)
+	require.Greater(t, result.Transactions, int64(0))
+	require.Greater(t, result.TPS, 0.0)
+	require.Greater(t, result.LatencyPercentile, time.Duration(0))
+}
+
+func TestXProtocolAddr(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	conn, err := net.DialTimeout("tcp", box.XProtocolAddr(), 5*time.Second)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Send a Mysqlx.Connection.CapabilitiesGet message: a 4-byte little-endian length followed by the message type.
+	_, err = conn.Write([]byte{1, 0, 0, 0, 1})
+	require.NoError(t, err)
+
+	// The server replies with a Mysqlx.Connection.Capabilities message (type 2), possibly after notices (type 11).
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	for {
+		header := make([]byte, 5)
+		_, err := io.ReadFull(conn, header)
+		require.NoError(t, err)
+
+		length := int(header[0]) | int(header[1])<<8 | int(header[2])<<16 | int(header[3])<<24
+		_, err = io.CopyN(io.Discard, conn, int64(length-1))
+		require.NoError(t, err)
+
+		if header[4] != 11 {
+			require.Equal(t, byte(2), header[4])
+			break
+		}
+	}
+}
+
+func TestWaitReady(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = mysqlbox.WaitReady(ctx, box.MustDSN())
+	require.NoError(t, err)
+}
+
+func TestAttach(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		Database:     "attached",
+		RootPassword: "secret",
+		InitialSQL:   mysqlbox.DataFromBuffer([]byte("CREATE TABLE users (id INT PRIMARY KEY);")),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	attached, err := mysqlbox.Attach(context.Background(), box.MustContainerName(), nil)
+	require.NoError(t, err)
+
+	require.Equal(t, box.MustContainerID(), attached.MustContainerID())
+	require.Equal(t, box.DBAddr(), attached.DBAddr())
+	require.Equal(t, "secret", attached.RootPassword())
+
+	db := attached.MustDB()
+	_, err = db.Exec("INSERT INTO users (id) VALUES (1)")
+	require.NoError(t, err)
+
+	require.NoError(t, attached.CleanAllTables())
+
+	var count int
+	err = box.MustDB().QueryRow("SELECT COUNT(*) FROM users").Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, 0, count)
+
+	// Stopping the attached box leaves the container running.
+	require.NoError(t, attached.Stop())
+	require.NoError(t, box.MustDB().Ping())
+}
+
+func TestMaintenance(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL:       mysqlbox.DataFromBuffer([]byte("CREATE TABLE users (id INT PRIMARY KEY);")),
+		MaintenanceEvery: 2,
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	db := box.MustDB()
+
+	t.Run("purge binary logs", func(t *testing.T) {
+		_, err := db.Exec("INSERT INTO users (id) VALUES (1)")
+		require.NoError(t, err)
+
+		require.NoError(t, box.PurgeBinaryLogs())
+
+		rows, err := db.Query("SHOW BINARY LOGS")
+		require.NoError(t, err)
+		defer rows.Close()
+
+		var count int
+		for rows.Next() {
+			count++
+		}
+		require.NoError(t, rows.Err())
+		require.Equal(t, 1, count)
+	})
+
+	t.Run("shrink temp tablespace", func(t *testing.T) {
+		_, err := db.Exec("CREATE TEMPORARY TABLE tmp_users (id INT)")
+		require.NoError(t, err)
+
+		require.NoError(t, box.ShrinkTempTablespace())
+	})
+
+	t.Run("after clean", func(t *testing.T) {
+		require.NoError(t, box.CleanAllTables())
+		require.NoError(t, box.CleanAllTables())
+	})
+}
+
+func TestContainerDSN(t *testing.T) {
+	ctx := context.Background()
+
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	require.NoError(t, err)
+
+	networkName := "mysqlbox-test-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	created, err := cli.NetworkCreate(ctx, networkName, types.NetworkCreate{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = cli.NetworkRemove(ctx, created.ID)
+	})
+
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		Network:        networkName,
+		NetworkAliases: []string{"db"},
+		RootPassword:   "secret",
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	dsn, err := box.ContainerDSN("testing")
+	require.NoError(t, err)
+	require.Equal(t, "root:secret@tcp(db:3306)/testing?parseTime=true", dsn)
+
+	cr, err := cli.ContainerInspect(ctx, box.MustContainerID())
+	require.NoError(t, err)
+	require.Contains(t, cr.NetworkSettings.Networks, networkName)
+}
+
+func TestSetReplicationDelay(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	t.Run("not a replica", func(t *testing.T) {
+		err := box.SetReplicationDelay(time.Second)
+		require.EqualError(t, err, "server is not a replica")
+	})
+
+	t.Run("negative", func(t *testing.T) {
+		err := box.SetReplicationDelay(-time.Second)
+		require.Error(t, err)
+	})
+
+	t.Run("replica", func(t *testing.T) {
+		db := box.MustDB()
+
+		// Configure replication from a source that does not exist. The SQL thread can still be stopped, changed,
+		// and started without a connection to the source.
+		_, err := db.Exec("CHANGE REPLICATION SOURCE TO SOURCE_HOST = 'source.invalid', SOURCE_USER = 'repl'")
+		require.NoError(t, err)
+		_, err = db.Exec("START REPLICA SQL_THREAD")
+		require.NoError(t, err)
+
+		require.NoError(t, box.SetReplicationDelay(30*time.Second))
+
+		rows, err := db.Query("SHOW REPLICA STATUS")
+		require.NoError(t, err)
+		defer rows.Close()
+
+		columns, err := rows.Columns()
+		require.NoError(t, err)
+		require.True(t, rows.Next())
+
+		values := make([]sql.NullString, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for n := range values {
+			ptrs[n] = &values[n]
+		}
+		require.NoError(t, rows.Scan(ptrs...))
+
+		delay := map[string]string{}
+		for n, column := range columns {
+			delay[column] = values[n].String
+		}
+		require.Equal(t, "30", delay["SQL_Delay"])
+	})
+}
+
+func TestEndpoint(t *testing.T) {
+	primary, err := mysqlbox.Start(&mysqlbox.Config{RootPassword: "secret"})
+	require.NoError(t, err)
+	t.Cleanup(primary.MustStop)
+
+	standby, err := mysqlbox.Start(&mysqlbox.Config{RootPassword: "secret"})
+	require.NoError(t, err)
+	t.Cleanup(standby.MustStop)
+
+	endpoint := mysqlbox.MustNewEndpoint(primary)
+	t.Cleanup(func() {
+		_ = endpoint.Close()
+	})
+
+	db, err := sql.Open("mysql", endpoint.DSN("root", "secret", "testing"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	hostname := func(box *mysqlbox.MySQLBox) string {
+		var name string
+		err := box.MustDB().QueryRow("SELECT @@hostname").Scan(&name)
+		require.NoError(t, err)
+		return name
+	}
+
+	var name string
+	require.NoError(t, db.QueryRow("SELECT @@hostname").Scan(&name))
+	require.Equal(t, hostname(primary), name)
+
+	endpoint.MustFailover(standby)
+
+	require.Eventually(t, func() bool {
+		err := db.QueryRow("SELECT @@hostname").Scan(&name)
+		return err == nil && name == hostname(standby)
+	}, 10*time.Second, 100*time.Millisecond)
+}
+
+func TestPause(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	db := box.MustDB()
+	require.NoError(t, db.Ping())
+
+	require.NoError(t, box.Pause())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, err = db.ExecContext(ctx, "SELECT 1")
+	require.Error(t, err)
+
+	require.NoError(t, box.Unpause())
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	require.NoError(t, db.PingContext(ctx))
+}
+
+func TestConnections(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	box.CheckNoOtherTestConnections(t)
+
+	t.Run("tagged", func(t *testing.T) {
+		db, err := sql.Open("mysql", box.MustDSNForTest(t, "testing"))
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = db.Close()
+		})
+		require.NoError(t, db.Ping())
+
+		conns := box.MustConnections()
+
+		var tests []string
+		for _, c := range conns {
+			if c.Test != "" {
+				tests = append(tests, c.Test)
+			}
+		}
+		require.Equal(t, []string{t.Name()}, tests)
+	})
+
+	t.Run("other test", func(t *testing.T) {
+		db, err := sql.Open("mysql", box.MustDSNForTest(t, "testing"))
+		require.NoError(t, err)
+		defer db.Close()
+		require.NoError(t, db.Ping())
+
+		conns, err := box.Connections()
+		require.NoError(t, err)
+
+		var found bool
+		for _, c := range conns {
+			if c.Test == t.Name() {
+				found = true
+				require.Equal(t, "root", c.User)
+				require.Equal(t, "testing", c.Database)
+			}
+		}
+		require.True(t, found)
+	})
+}
+
+func TestRestart(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromBuffer([]byte("CREATE TABLE users (id INT PRIMARY KEY);")),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	_, err = box.MustDB().Exec("INSERT INTO users (id) VALUES (1)")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	require.NoError(t, box.Restart(ctx))
+
+	var count int
+	err = box.MustDB().QueryRow("SELECT COUNT(*) FROM users").Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+}
+
+func TestIdleShutdown(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		IdleShutdown: 2 * time.Second,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = box.Stop()
+	})
+
+	// Close the connections of the box so that the server becomes idle.
+	require.NoError(t, box.MustDB().Close())
+
+	cli := box.MustDockerClient()
+	require.Eventually(t, func() bool {
+		_, err := cli.ContainerInspect(context.Background(), box.MustContainerID())
+		return client.IsErrNotFound(err)
+	}, time.Minute, time.Second)
+}
+
+func TestWithSQLMode(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromBuffer([]byte("CREATE TABLE orders (id INT PRIMARY KEY, customer INT, total INT);")),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	db := box.MustDB()
+	query := "SELECT customer, total FROM orders GROUP BY customer"
+
+	var initial string
+	require.NoError(t, db.QueryRow("SELECT @@GLOBAL.sql_mode").Scan(&initial))
+	require.Contains(t, initial, "ONLY_FULL_GROUP_BY")
+
+	t.Run("legacy", func(t *testing.T) {
+		box.WithSQLMode(t, "STRICT_TRANS_TABLES")
+
+		_, err := db.Exec(query)
+		require.NoError(t, err)
+	})
+
+	var restored string
+	require.NoError(t, db.QueryRow("SELECT @@GLOBAL.sql_mode").Scan(&restored))
+	require.Equal(t, initial, restored)
+
+	_, err = db.Exec(query)
+	require.Error(t, err)
+}
+
+func TestMySQLPortMax(t *testing.T) {
+	// Take a port, so that the box binds the next one.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	taken := listener.Addr().(*net.TCPAddr).Port
+
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		MySQLPort:    taken,
+		MySQLPortMax: taken + 10,
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	_, port, err := net.SplitHostPort(box.DBAddr())
+	require.NoError(t, err)
+	require.NotEqual(t, strconv.Itoa(taken), port)
+	require.NoError(t, box.MustDB().Ping())
+
+	t.Run("all_taken", func(t *testing.T) {
+		name := fmt.Sprintf("mysqlbox-port-taken-%d", taken)
+
+		_, err := mysqlbox.Start(&mysqlbox.Config{
+			ContainerName: name,
+			MySQLPort:     taken,
+			MySQLPortMax:  taken,
+		})
+		require.Error(t, err)
+
+		// The container of the failed start is removed, so its name can be used again.
+		box, err := mysqlbox.Start(&mysqlbox.Config{ContainerName: name})
+		require.NoError(t, err)
+		t.Cleanup(box.MustStop)
+	})
+}
+
+func TestBindIP(t *testing.T) {
+	t.Run("all_interfaces", func(t *testing.T) {
+		box, err := mysqlbox.Start(&mysqlbox.Config{BindIP: "0.0.0.0"})
+		require.NoError(t, err)
+		t.Cleanup(box.MustStop)
+
+		host, _, err := net.SplitHostPort(box.DBAddr())
+		require.NoError(t, err)
+		require.Equal(t, "127.0.0.1", host)
+		require.NoError(t, box.MustDB().Ping())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := mysqlbox.Start(&mysqlbox.Config{BindIP: "localhost"})
+		require.ErrorContains(t, err, "invalid bind IP")
+	})
+}
+
+func TestRetainFor(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{RetainFor: time.Second})
+	require.NoError(t, err)
+
+	containerName := box.MustContainerName()
+	require.NoError(t, box.Stop())
+
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	require.NoError(t, err)
+	defer cli.Close()
+
+	// The stopped container is kept until its retention period is over.
+	_, err = cli.ContainerInspect(context.Background(), containerName)
+	require.NoError(t, err)
+
+	time.Sleep(2 * time.Second)
+	_, err = mysqlbox.Cleanup(nil)
+	require.NoError(t, err)
+
+	_, err = cli.ContainerInspect(context.Background(), containerName)
+	require.True(t, client.IsErrNotFound(err))
+}
+
+func TestAutoTuning(t *testing.T) {
+	variable := func(db *sql.DB, name string) string {
+		var value string
+		err := db.QueryRow("SELECT @@" + name).Scan(&value)
+		require.NoError(t, err)
+		return value
+	}
+
+	t.Run("default", func(t *testing.T) {
+		box, err := mysqlbox.Start(&mysqlbox.Config{})
+		require.NoError(t, err)
+		t.Cleanup(box.MustStop)
+
+		db := box.MustDB()
+		require.Equal(t, "2", variable(db, "innodb_flush_log_at_trx_commit"))
+		require.Equal(t, "0", variable(db, "sync_binlog"))
+		require.Equal(t, "1", variable(db, "skip_name_resolve"))
+	})
+
+	t.Run("memory_limit", func(t *testing.T) {
+		box, err := mysqlbox.Start(&mysqlbox.Config{Memory: 2 << 30})
+		require.NoError(t, err)
+		t.Cleanup(box.MustStop)
+
+		// The buffer pool is 1/8 of the limit instead of the memory of the Docker host.
+		require.Equal(t, strconv.Itoa(256<<20), variable(box.MustDB(), "innodb_buffer_pool_size"))
+	})
+
+	t.Run("profile_overrides", func(t *testing.T) {
+		box, err := mysqlbox.Start(&mysqlbox.Config{Profile: "fast-ci"})
+		require.NoError(t, err)
+		t.Cleanup(box.MustStop)
+
+		require.Equal(t, "0", variable(box.MustDB(), "innodb_flush_log_at_trx_commit"))
+	})
+
+	t.Run("unsafe_fast", func(t *testing.T) {
+		box, err := mysqlbox.Start(&mysqlbox.Config{UnsafeFast: true})
+		require.NoError(t, err)
+		t.Cleanup(box.MustStop)
+
+		db := box.MustDB()
+		require.Equal(t, "0", variable(db, "innodb_flush_log_at_trx_commit"))
+		// innodb_doublewrite is an enum since MySQL 8.0.30.
+		require.Contains(t, []string{"0", "OFF"}, variable(db, "innodb_doublewrite"))
+		require.Equal(t, "0", variable(db, "log_bin"))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		box, err := mysqlbox.Start(&mysqlbox.Config{DisableAutoTuning: true})
+		require.NoError(t, err)
+		t.Cleanup(box.MustStop)
+
+		db := box.MustDB()
+		require.Equal(t, "1", variable(db, "innodb_flush_log_at_trx_commit"))
+		require.Equal(t, "0", variable(db, "skip_name_resolve"))
+	})
+}
+
+func TestUnixSocket(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{UnixSocket: true})
+	require.NoError(t, err)
+
+	path := box.MustSocketPath()
+	require.FileExists(t, path)
+
+	db, dsn := box.MustConnectSocketDB("testing")
+	require.Contains(t, dsn, "unix("+path+")")
+
+	// Socket connections are authenticated as 'root'@'localhost'.
+	var user string
+	err = db.QueryRow("SELECT CURRENT_USER()").Scan(&user)
+	require.NoError(t, err)
+	require.Equal(t, "root@localhost", user)
+
+	require.NoError(t, box.Stop())
+	require.NoDirExists(t, filepath.Dir(filepath.Dir(path)))
+}
+
+func TestCaptureTraffic(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{CaptureTraffic: true})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	capture := box.MustCapture()
+
+	db, err := sql.Open("mysql", capture.DSN("testing"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	_, err = db.Exec("SELECT 1")
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return strings.Contains(capture.String(), "COM_QUERY SELECT 1")
+	}, 5*time.Second, 100*time.Millisecond, capture.String())
+	require.Contains(t, capture.String(), "HandshakeResponse user=root")
+
+	var pcap bytes.Buffer
+	err = capture.WritePcap(&pcap)
+	require.NoError(t, err)
+	require.Greater(t, pcap.Len(), 24)
+
+	capture.Reset()
+	require.Empty(t, capture.Timeline())
+
+	// The box DB connects to MySQL directly.
+	_, err = box.MustDB().Exec("SELECT 2")
+	require.NoError(t, err)
+	require.Empty(t, capture.Timeline())
+}
+
+func TestToxiproxy(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{Toxiproxy: true})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	proxy := box.MustProxy()
+
+	db, err := sql.Open("mysql", proxy.DSN("testing"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	require.NoError(t, db.Ping())
+
+	t.Run("latency", func(t *testing.T) {
+		t.Cleanup(func() {
+			require.NoError(t, proxy.Reset())
+		})
+
+		_, err := proxy.AddLatency(300*time.Millisecond, 0)
+		require.NoError(t, err)
+
+		start := time.Now()
+		_, err = db.Exec("SELECT 1")
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, time.Since(start), 300*time.Millisecond)
+	})
+
+	t.Run("reset peer", func(t *testing.T) {
+		t.Cleanup(func() {
+			require.NoError(t, proxy.Reset())
+		})
+
+		_, err := proxy.AddResetPeer(0)
+		require.NoError(t, err)
+
+		conn, err := net.DialTimeout("tcp", proxy.Addr(), 5*time.Second)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		_, err = conn.Read(make([]byte, 1))
+		require.Error(t, err)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Cleanup(func() {
+			require.NoError(t, proxy.Reset())
+		})
+
+		require.NoError(t, proxy.SetEnabled(false))
+		require.NoError(t, db.Close())
+
+		db, err := sql.Open("mysql", proxy.DSN("testing"))
+		require.NoError(t, err)
+		defer db.Close()
+		require.Error(t, db.Ping())
+	})
+}
+
+func TestMySQLCLI(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{RootPassword: "secret"})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	t.Run("run sql file", func(t *testing.T) {
+		_, err := box.RunSQLFile("testdata/cli-script.sql")
+		require.NoError(t, err)
+
+		var count int
+		err = box.MustDB().QueryRow("SELECT COUNT(*) FROM counters").Scan(&count)
+		require.NoError(t, err)
+		require.Equal(t, 2, count)
+	})
+
+	t.Run("vertical output", func(t *testing.T) {
+		res, err := box.MySQLCLI("-e", "SELECT id, hits FROM counters WHERE id = 1\\G")
+		require.NoError(t, err)
+		require.Equal(t, 0, res.ExitCode)
+		require.Contains(t, res.Stdout, "*** 1. row ***")
+		require.Contains(t, res.Stdout, "hits: 0")
+	})
+
+	t.Run("error", func(t *testing.T) {
+		res, err := box.MySQLCLI("-e", "SELECT * FROM missing")
+		require.NoError(t, err)
+		require.NotEqual(t, 0, res.ExitCode)
+		require.Contains(t, res.Stderr, "doesn't exist")
+	})
+}
+
+func TestExec(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	ctx := context.Background()
+
+	t.Run("stdout", func(t *testing.T) {
+		stdout, stderr, exitCode, err := box.Exec(ctx, []string{"mysqladmin", "-uroot", "ping"})
+		require.NoError(t, err)
+		require.Equal(t, 0, exitCode)
+		require.Empty(t, stderr)
+		require.Contains(t, string(stdout), "mysqld is alive")
+	})
+
+	t.Run("exit code", func(t *testing.T) {
+		_, stderr, exitCode, err := box.Exec(ctx, []string{"sh", "-c", "echo failed >&2; exit 3"})
+		require.NoError(t, err)
+		require.Equal(t, 3, exitCode)
+		require.Equal(t, "failed\n", string(stderr))
+	})
+
+	t.Run("empty command", func(t *testing.T) {
+		_, _, _, err := box.Exec(ctx, nil)
+		require.Error(t, err)
+	})
+}
+
+func TestIgnoreInitErrors(t *testing.T) {
+	initialSQL := `
+		CREATE TABLE users (id INT PRIMARY KEY);
+		SELECT * FROM sales WHERE id = 1;
+		INSERT INTO users (id) VALUES (1);
+		INSERT INTO missing (id) VALUES (1);
+		INSERT INTO users (id) VALUES (2);
+	`
+	loggedErrors := []string{}
+
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL:       mysqlbox.DataFromBuffer([]byte(initialSQL)),
+		IgnoreInitErrors: true,
+		LoggedErrors:     &loggedErrors,
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	var count int
+	err = box.MustDB().QueryRow("SELECT COUNT(*) FROM users").Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+
+	initErrors := box.InitErrors()
+	require.Len(t, initErrors, 2)
+	require.Equal(t, 1146, initErrors[0].Code)
+	require.Equal(t, 3, initErrors[0].Line)
+	require.Equal(t, 1146, initErrors[1].Code)
+	require.Equal(t, 5, initErrors[1].Line)
+	require.Len(t, loggedErrors, 2)
+
+	var scriptErrors []mysqlbox.LogError
+	for _, e := range box.Errors() {
+		if e.Time.IsZero() {
+			scriptErrors = append(scriptErrors, e)
+		}
+	}
+	require.Equal(t, initErrors, scriptErrors)
+}
+
+func TestCopyFiles(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	ctx := context.Background()
+
+	t.Run("round trip", func(t *testing.T) {
+		require.NoError(t, box.CopyFileToContainer(ctx, "testdata/cli-script.sql", "/tmp/script.sql"))
+
+		hostPath := filepath.Join(t.TempDir(), "script.sql")
+		require.NoError(t, box.CopyFileFromContainer(ctx, "/tmp/script.sql", hostPath))
+
+		expected, err := os.ReadFile("testdata/cli-script.sql")
+		require.NoError(t, err)
+		actual, err := os.ReadFile(hostPath)
+		require.NoError(t, err)
+		require.Equal(t, expected, actual)
+	})
+
+	t.Run("source", func(t *testing.T) {
+		res, err := box.MySQLCLI("-e", "SOURCE /tmp/script.sql")
+		require.NoError(t, err)
+		require.Equal(t, 0, res.ExitCode, res.Stderr)
+
+		var count int
+		err = box.MustDB().QueryRow("SELECT COUNT(*) FROM counters").Scan(&count)
+		require.NoError(t, err)
+		require.Equal(t, 2, count)
+	})
+
+	t.Run("archive", func(t *testing.T) {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: "hello.txt", Mode: 0644, Size: 5}))
+		_, err := tw.Write([]byte("hello"))
+		require.NoError(t, err)
+		require.NoError(t, tw.Close())
+
+		require.NoError(t, box.CopyToContainer(ctx, "/tmp", &buf))
+
+		reader, err := box.CopyFromContainer(ctx, "/tmp/hello.txt")
+		require.NoError(t, err)
+		defer reader.Close()
+
+		tr := tar.NewReader(reader)
+		header, err := tr.Next()
+		require.NoError(t, err)
+		require.Equal(t, "hello.txt", header.Name)
+
+		content, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		require.Equal(t, "hello", string(content))
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		err := box.CopyFileFromContainer(ctx, "/tmp", filepath.Join(t.TempDir(), "tmp"))
+		require.Error(t, err)
+	})
+}
+
+func TestEffectiveVariables(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	t.Run("selected", func(t *testing.T) {
+		vars, err := box.EffectiveVariables("AUTOCOMMIT", "general_log")
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"autocommit": "ON", "general_log": "ON"}, vars)
+	})
+
+	t.Run("all", func(t *testing.T) {
+		vars := box.MustEffectiveVariables()
+		require.Greater(t, len(vars), 100)
+		require.Contains(t, vars, "version")
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := box.EffectiveVariables("no_such_variable")
+		require.Error(t, err)
+	})
+
+	t.Run("compare", func(t *testing.T) {
+		box.CheckVariables(t, "testdata/variables.json")
+
+		_, err := box.MustDB().Exec("SET GLOBAL innodb_flush_log_at_trx_commit = 2")
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_, _ = box.MustDB().Exec("SET GLOBAL innodb_flush_log_at_trx_commit = 1")
+		})
+
+		changes, err := box.CompareVariables("testdata/variables.json")
+		require.NoError(t, err)
+		require.Equal(t, []mysqlbox.VariableChange{
+			{Name: "innodb_flush_log_at_trx_commit", Expected: "1", Current: "2"},
+		}, changes)
+	})
+}
+
+func TestStats(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	t.Run("sample", func(t *testing.T) {
+		stats, err := box.Stats(context.Background())
+		require.NoError(t, err)
+		require.Greater(t, stats.MemoryUsage, uint64(0))
+		require.Greater(t, stats.MemoryLimit, stats.MemoryUsage)
+	})
+
+	t.Run("watch", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		var samples []mysqlbox.Stats
+		err := box.WatchStats(ctx, time.Second, func(stats mysqlbox.Stats) {
+			samples = append(samples, stats)
+		})
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, len(samples), 2)
+	})
+}
+
+func TestQueryWatchdog(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		QueryWatchdog: time.Second,
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	start := time.Now()
+
+	// SLEEP() returns 1 when it is interrupted.
+	var interrupted int
+	err = box.MustDB().QueryRow("SELECT SLEEP(30)").Scan(&interrupted)
+	require.NoError(t, err)
+	require.Equal(t, 1, interrupted)
+	require.Less(t, time.Since(start), 10*time.Second)
+
+	killed := box.KilledQueries()
+	require.Len(t, killed, 1)
+	require.Equal(t, "SELECT SLEEP(30)", killed[0].Query)
+	require.Equal(t, "testing", killed[0].Database)
+	require.GreaterOrEqual(t, killed[0].Duration, time.Second)
+}
+
+func TestLogger(t *testing.T) {
+	var messages []string
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		Logger: mysqlbox.LoggerFunc(func(format string, v ...interface{}) {
+			messages = append(messages, fmt.Sprintf(format, v...))
+		}),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	require.NoError(t, box.CleanTables("missing"))
+	require.Len(t, messages, 1)
+	require.Contains(t, messages[0], "truncate table failed (missing)")
+}
+
+func TestDebugUI(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		DebugUIAddr: "127.0.0.1:0",
+		InitialSQL:  mysqlbox.DataFromBuffer([]byte("CREATE TABLE debug_ui (id int PRIMARY KEY);")),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	_, err = box.MustDB().Exec("INSERT INTO debug_ui VALUES (1), (2)")
+	require.NoError(t, err)
+
+	addr := box.DebugUIAddr()
+	require.NotEmpty(t, addr)
+
+	resp, err := http.Get("http://" + addr + "/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), "<td>debug_ui</td><td class=\"num\">2</td>")
+	require.Contains(t, string(body), "INSERT INTO debug_ui VALUES (1), (2)")
+	require.Contains(t, string(body), "ready for connections")
+}
+
+func TestStartAdminUI(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	u, err := box.StartAdminUI(context.Background())
+	require.NoError(t, err)
+	require.Contains(t, u, "username=root")
+
+	again, err := box.StartAdminUI(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, u, again)
+
+	resp, err := http.Get(u)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), "Adminer")
+}
+
+func TestSeedReport(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromFile("./testdata/schema.sql"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	box.MustLoadFixtures("./testdata/fixtures")
+
+	report, err := box.SeedReport()
+	require.NoError(t, err)
+	require.Equal(t, "testing", report.Database)
+	require.Len(t, report.Sources, 3)
+	require.Equal(t, "initial_sql", report.Sources[0].Kind)
+	require.Equal(t, "fixtures", report.Sources[1].Kind)
+	require.Equal(t, "testdata/fixtures/categories.csv", report.Sources[1].Name)
+
+	tables := map[string]mysqlbox.SeedTable{}
+	for _, table := range report.Tables {
+		tables[table.Name] = table
+	}
+	require.EqualValues(t, 7, tables["categories"].Rows)
+	require.NotZero(t, tables["categories"].Checksum)
+
+	path := filepath.Join(t.TempDir(), "artifacts", "seed.json")
+	box.MustWriteSeedReport(path)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(content), `"kind": "fixtures"`)
+}
+
+func TestPullImage(t *testing.T) {
+	ctx := context.Background()
+
+	// The image is only pulled when it is not available locally, so pulling it again is cheap.
+	require.NoError(t, mysqlbox.PullImage(ctx, ""))
+	require.NoError(t, mysqlbox.PullImage(ctx, "mysql:8"))
+
+	require.Error(t, mysqlbox.PullImage(ctx, "mysqlbox-does-not-exist/missing:latest"))
+}
+
+func TestCommitToImage(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromBuffer([]byte("CREATE TABLE committed (id int PRIMARY KEY);")),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	_, err = box.MustDB().Exec("INSERT INTO committed VALUES (1), (2), (3)")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	tag := "mysqlbox-commit-test:" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	id, err := box.CommitToImage(ctx, tag, &mysqlbox.CommitOptions{LockTables: true})
+	require.NoError(t, err)
+	require.NotEmpty(t, id)
+
+	t.Cleanup(func() {
+		_, _ = box.MustDockerClient().ImageRemove(context.Background(), id, types.ImageRemoveOptions{Force: true})
+	})
+
+	restored, err := mysqlbox.Start(&mysqlbox.Config{Image: tag})
+	require.NoError(t, err)
+	t.Cleanup(restored.MustStop)
+
+	var count int
+	err = restored.MustDB().QueryRow("SELECT COUNT(*) FROM committed").Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+}
+
+func TestCleanAllTablesContext(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromFile("./testdata/schema.sql"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	t.Run("progress", func(t *testing.T) {
+		var tables []string
+		err := box.CleanAllTablesContext(context.Background(), func(done int, total int, table string) {
+			require.Equal(t, len(tables)+1, done)
+			require.Equal(t, 2, total)
+			tables = append(tables, table)
+		})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"categories", "users"}, tables)
+	})
+
+	t.Run("metadata_lock", func(t *testing.T) {
+		// A transaction that read a table holds a metadata lock on it until it ends, which blocks TRUNCATE.
+		tx, err := box.MustDB().Begin()
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		_, err = tx.Exec("SELECT * FROM users")
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		err = box.CleanAllTablesContext(ctx, nil)
+		require.ErrorIs(t, err, mysqlbox.ErrTimeout)
+	})
+}
+
+func TestKillLockHolders(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL:      mysqlbox.DataFromFile("./testdata/schema.sql"),
+		KillLockHolders: true,
+		Logger:          mysqlbox.DiscardLogger,
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	// A leaked transaction holds a metadata lock on the users table until it ends.
+	db, _, err := box.ConnectDB("testing")
+	require.NoError(t, err)
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	_, err = tx.Exec("SELECT * FROM users")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	require.NoError(t, box.CleanAllTablesContext(ctx, nil))
+
+	killed := box.KilledQueries()
+	require.Len(t, killed, 1)
+	require.Equal(t, "testing.users", killed[0].LockedTable)
+
+	// The transaction of the killed connection cannot continue.
+	require.Error(t, tx.Commit())
+}
+
+func TestHealthCheck(t *testing.T) {
+	// The default MySQL image has mysqladmin but no mariadb-admin.
+	box, err := mysqlbox.Start(&mysqlbox.Config{})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	inspected, err := box.MustDockerClient().ContainerInspect(context.Background(), box.MustContainerID())
+	require.NoError(t, err)
+	require.NotNil(t, inspected.State.Health)
+	require.Equal(t, "healthy", inspected.State.Health.Status)
+
+	// The check fails with exit status 1 until the server is available, never with 127 for a missing command.
+	for _, result := range inspected.State.Health.Log {
+		require.Contains(t, []int{0, 1}, result.ExitCode)
+	}
+}
+
+func TestWaitFor(t *testing.T) {
+	t.Run("log", func(t *testing.T) {
+		box, err := mysqlbox.Start(&mysqlbox.Config{
+			WaitFor: mysqlbox.WaitForAll(
+				mysqlbox.WaitForLog(`ready for connections.*port: 3306`, 1),
+				mysqlbox.WaitForPing(),
+			),
+		})
+		require.NoError(t, err)
+		t.Cleanup(box.MustStop)
+
+		require.NoError(t, box.MustDB().Ping())
+	})
+
+	t.Run("sql", func(t *testing.T) {
+		box, err := mysqlbox.Start(&mysqlbox.Config{
+			InitialSQL: mysqlbox.DataFromFile("./testdata/schema.sql"),
+			WaitFor:    mysqlbox.WaitForSQL("SELECT COUNT(*) FROM categories"),
+		})
+		require.NoError(t, err)
+		t.Cleanup(box.MustStop)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		box, err := mysqlbox.Start(&mysqlbox.Config{
+			ReadyTimeout: 5 * time.Second,
+			WaitFor:      mysqlbox.WaitForLog("this line is never logged", 1),
+		})
+		require.ErrorIs(t, err, mysqlbox.ErrTimeout)
+		t.Cleanup(box.MustStop)
+	})
+}
+
+func TestGeneralLog(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	ctx := context.Background()
+	db := box.MustDB()
+
+	t.Run("copy", func(t *testing.T) {
+		_, err := db.Exec("SELECT 'general log copy marker'")
+		require.NoError(t, err)
+
+		require.Contains(t, box.MustGeneralLog(ctx), "SELECT 'general log copy marker'")
+	})
+
+	t.Run("tail", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+
+		pr, pw := io.Pipe()
+		tailErr := make(chan error, 1)
+		go func() {
+			err := box.TailGeneralLog(ctx, pw)
+			_ = pw.CloseWithError(err)
+			tailErr <- err
+		}()
+
+		_, err := db.Exec("SELECT 'general log tail marker'")
+		require.NoError(t, err)
+
+		found := false
+		scanner := bufio.NewScanner(pr)
+		for scanner.Scan() {
+			if strings.Contains(scanner.Text(), "SELECT 'general log tail marker'") {
+				found = true
+				break
+			}
+		}
+		require.True(t, found)
+
+		cancel()
+		go func() {
+			_, _ = io.Copy(io.Discard, pr)
+		}()
+		require.NoError(t, <-tailErr)
+	})
+}
+
+// fatalRecorder is a testing.TB that records the message of a fatal failure instead of failing the test.
+type fatalRecorder struct {
+	testing.TB
+	fatal    string
+	cleanups []func()
+}
+
+// Cleanup records the cleanup function, which is only called by runCleanups.
+func (r *fatalRecorder) Cleanup(fn func()) {
+	r.cleanups = append(r.cleanups, fn)
+}
+
+// runCleanups calls the recorded cleanup functions in the reverse order they were added.
+func (r *fatalRecorder) runCleanups() {
+	for n := len(r.cleanups) - 1; n >= 0; n-- {
+		r.cleanups[n]()
+	}
+}
+
+func (r *fatalRecorder) Fatal(args ...interface{}) {
+	r.fatal = fmt.Sprint(args...)
+	runtime.Goexit()
+}
+
+func (r *fatalRecorder) Fatalf(format string, args ...interface{}) {
+	r.fatal = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+// runRecorded calls fn in a goroutine, so that a fatal failure recorded by a fatalRecorder only ends fn.
+func runRecorded(fn func()) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	<-done
+}
+
+func TestAutoClean(t *testing.T) {
+	t.Run("clean", func(t *testing.T) {
+		box, err := mysqlbox.Start(&mysqlbox.Config{
+			InitialSQL: mysqlbox.DataFromFile("./testdata/schema.sql"),
+		})
+		require.NoError(t, err)
+		t.Cleanup(box.MustStop)
+
+		db := box.MustDB()
+
+		t.Run("dirty test", func(t *testing.T) {
+			box.AutoClean(t)
+
+			_, err := db.Exec("INSERT INTO users (id, email, created_at, updated_at) " +
+				"VALUES ('U-1', 'a@example.com', NOW(), NOW())")
+			require.NoError(t, err)
+		})
+
+		var count int
+		require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM users").Scan(&count))
+		require.Equal(t, 0, count)
+		require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM categories").Scan(&count))
+		require.Equal(t, 0, count)
+	})
+
+	t.Run("verify empty tables", func(t *testing.T) {
+		box, err := mysqlbox.Start(&mysqlbox.Config{
+			InitialSQL:        mysqlbox.DataFromFile("./testdata/schema.sql"),
+			VerifyEmptyTables: true,
+		})
+		require.NoError(t, err)
+		t.Cleanup(box.MustStop)
+
+		ft := &fatalRecorder{TB: t}
+		runRecorded(func() { box.AutoClean(ft) })
+		require.Contains(t, ft.fatal, "categories (5 rows):")
+		require.Contains(t, ft.fatal, `id="C-TEST1" name="Alpha"`)
+
+		// The leftover rows are truncated when the failed test finishes, so that the next test is not affected.
+		ft.runCleanups()
+
+		ft = &fatalRecorder{TB: t}
+		runRecorded(func() { box.AutoClean(ft) })
+		require.Empty(t, ft.fatal)
+	})
+}
+
+func TestStartLintInitialSQL(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL:     mysqlbox.DataFromBuffer([]byte("CREATE TABLE t (id INT);\nDROP DATABASE mysql;\n")),
+		LintInitialSQL: &mysqlbox.ScriptLintConfig{},
+	})
+	require.EqualError(t, err,
+		"initial SQL lint failed: initial SQL script 1: line 2: forbidden statement: DROP DATABASE mysql")
+	require.Nil(t, box)
+}
+
+func TestStartInvalidInitialSQL(t *testing.T) {
+	// The temporary files are created in TMPDIR.
+	tmpDir := t.TempDir()
+	t.Setenv("TMPDIR", tmpDir)
+
+	t.Run("nil_script", func(t *testing.T) {
+		_, err := mysqlbox.Start(&mysqlbox.Config{
+			InitialSQLs: []*mysqlbox.Data{mysqlbox.DataFromBuffer([]byte("SELECT 1;")), nil},
+		})
+		require.EqualError(t, err, "initial SQL script 2 is nil")
+	})
+
+	t.Run("temporary_files_removed", func(t *testing.T) {
+		_, err := mysqlbox.Start(&mysqlbox.Config{
+			InitialSQL:     mysqlbox.DataFromBuffer([]byte("DROP DATABASE mysql;")),
+			LintInitialSQL: &mysqlbox.ScriptLintConfig{},
+		})
+		require.Error(t, err)
+
+		// The container cannot be created without a Docker daemon.
+		t.Setenv("DOCKER_HOST", "tcp://127.0.0.1:1")
+		_, err = mysqlbox.Start(&mysqlbox.Config{
+			InitialSQL: mysqlbox.DataFromBuffer([]byte("SELECT 1;")),
+			EnableTLS:  true,
+			UnixSocket: runtime.GOOS != "windows",
+		})
+		require.Error(t, err)
+
+		entries, err := os.ReadDir(tmpDir)
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+}
+
+func TestSlowQueries(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromFile("./testdata/schema.sql"),
+		SlowQueryLog: &mysqlbox.SlowQueryLogConfig{
+			LongQueryTime:          time.Hour,
+			QueriesNotUsingIndexes: true,
+		},
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	ctx := context.Background()
+	db := box.MustDB()
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM categories WHERE created_at < NOW()").Scan(&count))
+	require.Equal(t, 5, count)
+
+	var found *mysqlbox.SlowQuery
+	for _, q := range box.MustSlowQueries(ctx) {
+		q := q
+		if q.Query == "SELECT COUNT(*) FROM categories WHERE created_at < NOW();" {
+			found = &q
+		}
+	}
+	require.NotNil(t, found)
+	require.Equal(t, int64(5), found.RowsExamined)
+	require.Equal(t, "testing", found.Database)
+
+	t.Run("not enabled", func(t *testing.T) {
+		box, err := mysqlbox.Start(&mysqlbox.Config{})
+		require.NoError(t, err)
+		t.Cleanup(box.MustStop)
+
+		_, err = box.SlowQueries(ctx)
+		require.Error(t, err)
+	})
+}
+
+func TestProfile(t *testing.T) {
+	t.Run("fast-ci", func(t *testing.T) {
+		box, err := mysqlbox.Start(&mysqlbox.Config{
+			InitialSQL: mysqlbox.DataFromFile("./testdata/schema.sql"),
+			Profile:    "fast-ci",
+		})
+		require.NoError(t, err)
+		t.Cleanup(box.MustStop)
+
+		var performanceSchema, logBin int
+		require.NoError(t, box.MustDB().QueryRow("SELECT @@performance_schema, @@log_bin").
+			Scan(&performanceSchema, &logBin))
+		require.Equal(t, 0, performanceSchema)
+		require.Equal(t, 0, logBin)
+	})
+
+	t.Run("prod-like", func(t *testing.T) {
+		box, err := mysqlbox.Start(&mysqlbox.Config{
+			Profile: "prod-like",
+		})
+		require.NoError(t, err)
+		t.Cleanup(box.MustStop)
+
+		var isolation, charset string
+		require.NoError(t, box.MustDB().QueryRow("SELECT @@transaction_isolation, @@character_set_server").
+			Scan(&isolation, &charset))
+		require.Equal(t, "READ-COMMITTED", isolation)
+		require.Equal(t, "utf8mb4", charset)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := mysqlbox.Start(&mysqlbox.Config{Profile: "unknown"})
+		require.ErrorContains(t, err, `unknown server profile "unknown"`)
+	})
+}
+
+func TestRecordingDB(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromFile("./testdata/schema.sql"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	db, recorder := box.MustRecordingDB()
+	require.NoError(t, db.Ping())
+	require.Equal(t, 0, recorder.Len())
+
+	var name string
+	require.NoError(t, db.QueryRow("SELECT name FROM categories WHERE id = ?", "C-TEST1").Scan(&name))
+	require.Equal(t, "Alpha", name)
+
+	_, err = db.Exec("DELETE FROM categories WHERE id = 'C-TEST2'")
+	require.NoError(t, err)
+
+	queries := recorder.Queries()
+	require.Len(t, queries, 2, recorder.String())
+	require.Equal(t, "SELECT name FROM categories WHERE id = ?", queries[0].Query)
+	require.Equal(t, []interface{}{"C-TEST1"}, queries[0].Args)
+	require.Equal(t, "DELETE FROM categories WHERE id = 'C-TEST2'", queries[1].Query)
+	for _, q := range queries {
+		require.NoError(t, q.Err)
+		require.Greater(t, q.Duration, time.Duration(0))
+	}
 }
@@ -0,0 +1,95 @@
+// Package mysqlboxgoose runs goose migrations against the database of a MySQLBox. Use it with
+// mysqlbox.Config.Migrations:
+//
+//	box, err := mysqlbox.Start(&mysqlbox.Config{
+//		Migrations: mysqlboxgoose.Dir("migrations"),
+//	})
+//
+// The package is a separate module because goose requires a newer Go version than mysqlbox.
+package mysqlboxgoose
+
+import (
+	"context"
+	"database/sql"
+	"io/fs"
+	"os"
+
+	"github.com/pressly/goose/v3"
+
+	"github.com/virgild/mysqlbox"
+)
+
+// migrator is a mysqlbox.Migrator that runs goose migrations from a fs.FS.
+type migrator struct {
+	fsys fs.FS
+	dir  string
+}
+
+// Dir returns a mysqlbox.Migrator that reads the goose migrations from a directory.
+func Dir(dir string) mysqlbox.Migrator {
+	return &migrator{fsys: os.DirFS(dir), dir: "."}
+}
+
+// FS returns a mysqlbox.Migrator that reads the goose migrations from a directory in a fs.FS, e.g. an embed.FS.
+func FS(fsys fs.FS, dir string) mysqlbox.Migrator {
+	return &migrator{fsys: fsys, dir: dir}
+}
+
+// Up applies all pending migrations to the database.
+func (m *migrator) Up(db *sql.DB, database string) error {
+	provider, err := m.provider(db)
+	if err != nil {
+		return err
+	}
+
+	_, err = provider.Up(context.Background())
+	return err
+}
+
+// MigrateTo migrates the database up or down to the specified version.
+func (m *migrator) MigrateTo(db *sql.DB, database string, version uint) error {
+	provider, err := m.provider(db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	current, err := provider.GetDBVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	target := int64(version)
+	if target < current {
+		_, err = provider.DownTo(ctx, target)
+	} else {
+		_, err = provider.UpTo(ctx, target)
+	}
+
+	return err
+}
+
+// Version returns the current migration version of the database. It returns 0 if no migrations are applied.
+func (m *migrator) Version(db *sql.DB, database string) (uint, error) {
+	provider, err := m.provider(db)
+	if err != nil {
+		return 0, err
+	}
+
+	version, err := provider.GetDBVersion(context.Background())
+	if err != nil {
+		return 0, err
+	}
+
+	return uint(version), nil
+}
+
+// provider returns a goose provider for the migrations of the migrator and the database.
+func (m *migrator) provider(db *sql.DB) (*goose.Provider, error) {
+	fsys, err := fs.Sub(m.fsys, m.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return goose.NewProvider(goose.DialectMySQL, db, fsys)
+}
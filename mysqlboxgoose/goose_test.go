@@ -0,0 +1,37 @@
+package mysqlboxgoose_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/virgild/mysqlbox"
+	"github.com/virgild/mysqlbox/mysqlboxgoose"
+)
+
+func TestDir(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		Migrations: mysqlboxgoose.Dir("testdata/migrations"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	require.Equal(t, uint(2), box.MustMigrationVersion())
+
+	box.MustMigrateTo(1)
+	require.Equal(t, uint(1), box.MustMigrationVersion())
+
+	box.MustMigrateTo(2)
+	require.Equal(t, uint(2), box.MustMigrationVersion())
+}
+
+func TestFS(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		Migrations: mysqlboxgoose.FS(os.DirFS("testdata"), "migrations"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	require.Equal(t, uint(2), box.MustMigrationVersion())
+}
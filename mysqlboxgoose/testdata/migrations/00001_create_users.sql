@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE TABLE users (id int NOT NULL PRIMARY KEY, email varchar(128) NOT NULL);
+
+-- +goose Down
+DROP TABLE users;
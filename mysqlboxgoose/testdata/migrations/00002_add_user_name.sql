@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN name varchar(128) NULL;
+CREATE INDEX users_name_index ON users (name);
+
+-- +goose Down
+DROP INDEX users_name_index ON users;
+ALTER TABLE users DROP COLUMN name;
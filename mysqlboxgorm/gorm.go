@@ -0,0 +1,35 @@
+// Package mysqlboxgorm returns GORM databases connected to a MySQLBox. It is a separate module so that mysqlbox
+// does not force the GORM dependency on its users.
+package mysqlboxgorm
+
+import (
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/virgild/mysqlbox"
+)
+
+// DB returns a *gorm.DB that uses the connection pool of the box DB (see mysqlbox.MySQLBox.DB()). The options are
+// passed to gorm.Open(). If no options are specified, the default GORM config is used.
+func DB(box *mysqlbox.MySQLBox, opts ...gorm.Option) (*gorm.DB, error) {
+	db, err := box.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(opts) == 0 {
+		opts = []gorm.Option{&gorm.Config{}}
+	}
+
+	return gorm.Open(mysql.New(mysql.Config{Conn: db}), opts...)
+}
+
+// MustDB returns a *gorm.DB that uses the connection pool of the box DB.
+func MustDB(box *mysqlbox.MySQLBox, opts ...gorm.Option) *gorm.DB {
+	db, err := DB(box, opts...)
+	if err != nil {
+		panic(err)
+	}
+
+	return db
+}
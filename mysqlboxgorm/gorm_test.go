@@ -0,0 +1,43 @@
+package mysqlboxgorm_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/virgild/mysqlbox"
+	"github.com/virgild/mysqlbox/mysqlboxgorm"
+)
+
+type User struct {
+	ID        string
+	Email     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func TestDB(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromFile("../testdata/schema.sql"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	db := mysqlboxgorm.MustDB(box, &gorm.Config{SkipDefaultTransaction: true})
+
+	err = db.Create(&User{ID: "U-GORM1", Email: "gorm1@example.com"}).Error
+	require.NoError(t, err)
+
+	var user User
+	err = db.First(&user, "id = ?", "U-GORM1").Error
+	require.NoError(t, err)
+	require.Equal(t, "gorm1@example.com", user.Email)
+	require.False(t, user.CreatedAt.IsZero())
+}
+
+func TestDBNilBox(t *testing.T) {
+	_, err := mysqlboxgorm.DB(nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,115 @@
+// Package mysqlboxmigrate runs golang-migrate migrations against the database of a MySQLBox. Use it with
+// mysqlbox.Config.Migrations:
+//
+//	box, err := mysqlbox.Start(&mysqlbox.Config{
+//		Migrations: mysqlboxmigrate.Source("file://migrations"),
+//	})
+//
+// It is a separate module so that mysqlbox does not force the golang-migrate dependency on its users.
+package mysqlboxmigrate
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/mysql"
+	_ "github.com/golang-migrate/migrate/v4/source/file" // Register the file:// source.
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+
+	"github.com/virgild/mysqlbox"
+)
+
+// migrator is a mysqlbox.Migrator that runs golang-migrate migrations from a source URL or a fs.FS.
+type migrator struct {
+	sourceURL string
+	fsys      fs.FS
+	path      string
+}
+
+// Source returns a mysqlbox.Migrator that reads the migrations from a golang-migrate source URL,
+// e.g. "file://migrations". Sources other than file:// must be registered by importing their golang-migrate package.
+func Source(sourceURL string) mysqlbox.Migrator {
+	return &migrator{sourceURL: sourceURL}
+}
+
+// FS returns a mysqlbox.Migrator that reads the migrations from a directory in a fs.FS, e.g. an embed.FS.
+func FS(fsys fs.FS, path string) mysqlbox.Migrator {
+	return &migrator{fsys: fsys, path: path}
+}
+
+// Up applies all pending migrations to the database.
+func (m *migrator) Up(db *sql.DB, database string) error {
+	mg, err := m.open(db, database)
+	if err != nil {
+		return err
+	}
+	defer mg.Close()
+
+	err = mg.Up()
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+
+	return err
+}
+
+// MigrateTo migrates the database up or down to the specified version.
+func (m *migrator) MigrateTo(db *sql.DB, database string, version uint) error {
+	mg, err := m.open(db, database)
+	if err != nil {
+		return err
+	}
+	defer mg.Close()
+
+	err = mg.Migrate(version)
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+
+	return err
+}
+
+// Version returns the current migration version of the database. It returns 0 if no migrations are applied.
+func (m *migrator) Version(db *sql.DB, database string) (uint, error) {
+	mg, err := m.open(db, database)
+	if err != nil {
+		return 0, err
+	}
+	defer mg.Close()
+
+	version, dirty, err := mg.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if dirty {
+		return version, fmt.Errorf("database is dirty at version %d", version)
+	}
+
+	return version, nil
+}
+
+// open returns a golang-migrate instance for the source of the migrator and the database.
+func (m *migrator) open(db *sql.DB, database string) (*migrate.Migrate, error) {
+	driver, err := mysql.WithInstance(db, &mysql.Config{DatabaseName: database})
+	if err != nil {
+		return nil, err
+	}
+
+	if m.fsys == nil {
+		return migrate.NewWithDatabaseInstance(m.sourceURL, "mysql", driver)
+	}
+
+	src, err := iofs.New(m.fsys, m.path)
+	if err != nil {
+		return nil, err
+	}
+
+	return migrate.NewWithInstance("iofs", src, "mysql", driver)
+}
@@ -0,0 +1,57 @@
+package mysqlboxmigrate_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/virgild/mysqlbox"
+	"github.com/virgild/mysqlbox/mysqlboxmigrate"
+)
+
+func columnExists(t *testing.T, box *mysqlbox.MySQLBox, column string) bool {
+	var count int
+	err := box.MustDB().QueryRow(`SELECT COUNT(*) FROM information_schema.columns
+		WHERE table_schema = DATABASE() AND table_name = 'users' AND column_name = ?`, column).Scan(&count)
+	require.NoError(t, err)
+
+	return count > 0
+}
+
+func TestSource(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		Migrations: mysqlboxmigrate.Source("file://testdata/migrations"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	require.True(t, columnExists(t, box, "name"))
+	require.Equal(t, uint(2), box.MustMigrationVersion())
+
+	t.Run("down", func(t *testing.T) {
+		box.MustMigrateTo(1)
+		require.True(t, columnExists(t, box, "email"))
+		require.False(t, columnExists(t, box, "name"))
+		require.Equal(t, uint(1), box.MustMigrationVersion())
+	})
+
+	t.Run("up", func(t *testing.T) {
+		box.MustMigrateTo(2)
+		require.True(t, columnExists(t, box, "name"))
+	})
+}
+
+func TestFS(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		Migrations: mysqlboxmigrate.FS(os.DirFS("testdata"), "migrations"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	require.True(t, columnExists(t, box, "name"))
+
+	err = box.Reset()
+	require.NoError(t, err)
+	require.True(t, columnExists(t, box, "name"))
+}
@@ -0,0 +1 @@
+CREATE TABLE users (id int NOT NULL PRIMARY KEY, email varchar(128) NOT NULL);
@@ -0,0 +1,2 @@
+DROP INDEX users_name_index ON users;
+ALTER TABLE users DROP COLUMN name;
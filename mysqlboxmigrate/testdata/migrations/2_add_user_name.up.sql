@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN name varchar(128) NULL;
+CREATE INDEX users_name_index ON users (name);
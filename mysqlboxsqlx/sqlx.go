@@ -0,0 +1,31 @@
+// Package mysqlboxsqlx returns sqlx databases connected to a MySQLBox. It is a separate module so that mysqlbox
+// does not force the sqlx dependency on its users.
+package mysqlboxsqlx
+
+import (
+	"github.com/jmoiron/sqlx"
+
+	"github.com/virgild/mysqlbox"
+)
+
+// DB returns a *sqlx.DB that uses the connection pool of the box DB (see mysqlbox.MySQLBox.DB()). Time columns are
+// scanned into time.Time values, and struct fields are mapped to columns with the "db" tag or their lowercased
+// names.
+func DB(box *mysqlbox.MySQLBox) (*sqlx.DB, error) {
+	db, err := box.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	return sqlx.NewDb(db, "mysql"), nil
+}
+
+// MustDB returns a *sqlx.DB that uses the connection pool of the box DB.
+func MustDB(box *mysqlbox.MySQLBox) *sqlx.DB {
+	db, err := DB(box)
+	if err != nil {
+		panic(err)
+	}
+
+	return db
+}
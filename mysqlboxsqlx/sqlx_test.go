@@ -0,0 +1,40 @@
+package mysqlboxsqlx_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/virgild/mysqlbox"
+	"github.com/virgild/mysqlbox/mysqlboxsqlx"
+)
+
+type category struct {
+	ID        string    `db:"id"`
+	Name      string    `db:"name"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func TestDB(t *testing.T) {
+	box, err := mysqlbox.Start(&mysqlbox.Config{
+		InitialSQL: mysqlbox.DataFromFile("../testdata/schema.sql"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(box.MustStop)
+
+	db := mysqlboxsqlx.MustDB(box)
+
+	var categories []category
+	err = db.Select(&categories, "SELECT * FROM categories ORDER BY name")
+	require.NoError(t, err)
+	require.Len(t, categories, 5)
+	require.Equal(t, "Alpha", categories[0].Name)
+	require.Equal(t, 2021, categories[0].CreatedAt.Year())
+}
+
+func TestDBNilBox(t *testing.T) {
+	_, err := mysqlboxsqlx.DB(nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,79 @@
+// Package mysqlboxtestfixtures loads go-testfixtures fixture directories into a MySQLBox. It is a separate module so
+// that mysqlbox does not force the go-testfixtures dependency on its users.
+package mysqlboxtestfixtures
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-testfixtures/testfixtures/v3"
+
+	"github.com/virgild/mysqlbox"
+)
+
+// Load loads the fixture files of a go-testfixtures directory into the Database of the box with the box DB (see
+// mysqlbox.MySQLBox.DB()). Like in go-testfixtures, the tables of the fixture files are emptied before the records
+// are loaded, so a file for a table in Config.DoNotCleanTables returns an error without loading anything. The box
+// Database is always a test database, so the go-testfixtures check of the database name is skipped. The options
+// are passed to testfixtures.New(), e.g. testfixtures.Template() to enable templates.
+func Load(box *mysqlbox.MySQLBox, dir string, options ...func(*testfixtures.Loader) error) error {
+	db, err := box.DB()
+	if err != nil {
+		return err
+	}
+
+	err = checkDoNotCleanTables(dir, box.DoNotCleanTables())
+	if err != nil {
+		return err
+	}
+
+	loader, err := testfixtures.New(append([]func(*testfixtures.Loader) error{
+		testfixtures.Database(db),
+		testfixtures.Dialect("mysql"),
+		testfixtures.DangerousSkipTestDatabaseCheck(),
+		testfixtures.Directory(dir),
+	}, options...)...)
+	if err != nil {
+		return err
+	}
+
+	return loader.Load()
+}
+
+// MustLoad loads the fixture files of a go-testfixtures directory into the Database of the box.
+func MustLoad(box *mysqlbox.MySQLBox, dir string, options ...func(*testfixtures.Loader) error) {
+	err := Load(box, dir, options...)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// checkDoNotCleanTables returns an error if the directory has a fixture file for one of the tables, since
+// go-testfixtures empties the tables of the fixture files.
+func checkDoNotCleanTables(dir string, tables []string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	excluded := map[string]bool{}
+	for _, table := range tables {
+		excluded[table] = true
+	}
+
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yml" && ext != ".yaml") {
+			continue
+		}
+
+		table := strings.TrimSuffix(entry.Name(), ext)
+		if excluded[table] {
+			return fmt.Errorf("fixture table %s is in DoNotCleanTables and cannot be emptied", table)
+		}
+	}
+
+	return nil
+}
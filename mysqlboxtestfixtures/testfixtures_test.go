@@ -0,0 +1,60 @@
+package mysqlboxtestfixtures_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/virgild/mysqlbox"
+	"github.com/virgild/mysqlbox/mysqlboxtestfixtures"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("replaces_rows", func(t *testing.T) {
+		box, err := mysqlbox.Start(&mysqlbox.Config{
+			InitialSQL: mysqlbox.DataFromFile("../testdata/schema.sql"),
+		})
+		require.NoError(t, err)
+		t.Cleanup(box.MustStop)
+
+		db := box.MustDB()
+		_, err = db.Exec("INSERT INTO users VALUES ('U-OLD', 'old@example.com', NOW(), NOW())")
+		require.NoError(t, err)
+
+		// Loading twice replaces the rows of the fixture tables.
+		mysqlboxtestfixtures.MustLoad(box, "../testdata/testfixtures")
+		mysqlboxtestfixtures.MustLoad(box, "../testdata/testfixtures")
+
+		var count int
+		err = db.QueryRow("SELECT COUNT(*) FROM users").Scan(&count)
+		require.NoError(t, err)
+		require.Equal(t, 2, count)
+
+		err = db.QueryRow("SELECT COUNT(*) FROM categories").Scan(&count)
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+	})
+
+	t.Run("do_not_clean_tables", func(t *testing.T) {
+		box, err := mysqlbox.Start(&mysqlbox.Config{
+			InitialSQL:       mysqlbox.DataFromFile("../testdata/schema.sql"),
+			DoNotCleanTables: []string{"categories"},
+		})
+		require.NoError(t, err)
+		t.Cleanup(box.MustStop)
+
+		err = mysqlboxtestfixtures.Load(box, "../testdata/testfixtures")
+		require.ErrorContains(t, err, "fixture table categories is in DoNotCleanTables")
+
+		// Nothing is loaded.
+		var count int
+		err = box.MustDB().QueryRow("SELECT COUNT(*) FROM users").Scan(&count)
+		require.NoError(t, err)
+		require.Equal(t, 0, count)
+	})
+}
+
+func TestLoadNilBox(t *testing.T) {
+	err := mysqlboxtestfixtures.Load(nil, "../testdata/testfixtures")
+	require.Error(t, err)
+}
@@ -0,0 +1,44 @@
+package mysqlbox
+
+import "errors"
+
+// StopAcceptingConnections puts the MySQL server in offline mode, like a server taken down for maintenance, without
+// stopping it. New connections are rejected, and existing connections are closed before their next statement, with
+// the error "The server is currently in offline mode". This can be used to test the draining and reconnection
+// behavior of the application. Call AcceptConnections() to leave offline mode.
+//
+// Users with the CONNECTION_ADMIN or SUPER privilege, like root and the box DBs, are not affected by offline mode. The
+// application under test should connect as a user created with CreateUser(). See also SetOfflineMode().
+func (b *MySQLBox) StopAcceptingConnections() error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	return b.SetOfflineMode(true)
+}
+
+// MustStopAcceptingConnections puts the MySQL server in offline mode.
+func (b *MySQLBox) MustStopAcceptingConnections() {
+	err := b.StopAcceptingConnections()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// AcceptConnections takes the MySQL server out of the offline mode started by StopAcceptingConnections(). Connections
+// that were closed in offline mode are not restored.
+func (b *MySQLBox) AcceptConnections() error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	return b.SetOfflineMode(false)
+}
+
+// MustAcceptConnections takes the MySQL server out of offline mode.
+func (b *MySQLBox) MustAcceptConnections() {
+	err := b.AcceptConnections()
+	if err != nil {
+		panic(err)
+	}
+}
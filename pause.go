@@ -0,0 +1,41 @@
+package mysqlbox
+
+import (
+	"context"
+	"errors"
+)
+
+// Pause freezes all processes of the MySQL container, so that the server accepts TCP connections but does not
+// respond. This can be used to test client timeouts, retries, and failover. Call Unpause() to resume the server.
+func (b *MySQLBox) Pause() error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	return b.cli.ContainerPause(context.Background(), b.containerID)
+}
+
+// MustPause freezes all processes of the MySQL container.
+func (b *MySQLBox) MustPause() {
+	err := b.Pause()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Unpause resumes the processes of the MySQL container frozen by Pause().
+func (b *MySQLBox) Unpause() error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	return b.cli.ContainerUnpause(context.Background(), b.containerID)
+}
+
+// MustUnpause resumes the processes of the MySQL container frozen by Pause().
+func (b *MySQLBox) MustUnpause() {
+	err := b.Unpause()
+	if err != nil {
+		panic(err)
+	}
+}
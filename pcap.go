@@ -0,0 +1,181 @@
+package mysqlbox
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+const (
+	// pcapLinkTypeRaw is the pcap link type of packets that start with an IP header.
+	pcapLinkTypeRaw = 101
+
+	// pcapServerPort is the TCP port of the server in a pcap file, which Wireshark decodes as MySQL traffic.
+	pcapServerPort = 3306
+
+	// maxSegmentData is the maximum data length of a TCP segment in a pcap file, so that the IP packet length fits
+	// in 16 bits.
+	maxSegmentData = 65535 - 40
+)
+
+// TCP flags.
+const (
+	tcpFIN = 0x01
+	tcpSYN = 0x02
+	tcpPSH = 0x08
+	tcpACK = 0x10
+)
+
+// pcapConn contains the next TCP sequence numbers of a connection in a pcap file, for the client and the server.
+type pcapConn struct {
+	next [2]uint32
+}
+
+// writePcap writes the captured events as a pcap file. The TCP handshake is written for connections opened while
+// capturing, and the FIN segments for connections closed while capturing.
+func writePcap(w io.Writer, events []captureEvent) error {
+	header := make([]byte, 24)
+	binary.LittleEndian.PutUint32(header[0:], 0xa1b2c3d4)
+	binary.LittleEndian.PutUint16(header[4:], 2)
+	binary.LittleEndian.PutUint16(header[6:], 4)
+	binary.LittleEndian.PutUint32(header[16:], 262144)
+	binary.LittleEndian.PutUint32(header[20:], pcapLinkTypeRaw)
+	_, err := w.Write(header)
+	if err != nil {
+		return err
+	}
+
+	conns := map[*captureConn]*pcapConn{}
+	for _, event := range events {
+		pc, ok := conns[event.conn]
+		if !ok {
+			pc = &pcapConn{next: [2]uint32{1, 1}}
+			conns[event.conn] = pc
+		}
+
+		var segments []pcapSegment
+		switch event.kind {
+		case captureOpen:
+			pc.next = [2]uint32{0, 0}
+			segments = []pcapSegment{
+				{fromClient: true, flags: tcpSYN},
+				{fromClient: false, flags: tcpSYN | tcpACK},
+				{fromClient: true, flags: tcpACK},
+			}
+		case captureData:
+			for data := event.data; len(data) > 0; {
+				n := len(data)
+				if n > maxSegmentData {
+					n = maxSegmentData
+				}
+				segments = append(segments, pcapSegment{
+					fromClient: event.fromClient,
+					flags:      tcpPSH | tcpACK,
+					data:       data[:n],
+				})
+				data = data[n:]
+			}
+		case captureClose:
+			segments = []pcapSegment{
+				{fromClient: true, flags: tcpFIN | tcpACK},
+				{fromClient: false, flags: tcpFIN | tcpACK},
+				{fromClient: true, flags: tcpACK},
+			}
+		}
+
+		for _, segment := range segments {
+			packet := pc.packet(event.conn.clientPort, segment)
+
+			record := make([]byte, 16)
+			binary.LittleEndian.PutUint32(record[0:], uint32(event.time.Unix()))
+			binary.LittleEndian.PutUint32(record[4:], uint32(event.time.Nanosecond()/1000))
+			binary.LittleEndian.PutUint32(record[8:], uint32(len(packet)))
+			binary.LittleEndian.PutUint32(record[12:], uint32(len(packet)))
+			_, err := w.Write(append(record, packet...))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// pcapSegment is a TCP segment written to a pcap file.
+type pcapSegment struct {
+	fromClient bool
+	flags      byte
+	data       []byte
+}
+
+// packet returns the IPv4 packet of a segment between the client port and the server port on the loopback address,
+// and advances the sequence number of the sender.
+func (pc *pcapConn) packet(clientPort int, segment pcapSegment) []byte {
+	src, dst := 0, 1
+	srcPort, dstPort := uint16(pcapServerPort), uint16(clientPort)
+	if segment.fromClient {
+		src, dst = 1, 0
+		srcPort, dstPort = dstPort, srcPort
+	}
+
+	packet := make([]byte, 40+len(segment.data))
+
+	// IPv4 header
+	ip := packet[:20]
+	ip[0] = 0x45
+	binary.BigEndian.PutUint16(ip[2:], uint16(len(packet)))
+	ip[8] = 64
+	ip[9] = 6
+	copy(ip[12:], []byte{127, 0, 0, 1})
+	copy(ip[16:], []byte{127, 0, 0, 1})
+	binary.BigEndian.PutUint16(ip[10:], checksum(ip, 0))
+
+	// TCP header
+	tcp := packet[20:]
+	binary.BigEndian.PutUint16(tcp[0:], srcPort)
+	binary.BigEndian.PutUint16(tcp[2:], dstPort)
+	binary.BigEndian.PutUint32(tcp[4:], pc.next[src])
+	if segment.flags&tcpACK != 0 {
+		binary.BigEndian.PutUint32(tcp[8:], pc.next[dst])
+	}
+	tcp[12] = 5 << 4
+	tcp[13] = segment.flags
+	binary.BigEndian.PutUint16(tcp[14:], 65535)
+	copy(tcp[20:], segment.data)
+
+	// The TCP checksum includes a pseudo-header with the addresses, protocol, and TCP length.
+	pseudo := make([]byte, 12)
+	copy(pseudo[0:], ip[12:20])
+	pseudo[9] = 6
+	binary.BigEndian.PutUint16(pseudo[10:], uint16(len(tcp)))
+	binary.BigEndian.PutUint16(tcp[16:], checksum(tcp, wordSum(pseudo)))
+
+	pc.next[src] += uint32(len(segment.data))
+	if segment.flags&(tcpSYN|tcpFIN) != 0 {
+		pc.next[src]++
+	}
+
+	return packet
+}
+
+// checksum returns the Internet checksum of the data, starting from an initial sum.
+func checksum(data []byte, initial uint32) uint16 {
+	s := initial + wordSum(data)
+	for s > 0xffff {
+		s = s>>16 + s&0xffff
+	}
+
+	return ^uint16(s)
+}
+
+// wordSum returns the sum of the 16-bit big-endian words of the data, without folding the carries.
+func wordSum(data []byte) uint32 {
+	var s uint32
+	for i := 0; i+1 < len(data); i += 2 {
+		s += uint32(data[i])<<8 | uint32(data[i+1])
+	}
+	if len(data)%2 == 1 {
+		s += uint32(data[len(data)-1]) << 8
+	}
+
+	return s
+}
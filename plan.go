@@ -0,0 +1,218 @@
+package mysqlbox
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// PlanQuery is a query whose plan is recorded in a plan baseline.
+type PlanQuery struct {
+	// Name identifies the query in the baseline file.
+	Name string
+
+	// Query is the SQL query. It is prefixed with EXPLAIN when the plan is read.
+	Query string
+
+	// Args are the query arguments.
+	Args []interface{}
+}
+
+// PlanStep is a row of the EXPLAIN output of a query.
+type PlanStep struct {
+	// Table is the table accessed by the step.
+	Table string `json:"table"`
+
+	// AccessType is the join type of the step, e.g. "ALL", "ref", or "const".
+	AccessType string `json:"type"`
+
+	// Key is the index chosen for the step. It is blank if no index is used.
+	Key string `json:"key"`
+}
+
+// String returns the step in the form "table:type:key".
+func (s PlanStep) String() string {
+	return fmt.Sprintf("%s:%s:%s", s.Table, s.AccessType, s.Key)
+}
+
+// PlanChange is a query whose plan differs from its baseline plan.
+type PlanChange struct {
+	// Query is the name of the query.
+	Query string
+
+	// Baseline is the plan recorded in the baseline file.
+	Baseline []PlanStep
+
+	// Current is the plan chosen by MySQL.
+	Current []PlanStep
+}
+
+// String describes the plan change.
+func (c PlanChange) String() string {
+	return fmt.Sprintf("plan of query %s changed from [%s] to [%s]", c.Query, planString(c.Baseline),
+		planString(c.Current))
+}
+
+// ExplainPlan returns the steps of the plan chosen by MySQL for the query.
+func (b *MySQLBox) ExplainPlan(query string, args ...interface{}) ([]PlanStep, error) {
+	if b == nil {
+		return nil, errors.New("mysqlbox is nil")
+	}
+
+	rows, err := b.db.Query("EXPLAIN "+query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var steps []PlanStep
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for n := range values {
+			ptrs[n] = &values[n]
+		}
+
+		err := rows.Scan(ptrs...)
+		if err != nil {
+			return nil, err
+		}
+
+		var step PlanStep
+		for n, column := range columns {
+			switch strings.ToLower(column) {
+			case "table":
+				step.Table = values[n].String
+			case "type":
+				step.AccessType = values[n].String
+			case "key":
+				step.Key = values[n].String
+			}
+		}
+		steps = append(steps, step)
+	}
+
+	return steps, rows.Err()
+}
+
+// ComparePlanBaseline compares the plans of the queries with the plans recorded in a baseline file and returns the
+// queries whose access type or chosen index changed. Plans of queries that are not in the baseline file yet are
+// recorded, creating the file if it does not exist. To record new baseline plans, delete the file.
+func (b *MySQLBox) ComparePlanBaseline(path string, queries ...PlanQuery) ([]PlanChange, error) {
+	if b == nil {
+		return nil, errors.New("mysqlbox is nil")
+	}
+
+	baseline, err := readPlanBaseline(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []PlanChange
+	recorded := false
+	for _, query := range queries {
+		current, err := b.ExplainPlan(query.Query, query.Args...)
+		if err != nil {
+			return nil, fmt.Errorf("explain query %s failed: %w", query.Name, err)
+		}
+
+		plan, ok := baseline[query.Name]
+		if !ok {
+			baseline[query.Name] = current
+			recorded = true
+			continue
+		}
+
+		if !equalPlans(plan, current) {
+			changes = append(changes, PlanChange{Query: query.Name, Baseline: plan, Current: current})
+		}
+	}
+
+	if recorded {
+		err := writePlanBaseline(path, baseline)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return changes, nil
+}
+
+// CheckPlanBaseline compares the plans of the queries with the plans recorded in a baseline file and fails the test
+// for each query whose access type or chosen index changed. See ComparePlanBaseline().
+func (b *MySQLBox) CheckPlanBaseline(t testing.TB, path string, queries ...PlanQuery) {
+	t.Helper()
+
+	changes, err := b.ComparePlanBaseline(path, queries...)
+	if err != nil {
+		t.Fatalf("error comparing plan baseline: %s", err.Error())
+	}
+
+	for _, change := range changes {
+		t.Error(change.String())
+	}
+}
+
+// readPlanBaseline reads the plans of a baseline file. It returns an empty baseline if the file does not exist.
+func readPlanBaseline(path string) (map[string][]PlanStep, error) {
+	baseline := map[string][]PlanStep{}
+
+	content, err := os.ReadFile(path) // #nosec G304
+	if errors.Is(err, os.ErrNotExist) {
+		return baseline, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(content, &baseline)
+	if err != nil {
+		return nil, fmt.Errorf("error reading plan baseline %s: %w", path, err)
+	}
+
+	return baseline, nil
+}
+
+// writePlanBaseline writes the plans to a baseline file.
+func writePlanBaseline(path string, baseline map[string][]PlanStep) error {
+	content, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(content, '\n'), 0644) // #nosec G306
+}
+
+// equalPlans reports whether two plans have the same steps.
+func equalPlans(a []PlanStep, b []PlanStep) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for n := range a {
+		if a[n] != b[n] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// planString returns the steps of a plan separated by commas.
+func planString(plan []PlanStep) string {
+	steps := make([]string, len(plan))
+	for n, step := range plan {
+		steps[n] = step.String()
+	}
+
+	return strings.Join(steps, ", ")
+}
@@ -0,0 +1,34 @@
+package mysqlbox
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanBaselineFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plans.json")
+
+	baseline, err := readPlanBaseline(path)
+	require.NoError(t, err)
+	require.Empty(t, baseline)
+
+	baseline["users_by_email"] = []PlanStep{{Table: "users", AccessType: "const", Key: "users_email_uindex"}}
+	err = writePlanBaseline(path, baseline)
+	require.NoError(t, err)
+
+	read, err := readPlanBaseline(path)
+	require.NoError(t, err)
+	require.Equal(t, baseline, read)
+}
+
+func TestEqualPlans(t *testing.T) {
+	plan := []PlanStep{{Table: "users", AccessType: "ref", Key: "users_name_index"}}
+	require.True(t, equalPlans(plan, []PlanStep{{Table: "users", AccessType: "ref", Key: "users_name_index"}}))
+	require.False(t, equalPlans(plan, []PlanStep{{Table: "users", AccessType: "ALL"}}))
+	require.False(t, equalPlans(plan, nil))
+
+	change := PlanChange{Query: "q", Baseline: plan, Current: []PlanStep{{Table: "users", AccessType: "ALL"}}}
+	require.Equal(t, "plan of query q changed from [users:ref:users_name_index] to [users:ALL:]", change.String())
+}
@@ -0,0 +1,181 @@
+package mysqlbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultPollInitialInterval, defaultPollMaxInterval, defaultPollMultiplier, and defaultPollJitter are the
+	// backoff settings of a Poller that does not set them.
+	defaultPollInitialInterval = 100 * time.Millisecond
+	defaultPollMaxInterval     = time.Second
+	defaultPollMultiplier      = 1.5
+	defaultPollJitter          = 0.2
+
+	// defaultPollKeepErrors is the number of errors kept by a Poller that does not set KeepErrors.
+	defaultPollKeepErrors = 5
+)
+
+// Poller calls a function until it succeeds, waiting between the attempts with a jittered exponential backoff. The
+// zero value uses the default settings, which are also used to wait until the MySQL server of a box is ready:
+//
+//	err := mysqlbox.Poller{}.Poll(ctx, db.PingContext)
+type Poller struct {
+	// InitialInterval is the time to wait after the first failed attempt. The default is 100ms.
+	InitialInterval time.Duration
+
+	// MaxInterval is the maximum time to wait between attempts. The default is 1s.
+	MaxInterval time.Duration
+
+	// Multiplier is the factor the interval is multiplied by after each failed attempt. The default is 1.5, which
+	// is also used for values less than 1.
+	Multiplier float64
+
+	// Jitter is the fraction of the interval that is randomly added or subtracted, so that many boxes started at
+	// the same time do not poll in lockstep. The default is 0.2. A negative value disables the jitter.
+	Jitter float64
+
+	// KeepErrors is the number of errors of the last attempts returned in a PollError. The default is 5.
+	KeepErrors int
+}
+
+// PollError is returned by Poller.Poll() when the context is done before the function succeeds. It unwraps to the
+// context error, so errors.Is(err, context.DeadlineExceeded) reports whether the poll timed out.
+type PollError struct {
+	// Attempts is the number of times the function was called.
+	Attempts int
+
+	// Errors contains the errors of the last attempts, oldest first.
+	Errors []error
+
+	// Err is the error of the context.
+	Err error
+}
+
+// Error returns the context error with the errors of the last attempts.
+func (e *PollError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("gave up after %d attempts: %s", e.Attempts, e.Err.Error())
+	}
+
+	return fmt.Sprintf("gave up after %d attempts: %s: last errors: %s", e.Attempts, e.Err.Error(),
+		e.LastErrors())
+}
+
+// LastErrors returns the errors of the last attempts separated by semicolons. Repeated errors are shown once with
+// their count.
+func (e *PollError) LastErrors() string {
+	var messages []string
+	var counts []int
+	for _, err := range e.Errors {
+		message := err.Error()
+		if len(messages) > 0 && messages[len(messages)-1] == message {
+			counts[len(counts)-1]++
+			continue
+		}
+
+		messages = append(messages, message)
+		counts = append(counts, 1)
+	}
+
+	for n, count := range counts {
+		if count > 1 {
+			messages[n] = fmt.Sprintf("%s (%d times)", messages[n], count)
+		}
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap returns the error of the context.
+func (e *PollError) Unwrap() error {
+	return e.Err
+}
+
+// Poll calls fn until it returns nil. If the context is done first, a *PollError with the errors of the last attempts
+// is returned.
+func (p Poller) Poll(ctx context.Context, fn func(ctx context.Context) error) error {
+	p.loadDefaults()
+
+	var errs []error
+	interval := p.InitialInterval
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+
+		// The error of an attempt interrupted by the context is not kept, since it only repeats the context error.
+		if ctx.Err() != nil {
+			return &PollError{Attempts: attempt, Errors: errs, Err: ctx.Err()}
+		}
+
+		errs = append(errs, err)
+		if len(errs) > p.KeepErrors {
+			errs = errs[len(errs)-p.KeepErrors:]
+		}
+
+		select {
+		case <-ctx.Done():
+			return &PollError{Attempts: attempt, Errors: errs, Err: ctx.Err()}
+		case <-time.After(p.jitter(interval)):
+		}
+
+		interval = time.Duration(float64(interval) * p.Multiplier)
+		if interval > p.MaxInterval {
+			interval = p.MaxInterval
+		}
+	}
+}
+
+// loadDefaults sets the settings that are not set to their defaults.
+func (p *Poller) loadDefaults() {
+	if p.InitialInterval <= 0 {
+		p.InitialInterval = defaultPollInitialInterval
+	}
+
+	if p.MaxInterval <= 0 {
+		p.MaxInterval = defaultPollMaxInterval
+	}
+
+	if p.MaxInterval < p.InitialInterval {
+		p.MaxInterval = p.InitialInterval
+	}
+
+	if p.Multiplier < 1 {
+		p.Multiplier = defaultPollMultiplier
+	}
+
+	if p.Jitter == 0 {
+		p.Jitter = defaultPollJitter
+	}
+
+	if p.KeepErrors <= 0 {
+		p.KeepErrors = defaultPollKeepErrors
+	}
+}
+
+// jitter returns the interval with a random fraction of up to Jitter added or subtracted.
+func (p Poller) jitter(interval time.Duration) time.Duration {
+	if p.Jitter <= 0 {
+		return interval
+	}
+
+	return time.Duration(float64(interval) * (1 + p.Jitter*(2*rand.Float64()-1))) // #nosec G404
+}
+
+// withLastErrors adds the errors of the last attempts of a *PollError in err to a timeout error, so that a readiness
+// timeout says why the server could not be reached.
+func withLastErrors(timeoutErr error, err error) error {
+	var pollErr *PollError
+	if errors.As(err, &pollErr) && len(pollErr.Errors) > 0 {
+		return fmt.Errorf("%w: last errors: %s", timeoutErr, pollErr.LastErrors())
+	}
+
+	return timeoutErr
+}
@@ -0,0 +1,103 @@
+package mysqlbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoller(t *testing.T) {
+	fast := Poller{InitialInterval: time.Millisecond, MaxInterval: 5 * time.Millisecond, KeepErrors: 3}
+
+	t.Run("success", func(t *testing.T) {
+		attempts := 0
+		err := fast.Poll(context.Background(), func(context.Context) error {
+			attempts++
+			if attempts < 4 {
+				return errors.New("not yet")
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 4, attempts)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		attempts := 0
+		err := fast.Poll(ctx, func(context.Context) error {
+			attempts++
+			return fmt.Errorf("attempt %d failed", attempts)
+		})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		var pollErr *PollError
+		require.ErrorAs(t, err, &pollErr)
+		require.Equal(t, attempts, pollErr.Attempts)
+		require.Len(t, pollErr.Errors, 3)
+		require.EqualError(t, pollErr.Errors[2], fmt.Sprintf("attempt %d failed", attempts))
+		require.Contains(t, err.Error(), "gave up after")
+	})
+
+	t.Run("backoff", func(t *testing.T) {
+		p := Poller{InitialInterval: 10 * time.Millisecond, MaxInterval: 25 * time.Millisecond, Multiplier: 2,
+			Jitter: -1}
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		var times []time.Time
+		_ = p.Poll(ctx, func(context.Context) error {
+			times = append(times, time.Now())
+			return errors.New("down")
+		})
+
+		// The intervals are 10ms, 20ms, 25ms, 25ms...
+		require.GreaterOrEqual(t, len(times), 4)
+		require.GreaterOrEqual(t, times[1].Sub(times[0]), 10*time.Millisecond)
+		require.GreaterOrEqual(t, times[2].Sub(times[1]), 20*time.Millisecond)
+		require.GreaterOrEqual(t, times[3].Sub(times[2]), 25*time.Millisecond)
+	})
+
+	t.Run("jitter", func(t *testing.T) {
+		p := Poller{Jitter: 0.5}
+		for n := 0; n < 100; n++ {
+			interval := p.jitter(100 * time.Millisecond)
+			require.GreaterOrEqual(t, interval, 50*time.Millisecond)
+			require.LessOrEqual(t, interval, 150*time.Millisecond)
+		}
+	})
+}
+
+func TestPollErrorLastErrors(t *testing.T) {
+	err := &PollError{
+		Attempts: 4,
+		Errors: []error{
+			errors.New("connection refused"),
+			errors.New("connection refused"),
+			errors.New("access denied"),
+		},
+		Err: context.DeadlineExceeded,
+	}
+
+	require.Equal(t, "connection refused (2 times); access denied", err.LastErrors())
+	require.EqualError(t, err,
+		"gave up after 4 attempts: context deadline exceeded: last errors: connection refused (2 times); access denied")
+}
+
+func TestWaitForDBLastErrors(t *testing.T) {
+	b := &MySQLBox{waitFor: WaitStrategyFunc(func(ctx context.Context, b *MySQLBox) error {
+		return Poller{InitialInterval: time.Millisecond}.Poll(ctx, func(context.Context) error {
+			return errors.New("connection refused")
+		})
+	})}
+
+	err := b.waitForDB(50*time.Millisecond, nil)
+	require.ErrorIs(t, err, ErrTimeout)
+	require.Contains(t, err.Error(), "last errors: connection refused")
+}
@@ -0,0 +1,68 @@
+package mysqlbox
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+)
+
+// FlushPool closes the idle connections of the box's DB and of the DBs returned by ConnectDB(), so that subsequent
+// statements run on new connections. This is useful after changing global variables or users, which are only picked
+// up by new sessions. Only idle connections are recycled: connections that are in use (e.g. by an open transaction or
+// a sql.Conn) keep their session and are returned to the pool when they are released. The pool settings of the DBs,
+// like SetMaxIdleConns(), are not changed.
+func (b *MySQLBox) FlushPool() error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	ctx := context.Background()
+	for _, db := range b.pooledDBs() {
+		err := closeIdleConns(ctx, db)
+		if err != nil {
+			return fmt.Errorf("error closing idle connections: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// MustFlushPool closes the idle connections of the box's DB and of the DBs returned by ConnectDB().
+func (b *MySQLBox) MustFlushPool() {
+	err := b.FlushPool()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// closeIdleConns closes the idle connections of the DB. Each idle connection is taken from the pool and released as a
+// bad connection, which database/sql closes instead of returning it to the pool.
+func closeIdleConns(ctx context.Context, db *sql.DB) error {
+	for n := db.Stats().Idle; n > 0; n-- {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			return err
+		}
+
+		_ = conn.Raw(func(interface{}) error {
+			return driver.ErrBadConn
+		})
+		_ = conn.Close()
+	}
+
+	return nil
+}
+
+// pooledDBs returns the box's DB and the DBs returned by ConnectDB().
+func (b *MySQLBox) pooledDBs() []*sql.DB {
+	b.derivedDBsMu.Lock()
+	defer b.derivedDBsMu.Unlock()
+
+	dbs := make([]*sql.DB, 0, len(b.derivedDBs)+1)
+	dbs = append(dbs, b.db)
+	dbs = append(dbs, b.derivedDBs...)
+
+	return dbs
+}
@@ -0,0 +1,81 @@
+package mysqlbox
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// countingConnector is a driver.Connector of connections that do nothing, which counts the open connections.
+type countingConnector struct {
+	opened int32
+	closed int32
+}
+
+func (c *countingConnector) Connect(context.Context) (driver.Conn, error) {
+	atomic.AddInt32(&c.opened, 1)
+	return &countingConn{connector: c}, nil
+}
+
+func (c *countingConnector) Driver() driver.Driver {
+	return nil
+}
+
+type countingConn struct {
+	connector *countingConnector
+}
+
+func (c *countingConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *countingConn) Close() error {
+	atomic.AddInt32(&c.connector.closed, 1)
+	return nil
+}
+
+func (c *countingConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestFlushPoolIdleConns(t *testing.T) {
+	ctx := context.Background()
+	connector := &countingConnector{}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	db.SetMaxIdleConns(5)
+
+	// openConns opens n connections at the same time and returns them to the pool.
+	openConns := func(n int) {
+		conns := make([]*sql.Conn, n)
+		for i := range conns {
+			conn, err := db.Conn(ctx)
+			require.NoError(t, err)
+			conns[i] = conn
+		}
+		for _, conn := range conns {
+			require.NoError(t, conn.Close())
+		}
+	}
+
+	openConns(3)
+	inUse, err := db.Conn(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, db.Stats().Idle)
+
+	b := &MySQLBox{db: db}
+	require.NoError(t, b.FlushPool())
+	require.Equal(t, 0, db.Stats().Idle)
+	require.EqualValues(t, 2, atomic.LoadInt32(&connector.closed))
+
+	// The connection in use is returned to the pool, and the idle limit is kept.
+	require.NoError(t, inUse.Close())
+	openConns(4)
+	require.Equal(t, 4, db.Stats().Idle)
+	require.EqualValues(t, 2, atomic.LoadInt32(&connector.closed))
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package mysqlbox
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processRunning returns true if a process with the given ID exists.
+func processRunning(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package mysqlbox
+
+import (
+	"os"
+)
+
+// processRunning returns true if a process with the given ID exists.
+func processRunning(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = p.Release()
+
+	return true
+}
@@ -0,0 +1,293 @@
+package mysqlbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+// profileBatchSize is the number of rows inserted per statement when a dataset profile is loaded.
+const profileBatchSize = 500
+
+// datasetProfile is a standardized dataset that can be loaded with LoadProfile().
+type datasetProfile struct {
+	// tables contains the CREATE TABLE statements of the profile.
+	tables []string
+
+	// load inserts the rows of the profile for the scale.
+	load func(ctx context.Context, tx *sql.Tx, scale int, rnd *rand.Rand) error
+}
+
+// profiles contains the dataset profiles by name.
+var profiles = map[string]datasetProfile{
+	"sbtest": {
+		tables: []string{
+			`CREATE TABLE sbtest1 (
+				id int NOT NULL AUTO_INCREMENT,
+				k int NOT NULL DEFAULT 0,
+				c char(120) NOT NULL DEFAULT '',
+				pad char(60) NOT NULL DEFAULT '',
+				PRIMARY KEY (id),
+				KEY k_1 (k)
+			) ENGINE = InnoDB`,
+		},
+		load: loadSbtest,
+	},
+	"tpcc": {
+		tables: []string{
+			`CREATE TABLE warehouse (
+				w_id int NOT NULL,
+				w_name varchar(10) NOT NULL,
+				w_tax decimal(4,4) NOT NULL,
+				w_ytd decimal(12,2) NOT NULL,
+				PRIMARY KEY (w_id)
+			) ENGINE = InnoDB`,
+			`CREATE TABLE district (
+				d_id tinyint NOT NULL,
+				d_w_id int NOT NULL,
+				d_name varchar(10) NOT NULL,
+				d_tax decimal(4,4) NOT NULL,
+				d_ytd decimal(12,2) NOT NULL,
+				d_next_o_id int NOT NULL,
+				PRIMARY KEY (d_w_id, d_id)
+			) ENGINE = InnoDB`,
+			`CREATE TABLE customer (
+				c_id int NOT NULL,
+				c_d_id tinyint NOT NULL,
+				c_w_id int NOT NULL,
+				c_first varchar(16) NOT NULL,
+				c_last varchar(16) NOT NULL,
+				c_balance decimal(12,2) NOT NULL,
+				c_since datetime NOT NULL,
+				PRIMARY KEY (c_w_id, c_d_id, c_id),
+				KEY idx_customer (c_w_id, c_d_id, c_last, c_first)
+			) ENGINE = InnoDB`,
+			`CREATE TABLE item (
+				i_id int NOT NULL,
+				i_name varchar(24) NOT NULL,
+				i_price decimal(5,2) NOT NULL,
+				i_data varchar(50) NOT NULL,
+				PRIMARY KEY (i_id)
+			) ENGINE = InnoDB`,
+			`CREATE TABLE stock (
+				s_i_id int NOT NULL,
+				s_w_id int NOT NULL,
+				s_quantity smallint NOT NULL,
+				s_ytd decimal(8,0) NOT NULL,
+				s_order_cnt smallint NOT NULL,
+				PRIMARY KEY (s_w_id, s_i_id)
+			) ENGINE = InnoDB`,
+		},
+		load: loadTPCC,
+	},
+}
+
+// Profiles returns the names of the dataset profiles that can be loaded with LoadProfile().
+func Profiles() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// LoadProfile creates the tables of a standardized dataset profile in the Database and loads its rows, so that
+// performance tests can use the same data. The rows are generated deterministically, so they are the same for
+// every load with the same scale. The following profiles are available:
+//
+//   - "sbtest": the sysbench sbtest1 table with 1000 × scale rows.
+//   - "tpcc": a small subset of the TPC-C tables (warehouse, district, customer, item, and stock) with scale
+//     warehouses. Each warehouse has 10 districts with 30 customers each and stock for 1000 items.
+func (b *MySQLBox) LoadProfile(name string, scale int) error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	profile, ok := profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown dataset profile %s", name)
+	}
+
+	if scale < 1 {
+		return errors.New("scale must be at least 1")
+	}
+
+	ctx := context.Background()
+	for _, ddl := range profile.tables {
+		_, err := b.db.ExecContext(ctx, ddl)
+		if err != nil {
+			return fmt.Errorf("error creating profile table: %w", err)
+		}
+	}
+
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	rnd := rand.New(rand.NewSource(int64(scale))) // #nosec G404
+	err = profile.load(ctx, tx, scale, rnd)
+	if err != nil {
+		return fmt.Errorf("error loading profile %s: %w", name, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	b.addSeedSources(SeedSource{Kind: "profile", Name: fmt.Sprintf("%s (scale %d)", name, scale)})
+
+	return nil
+}
+
+// MustLoadProfile creates the tables of a standardized dataset profile in the Database and loads its rows.
+func (b *MySQLBox) MustLoadProfile(name string, scale int) {
+	err := b.LoadProfile(name, scale)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// loadSbtest inserts the rows of the sbtest profile.
+func loadSbtest(ctx context.Context, tx *sql.Tx, scale int, rnd *rand.Rand) error {
+	size := 1000 * scale
+	rows := make([][]interface{}, size)
+	for n := range rows {
+		rows[n] = []interface{}{rnd.Intn(size) + 1, digitGroups(rnd, 10), digitGroups(rnd, 5)}
+	}
+
+	return insertBatches(ctx, tx, "sbtest1", []string{"k", "c", "pad"}, rows)
+}
+
+// loadTPCC inserts the rows of the tpcc profile.
+func loadTPCC(ctx context.Context, tx *sql.Tx, scale int, rnd *rand.Rand) error {
+	const (
+		districts = 10
+		customers = 30
+		items     = 1000
+	)
+
+	since := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var itemRows [][]interface{}
+	for i := 1; i <= items; i++ {
+		itemRows = append(itemRows, []interface{}{i, fmt.Sprintf("item-%d", i), float64(rnd.Intn(9901)+100) / 100,
+			randLetters(rnd, 26, 50)})
+	}
+
+	var warehouseRows, districtRows, customerRows, stockRows [][]interface{}
+	for w := 1; w <= scale; w++ {
+		warehouseRows = append(warehouseRows, []interface{}{w, fmt.Sprintf("w-%d", w),
+			float64(rnd.Intn(2001)) / 10000, 300000})
+
+		for d := 1; d <= districts; d++ {
+			districtRows = append(districtRows, []interface{}{d, w, fmt.Sprintf("d-%d-%d", w, d),
+				float64(rnd.Intn(2001)) / 10000, 30000, customers + 1})
+
+			for c := 1; c <= customers; c++ {
+				customerRows = append(customerRows, []interface{}{c, d, w, randLetters(rnd, 8, 16),
+					tpccLastName(c - 1), -10, since})
+			}
+		}
+
+		for i := 1; i <= items; i++ {
+			stockRows = append(stockRows, []interface{}{i, w, rnd.Intn(91) + 10, 0, 0})
+		}
+	}
+
+	inserts := []struct {
+		table   string
+		columns []string
+		rows    [][]interface{}
+	}{
+		{"item", []string{"i_id", "i_name", "i_price", "i_data"}, itemRows},
+		{"warehouse", []string{"w_id", "w_name", "w_tax", "w_ytd"}, warehouseRows},
+		{"district", []string{"d_id", "d_w_id", "d_name", "d_tax", "d_ytd", "d_next_o_id"}, districtRows},
+		{"customer", []string{"c_id", "c_d_id", "c_w_id", "c_first", "c_last", "c_balance", "c_since"},
+			customerRows},
+		{"stock", []string{"s_i_id", "s_w_id", "s_quantity", "s_ytd", "s_order_cnt"}, stockRows},
+	}
+
+	for _, insert := range inserts {
+		err := insertBatches(ctx, tx, insert.table, insert.columns, insert.rows)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// insertBatches inserts rows into a table with multi-row INSERT statements of up to profileBatchSize rows.
+func insertBatches(ctx context.Context, tx *sql.Tx, table string, columns []string, rows [][]interface{}) error {
+	quoted := make([]string, len(columns))
+	for n, column := range columns {
+		quoted[n] = quoteIdent(column)
+	}
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+
+	for start := 0; start < len(rows); start += profileBatchSize {
+		end := start + profileBatchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		values := make([]string, 0, end-start)
+		args := make([]interface{}, 0, (end-start)*len(columns))
+		for _, row := range rows[start:end] {
+			values = append(values, placeholders)
+			args = append(args, row...)
+		}
+
+		query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", quoteIdent(table), strings.Join(quoted, ", "),
+			strings.Join(values, ", "))
+		_, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("error inserting into %s: %w", table, err)
+		}
+	}
+
+	return nil
+}
+
+// digitGroups returns groups of 11 random digits separated by dashes, like the sysbench c and pad columns.
+func digitGroups(rnd *rand.Rand, groups int) string {
+	var sb strings.Builder
+	for g := 0; g < groups; g++ {
+		if g > 0 {
+			sb.WriteByte('-')
+		}
+		for n := 0; n < 11; n++ {
+			sb.WriteByte(byte('0' + rnd.Intn(10)))
+		}
+	}
+
+	return sb.String()
+}
+
+// randLetters returns a random string of lowercase letters with a length between min and max.
+func randLetters(rnd *rand.Rand, minLen int, maxLen int) string {
+	b := make([]byte, minLen+rnd.Intn(maxLen-minLen+1))
+	for n := range b {
+		b[n] = byte('a' + rnd.Intn(26))
+	}
+
+	return string(b)
+}
+
+// tpccLastName returns the TPC-C customer last name for a number between 0 and 999.
+func tpccLastName(num int) string {
+	syllables := []string{"BAR", "OUGHT", "ABLE", "PRI", "PRES", "ESE", "ANTI", "CALLY", "ATION", "EING"}
+	return syllables[num/100%10] + syllables[num/10%10] + syllables[num%10]
+}
@@ -0,0 +1,24 @@
+package mysqlbox
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfiles(t *testing.T) {
+	require.Equal(t, []string{"sbtest", "tpcc"}, Profiles())
+}
+
+func TestDigitGroups(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	require.Len(t, digitGroups(rnd, 10), 119)
+	require.Regexp(t, `^\d{11}-\d{11}-\d{11}-\d{11}-\d{11}$`, digitGroups(rnd, 5))
+}
+
+func TestTPCCLastName(t *testing.T) {
+	require.Equal(t, "BARBARBAR", tpccLastName(0))
+	require.Equal(t, "OUGHTABLEPRI", tpccLastName(123))
+	require.Equal(t, "EINGEINGEING", tpccLastName(999))
+}
@@ -0,0 +1,213 @@
+package mysqlbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/jsonmessage"
+)
+
+const (
+	// defaultPullRetries is the number of retries of a failed image pull when Config.PullRetries is not set.
+	defaultPullRetries = 3
+
+	// pullRetryBackoff is the time to wait before the first retry of a failed image pull. It is doubled for each
+	// retry, up to maxPullRetryBackoff.
+	pullRetryBackoff    = time.Second
+	maxPullRetryBackoff = 30 * time.Second
+)
+
+// transientPullErrors contains parts of the error messages of registry errors that are worth retrying.
+var transientPullErrors = []string{
+	"toomanyrequests",
+	"too many requests",
+	"429",
+	"502 bad gateway",
+	"503 service unavailable",
+	"504 gateway timeout",
+	"connection reset",
+	"connection refused",
+	"tls handshake timeout",
+	"i/o timeout",
+	"unexpected eof",
+	"temporary failure",
+}
+
+// pullConfig contains the settings of Docker image pulls.
+type pullConfig struct {
+	// logger receives the messages about pulls.
+	logger Logger
+
+	// output receives the pull progress of each layer.
+	output io.Writer
+
+	// retries is the number of retries after transient errors.
+	retries int
+}
+
+// PullImage pulls a Docker image if it is not available locally. It can be called in TestMain to pull the image once
+// before the tests of parallel packages start boxes at the same time. If image is blank, it defaults to "mysql:8". The
+// pull progress is printed to stderr, and transient registry errors are retried like with the default
+// Config.PullRetries.
+func PullImage(ctx context.Context, image string) error {
+	if image == "" {
+		image = defaultMySQLImage
+	}
+
+	cli, err := newDockerClient()
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	_, _, err = cli.ImageInspectWithRaw(ctx, image)
+	if err == nil {
+		return nil
+	}
+	if !client.IsErrNotFound(err) {
+		return err
+	}
+
+	logger := stderrLogger{}
+	err = pullImage(ctx, cli, image, pullConfig{
+		logger:  logger,
+		output:  defaultPullOutput(logger),
+		retries: defaultPullRetries,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to pull image: %w", err)
+	}
+
+	return nil
+}
+
+// MustPullImage pulls a Docker image if it is not available locally.
+func MustPullImage(ctx context.Context, image string) {
+	err := PullImage(ctx, image)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// pullConfig returns the image pull settings of the config.
+func (c *Config) pullConfig() pullConfig {
+	return pullConfig{
+		logger:  c.Logger,
+		output:  c.PullOutput,
+		retries: c.PullRetries,
+	}
+}
+
+// dockerRetry returns the settings of retrying container creates and starts of the config.
+func (c *Config) dockerRetry() dockerRetry {
+	return dockerRetry{
+		logger:  c.Logger,
+		retries: c.DockerRetries,
+	}
+}
+
+// pullImage pulls a Docker image, logs when it starts and ends, and writes the progress of each layer to the pull
+// output. Transient registry errors are retried with exponential backoff.
+func pullImage(ctx context.Context, cli *client.Client, image string, pull pullConfig) error {
+	backoff := pullRetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := pullImageOnce(ctx, cli, image, pull)
+		if err == nil || attempt >= pull.retries || !isTransientPullError(err) || ctx.Err() != nil {
+			return err
+		}
+
+		pull.logger.Printf("pulling Docker image %s failed, retrying in %s: %s", image, backoff, err.Error())
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > maxPullRetryBackoff {
+			backoff = maxPullRetryBackoff
+		}
+	}
+}
+
+// pullImageOnce pulls a Docker image without retrying.
+func pullImageOnce(ctx context.Context, cli *client.Client, image string, pull pullConfig) error {
+	if image == "" {
+		return errors.New("image is blank")
+	}
+
+	pull.logger.Printf("pulling Docker image %s...", image)
+	started := time.Now()
+	reader, err := cli.ImagePull(ctx, image, types.ImagePullOptions{})
+	if err != nil {
+		return fmt.Errorf("docker image pull error: %w", err)
+	}
+	defer reader.Close()
+
+	output := pull.output
+	if output == nil {
+		output = io.Discard
+	}
+
+	err = jsonmessage.DisplayJSONMessagesStream(reader, output, 0, false, nil)
+	if err != nil {
+		return fmt.Errorf("docker image pull stream error: %w", err)
+	}
+	pull.logger.Printf("Docker image %s pulled.", image)
+	recordPull(time.Since(started))
+
+	return nil
+}
+
+// isTransientPullError reports whether an image pull error is likely to go away when the pull is retried, e.g.
+// because the registry rate limited the pull or the connection was reset. Errors like a missing image or denied
+// access are not transient.
+func isTransientPullError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errdefs.IsNotFound(err) || errdefs.IsUnauthorized(err) || errdefs.IsForbidden(err) ||
+		errdefs.IsInvalidParameter(err) {
+		return false
+	}
+
+	if errdefs.IsUnavailable(err) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	message := strings.ToLower(err.Error())
+	for _, part := range transientPullErrors {
+		if strings.Contains(message, part) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// defaultPullOutput returns the writer of the image pull progress when Config.PullOutput is not set. The progress is
+// only written to stderr with the default logger.
+func defaultPullOutput(logger Logger) io.Writer {
+	if _, ok := logger.(stderrLogger); ok {
+		return os.Stderr
+	}
+
+	return io.Discard
+}
@@ -0,0 +1,75 @@
+package mysqlbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsTransientPullError(t *testing.T) {
+	tests := []struct {
+		err       error
+		transient bool
+	}{
+		{errors.New("toomanyrequests: You have reached your pull rate limit"), true},
+		{errors.New("read tcp 10.0.0.1:1234->10.0.0.2:443: read: connection reset by peer"), true},
+		{fmt.Errorf("docker image pull stream error: %w", io.ErrUnexpectedEOF), true},
+		{errdefs.Unavailable(errors.New("registry unavailable")), true},
+		{errdefs.NotFound(errors.New("manifest for mysql:404 not found")), false},
+		{errdefs.Unauthorized(errors.New("pull access denied")), false},
+		{errors.New("invalid reference format"), false},
+		{context.DeadlineExceeded, false},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.transient, isTransientPullError(test.err), test.err.Error())
+	}
+}
+
+func TestPullImageRetry(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/images/create") {
+			http.NotFound(w, r)
+			return
+		}
+
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"message":"toomanyrequests: rate limit exceeded"}`))
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"status":"Pull complete"}` + "\n"))
+	}))
+	defer srv.Close()
+
+	cli, err := client.NewClientWithOpts(client.WithHost("tcp://"+strings.TrimPrefix(srv.URL, "http://")),
+		client.WithVersion("1.42"))
+	require.NoError(t, err)
+
+	var messages []string
+	logger := LoggerFunc(func(format string, v ...interface{}) {
+		messages = append(messages, fmt.Sprintf(format, v...))
+	})
+
+	err = pullImage(context.Background(), cli, "mysql:8", pullConfig{logger: logger, retries: 1})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, atomic.LoadInt32(&requests))
+	require.Contains(t, messages[1], "retrying in 1s")
+
+	atomic.StoreInt32(&requests, 0)
+	err = pullImage(context.Background(), cli, "mysql:8", pullConfig{logger: DiscardLogger})
+	require.Error(t, err)
+	require.EqualValues(t, 1, atomic.LoadInt32(&requests))
+}
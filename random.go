@@ -2,6 +2,8 @@ package mysqlbox
 
 import (
 	"math/rand"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -12,8 +14,18 @@ func init() {
 var charset = []rune("abcdefghijklmnopqrstuvwxyz0123456789")
 var charsetLen = len(charset)
 
+// timestampLen is the length of the timestamp prefix of IDs returned by randomID.
+const timestampLen = 9
+
+// randomID returns an ID that starts with the current time in milliseconds (base 36) followed by random characters.
+// IDs created at different times sort by their creation time.
 func randomID() string {
-	return randStr(5)
+	ts := strconv.FormatInt(time.Now().UnixMilli(), 36)
+	if len(ts) < timestampLen {
+		ts = strings.Repeat("0", timestampLen-len(ts)) + ts
+	}
+
+	return ts + randStr(5)
 }
 
 func randStr(length int) string {
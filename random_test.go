@@ -2,6 +2,7 @@ package mysqlbox
 
 import (
 	"testing"
+	"time"
 )
 
 func TestRandomID(t *testing.T) {
@@ -15,3 +16,30 @@ func TestRandomID(t *testing.T) {
 		idMap[id] = true
 	}
 }
+
+func TestRandomIDSortable(t *testing.T) {
+	first := randomID()
+	time.Sleep(time.Millisecond * 2)
+	second := randomID()
+
+	if len(first) != len(second) {
+		t.Fatalf("id lengths differ: %s, %s", first, second)
+	}
+
+	if first >= second {
+		t.Errorf("id %s should sort before %s", first, second)
+	}
+}
+
+func TestConfigIDGenerator(t *testing.T) {
+	c := &Config{
+		IDGenerator: func() string {
+			return "fixed"
+		},
+	}
+	c.LoadDefaults()
+
+	if c.ContainerName != "mysqlbox-fixed" {
+		t.Errorf("unexpected container name: %s", c.ContainerName)
+	}
+}
@@ -0,0 +1,93 @@
+package mysqlbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/go-sql-driver/mysql"
+)
+
+// healthCheckCommand checks that the MySQL server accepts TCP connections. The temporary server run by the image
+// entrypoint while the initial scripts are run does not listen on TCP, so the container only becomes healthy when
+// the final server is started. mysqladmin ping succeeds even if the access is denied. MariaDB images only have
+// mariadb-admin. The exit status is the one of the available binary, so that a server that is not available yet
+// exits with 1 rather than with the 127 of a missing binary.
+const healthCheckCommand = "if command -v mysqladmin >/dev/null; then mysqladmin ping --host=127.0.0.1 --silent; " +
+	"else mariadb-admin ping --host=127.0.0.1 --silent; fi"
+
+// mysqlHealthcheck returns the Docker healthcheck of the MySQL container.
+func mysqlHealthcheck() *container.HealthConfig {
+	return &container.HealthConfig{
+		Test:     []string{"CMD-SHELL", healthCheckCommand},
+		Interval: time.Second,
+		Timeout:  5 * time.Second,
+		Retries:  3,
+	}
+}
+
+// WaitReady blocks until the MySQL server at the DSN accepts connections, using the same readiness check as Start().
+// It does not need a MySQLBox, so it can be used with servers started by other means, e.g. Docker Compose or
+// Kubernetes. If the context has a deadline and it is reached, the returned error wraps ErrTimeout.
+func WaitReady(ctx context.Context, dsn string) error {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return fmt.Errorf("invalid DSN: %w", err)
+	}
+
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		return err
+	}
+
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	err = pingUntilReady(ctx, db)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return withLastErrors(fmt.Errorf("readiness did not complete: %w", ErrTimeout), err)
+	}
+
+	return err
+}
+
+// waitHealthy waits for the Docker health status of the container to be healthy, the context to be done, or closed to
+// be signalled. It returns without an error if the container has no healthcheck, or if the healthcheck command cannot
+// run in the container, e.g. because the image does not have mysqladmin, so that the readiness is checked with pings
+// from the host instead.
+func (b *MySQLBox) waitHealthy(ctx context.Context, closed <-chan bool) error {
+	for {
+		inspect, err := b.cli.ContainerInspect(ctx, b.containerID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		health := inspect.State.Health
+		if health == nil || health.Status == types.Healthy {
+			return nil
+		}
+
+		// mysqladmin exits with 1 when the server is not available yet. Other exit codes mean the check cannot run.
+		if len(health.Log) > 0 {
+			last := health.Log[len(health.Log)-1]
+			if last.ExitCode != 0 && last.ExitCode != 1 {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-closed:
+			return errors.New("container closed")
+		case <-time.After(waitBetweenPings):
+		}
+	}
+}
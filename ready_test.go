@@ -0,0 +1,155 @@
+package mysqlbox
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitReadyErrors(t *testing.T) {
+	t.Run("invalid dsn", func(t *testing.T) {
+		err := WaitReady(context.Background(), "root@tcp(127.0.0.1")
+		require.Error(t, err)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		// Reserve a port with nothing listening on it.
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := ln.Addr().String()
+		require.NoError(t, ln.Close())
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		err = WaitReady(ctx, "root@tcp("+addr+")/")
+		require.ErrorIs(t, err, ErrTimeout)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := WaitReady(ctx, "root@tcp(127.0.0.1:1)/")
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+// healthDockerAPI returns a Docker client of a fake API that reports the health states in order for each inspect
+// request, and then the last state. It also returns the number of inspect requests.
+func healthDockerAPI(t *testing.T, states ...*types.Health) (*client.Client, *int32) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&requests, 1)) - 1
+		if n >= len(states) {
+			n = len(states) - 1
+		}
+
+		inspect := types.ContainerJSON{ContainerJSONBase: &types.ContainerJSONBase{
+			ID:    "abc",
+			State: &types.ContainerState{Running: true, Health: states[n]},
+		}}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(inspect)
+	}))
+	t.Cleanup(srv.Close)
+
+	cli, err := client.NewClientWithOpts(client.WithHost("tcp://"+strings.TrimPrefix(srv.URL, "http://")),
+		client.WithVersion("1.42"))
+	require.NoError(t, err)
+
+	return cli, &requests
+}
+
+func TestWaitHealthy(t *testing.T) {
+	starting := &types.Health{Status: types.Starting, Log: []*types.HealthcheckResult{{ExitCode: 1}}}
+
+	t.Run("healthy", func(t *testing.T) {
+		cli, requests := healthDockerAPI(t, starting, &types.Health{Status: types.Healthy})
+		b := &MySQLBox{cli: cli, containerID: "abc"}
+
+		require.NoError(t, b.waitHealthy(context.Background(), nil))
+		require.EqualValues(t, 2, atomic.LoadInt32(requests))
+	})
+
+	t.Run("no_healthcheck", func(t *testing.T) {
+		cli, requests := healthDockerAPI(t, nil)
+		b := &MySQLBox{cli: cli, containerID: "abc"}
+
+		require.NoError(t, b.waitHealthy(context.Background(), nil))
+		require.EqualValues(t, 1, atomic.LoadInt32(requests))
+	})
+
+	t.Run("check_cannot_run", func(t *testing.T) {
+		cli, _ := healthDockerAPI(t, &types.Health{Status: types.Unhealthy,
+			Log: []*types.HealthcheckResult{{ExitCode: 127}}})
+		b := &MySQLBox{cli: cli, containerID: "abc"}
+
+		require.NoError(t, b.waitHealthy(context.Background(), nil))
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		cli, _ := healthDockerAPI(t, starting)
+		b := &MySQLBox{cli: cli, containerID: "abc"}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		require.ErrorIs(t, b.waitHealthy(ctx, nil), context.DeadlineExceeded)
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		cli, _ := healthDockerAPI(t, starting)
+		b := &MySQLBox{cli: cli, containerID: "abc"}
+
+		closed := make(chan bool, 1)
+		closed <- true
+		require.EqualError(t, b.waitHealthy(context.Background(), closed), "container closed")
+	})
+}
+
+func TestHealthCheckCommand(t *testing.T) {
+	t.Run("exit_status", func(t *testing.T) {
+		// A stub mysqladmin that fails like a server that is not available yet, with no mariadb-admin on the PATH.
+		dir := t.TempDir()
+		err := os.WriteFile(filepath.Join(dir, "mysqladmin"), []byte("#!/bin/sh\nexit 1\n"), 0o755)
+		require.NoError(t, err)
+
+		cmd := exec.Command("/bin/sh", "-c", healthCheckCommand)
+		cmd.Env = []string{"PATH=" + dir}
+		err = cmd.Run()
+
+		var exitErr *exec.ExitError
+		require.ErrorAs(t, err, &exitErr)
+		require.Equal(t, 1, exitErr.ExitCode())
+	})
+
+	t.Run("mariadb_admin", func(t *testing.T) {
+		// MariaDB images only have mariadb-admin.
+		dir := t.TempDir()
+		err := os.WriteFile(filepath.Join(dir, "mariadb-admin"), []byte("#!/bin/sh\nexit 0\n"), 0o755)
+		require.NoError(t, err)
+
+		cmd := exec.Command("/bin/sh", "-c", healthCheckCommand)
+		cmd.Env = []string{"PATH=" + dir}
+		require.NoError(t, cmd.Run())
+	})
+
+	t.Run("healthcheck", func(t *testing.T) {
+		require.Equal(t, []string{"CMD-SHELL", healthCheckCommand}, mysqlHealthcheck().Test)
+	})
+
+}
@@ -0,0 +1,219 @@
+package mysqlbox
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
+)
+
+const defaultReaperImage = "testcontainers/ryuk:0.5.1"
+const reaperConnectTimeout = time.Second * 30
+
+const (
+	// sessionLabel contains the ID of the process session that created the container. The reaper removes all
+	// containers with the session label of its process.
+	sessionLabel = "com.github.virgild.mysqlbox.session"
+
+	// reaperLabel is set on reaper containers.
+	reaperLabel = "com.github.virgild.mysqlbox.reaper"
+)
+
+var (
+	// sessionID identifies the containers created by this process.
+	sessionID = randStr(16)
+
+	reaperMu sync.Mutex
+
+	// reaperStarted is set when the reaper is started or skipped. A reaper that failed to start is started again
+	// by the next box.
+	reaperStarted bool
+
+	// reaperConn is the connection to the reaper. It is kept open for the lifetime of the process. When the process
+	// exits, the reaper sees the connection close and removes the session containers.
+	reaperConn net.Conn
+)
+
+// startReaper starts the reaper container and registers the session containers with it. It only starts the reaper
+// once per process, and returns without an error on subsequent calls once it is started. If the Docker socket to
+// mount in the reaper is not known, the reaper is skipped and a message is logged.
+func startReaper(ctx context.Context, cli *client.Client, c *Config) error {
+	reaperMu.Lock()
+	defer reaperMu.Unlock()
+
+	if reaperStarted {
+		return nil
+	}
+
+	image := c.imageName(c.ReaperImage)
+	if image == "" {
+		image = defaultReaperImage
+	}
+
+	socket := c.ReaperDockerSocket
+	if socket == "" {
+		socket = reaperSocket(cli.DaemonHost())
+	}
+	if socket == "" {
+		c.Logger.Printf("skipping reaper, the Docker socket of %s cannot be mounted; set Config.ReaperDockerSocket",
+			cli.DaemonHost())
+		reaperStarted = true
+		return nil
+	}
+
+	conn, err := runReaper(ctx, cli, image, socket, c.pullConfig(), c.PullTimeout)
+	if err != nil {
+		return err
+	}
+
+	reaperConn = conn
+	reaperStarted = true
+
+	return nil
+}
+
+// reaperSocket returns the path of the Docker socket to mount in the reaper for the Docker host, e.g.
+// "unix:///run/user/1000/docker.sock" for rootless Docker. It returns an empty string if the host is not a unix
+// socket, since the path of the socket on a remote host is not known.
+func reaperSocket(host string) string {
+	path := strings.TrimPrefix(host, "unix://")
+	if path == host || path == "" {
+		return ""
+	}
+
+	return path
+}
+
+// runReaper creates and starts a reaper container that mounts the Docker socket and connects to it. The image is
+// pulled within pullTimeout if it is not available locally. The container is removed if the reaper cannot be
+// connected to.
+func runReaper(ctx context.Context, cli *client.Client, image, socket string, pull pullConfig,
+	pullTimeout time.Duration) (net.Conn, error) {
+	cfg := &container.Config{
+		Image: image,
+		ExposedPorts: map[nat.Port]struct{}{
+			"8080/tcp": {},
+		},
+		Labels: map[string]string{
+			reaperLabel: "1",
+			pidLabel:    strconv.Itoa(os.Getpid()),
+		},
+	}
+
+	hostCfg := &container.HostConfig{
+		AutoRemove: true,
+		PortBindings: map[nat.Port][]nat.PortBinding{
+			"8080/tcp": {
+				{HostIP: "127.0.0.1", HostPort: "0"},
+			},
+		},
+		Mounts: []mount.Mount{
+			{
+				Type:   mount.TypeBind,
+				Source: socket,
+				Target: "/var/run/docker.sock",
+			},
+		},
+	}
+
+	name := fmt.Sprintf("mysqlbox-reaper-%s", sessionID)
+	created, err := cli.ContainerCreate(ctx, cfg, hostCfg, nil, nil, name)
+	if client.IsErrNotFound(err) {
+		pullCtx, cancelPull := context.WithTimeout(ctx, pullTimeout)
+		err = pullImage(pullCtx, cli, image, pull)
+		cancelPull()
+		if errors.Is(pullCtx.Err(), context.DeadlineExceeded) {
+			return nil, phaseTimeout("reaper image pull", pullTimeout)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to pull image: %w", err)
+		}
+
+		created, err = cli.ContainerCreate(ctx, cfg, hostCfg, nil, nil, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error creating container: %w", err)
+	}
+
+	conn, err := startReaperContainer(ctx, cli, created.ID)
+	if err != nil {
+		_ = cli.ContainerRemove(context.Background(), created.ID, types.ContainerRemoveOptions{Force: true})
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// startReaperContainer starts the created reaper container and connects to it.
+func startReaperContainer(ctx context.Context, cli *client.Client, containerID string) (net.Conn, error) {
+	err := cli.ContainerStart(ctx, containerID, types.ContainerStartOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	port, err := containerHostPort(ctx, cli, containerID, "8080/tcp")
+	if err != nil {
+		return nil, err
+	}
+
+	return connectReaper(net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+}
+
+// connectReaper connects to the reaper and registers the session label filter. The reaper may take a moment to
+// start listening, so connecting is retried until reaperConnectTimeout.
+func connectReaper(addr string) (net.Conn, error) {
+	deadline := time.Now().Add(reaperConnectTimeout)
+
+	for {
+		conn, err := registerWithReaper(addr)
+		if err == nil {
+			return conn, nil
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("error connecting to reaper: %w", err)
+		}
+		time.Sleep(waitBetweenPings)
+	}
+}
+
+// registerWithReaper sends the session label filter to the reaper and waits for its acknowledgement.
+func registerWithReaper(addr string) (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", addr, time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = conn.SetDeadline(time.Now().Add(time.Second * 5))
+	_, err = fmt.Fprintf(conn, "label=%s=%s\n", sessionLabel, sessionID)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if reply != "ACK\n" {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected reaper reply: %q", reply)
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	return conn, nil
+}
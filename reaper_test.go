@@ -0,0 +1,79 @@
+package mysqlbox
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterWithReaper(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		ln.Close()
+	})
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		received <- line
+		_, _ = conn.Write([]byte("ACK\n"))
+	}()
+
+	conn, err := registerWithReaper(ln.Addr().String())
+	require.NoError(t, err)
+	conn.Close()
+
+	require.Equal(t, "label="+sessionLabel+"="+sessionID+"\n", <-received)
+}
+
+func TestReaperSocket(t *testing.T) {
+	require.Equal(t, "/var/run/docker.sock", reaperSocket("unix:///var/run/docker.sock"))
+	require.Equal(t, "/run/user/1000/docker.sock", reaperSocket("unix:///run/user/1000/docker.sock"))
+	require.Equal(t, "", reaperSocket("tcp://192.168.1.10:2376"))
+	require.Equal(t, "", reaperSocket("npipe:////./pipe/docker_engine"))
+	require.Equal(t, "", reaperSocket("unix://"))
+}
+
+func TestStartReaper(t *testing.T) {
+	// Other tests may have started the reaper of the process.
+	reaperMu.Lock()
+	started := reaperStarted
+	reaperStarted = false
+	reaperMu.Unlock()
+	t.Cleanup(func() {
+		reaperMu.Lock()
+		reaperStarted = started
+		reaperMu.Unlock()
+	})
+
+	t.Run("failure_is_retried", func(t *testing.T) {
+		cli, requests := fakeDockerAPI(t, map[string]int{"/create": http.StatusInternalServerError})
+		c := &Config{ReaperDockerSocket: "/var/run/docker.sock", Logger: DiscardLogger}
+		c.LoadDefaults()
+
+		require.Error(t, startReaper(context.Background(), cli, c))
+		require.Error(t, startReaper(context.Background(), cli, c))
+		require.Len(t, requests(), 2)
+	})
+
+	t.Run("unknown_socket", func(t *testing.T) {
+		// The fake Docker API is a tcp:// host, so the socket to mount is not known.
+		cli, requests := fakeDockerAPI(t, nil)
+		c := &Config{Logger: DiscardLogger}
+		c.LoadDefaults()
+
+		require.NoError(t, startReaper(context.Background(), cli, c))
+		require.Empty(t, requests())
+	})
+}
@@ -0,0 +1,378 @@
+package mysqlbox
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RecordedQuery is a statement run through a DB returned by RecordingDB().
+type RecordedQuery struct {
+	// Query is the text of the statement.
+	Query string
+
+	// Args contains the arguments of the statement.
+	Args []interface{}
+
+	// StartedAt is the time the statement was sent.
+	StartedAt time.Time
+
+	// Duration is the time until the server returned the result of the statement. For queries, this does not include
+	// the time to read the rows.
+	Duration time.Duration
+
+	// Err is the error returned by the statement.
+	Err error
+}
+
+// String returns the statement with its arguments and duration.
+func (q RecordedQuery) String() string {
+	var sb strings.Builder
+	sb.WriteString(q.Query)
+
+	if len(q.Args) > 0 {
+		fmt.Fprintf(&sb, " %v", q.Args)
+	}
+	fmt.Fprintf(&sb, " (%s)", q.Duration)
+
+	if q.Err != nil {
+		fmt.Fprintf(&sb, ": %s", q.Err.Error())
+	}
+
+	return sb.String()
+}
+
+// QueryRecorder records the statements run through a DB returned by RecordingDB(). It is safe for concurrent use.
+type QueryRecorder struct {
+	queries []RecordedQuery
+	mu      sync.Mutex
+}
+
+// Queries returns the recorded statements in the order they were sent.
+func (r *QueryRecorder) Queries() []RecordedQuery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]RecordedQuery(nil), r.queries...)
+}
+
+// Len returns the number of recorded statements.
+func (r *QueryRecorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.queries)
+}
+
+// Reset removes the recorded statements, e.g. after the test data is set up and before the code under test runs.
+func (r *QueryRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.queries = nil
+}
+
+// String returns the recorded statements, one per line, e.g. for the message of a failed assertion.
+func (r *QueryRecorder) String() string {
+	queries := r.Queries()
+	lines := make([]string, len(queries))
+	for n, q := range queries {
+		lines[n] = q.String()
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// record adds a statement that was started at the time.
+func (r *QueryRecorder) record(query string, args []driver.NamedValue, started time.Time, err error) {
+	q := RecordedQuery{
+		Query:     query,
+		StartedAt: started,
+		Duration:  time.Since(started),
+		Err:       err,
+	}
+
+	if len(args) > 0 {
+		q.Args = make([]interface{}, len(args))
+		for n, arg := range args {
+			// The driver may reuse the buffers of byte slice arguments.
+			if b, ok := arg.Value.([]byte); ok {
+				q.Args[n] = append([]byte(nil), b...)
+			} else {
+				q.Args[n] = arg.Value
+			}
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.queries = append(r.queries, q)
+}
+
+// RecordingDB returns a new DB connection to the Database that records every statement run through it, with its
+// arguments, duration, and error, in the returned QueryRecorder. This can be used to assert which statements the code
+// under test runs:
+//
+//	db, recorder := box.MustRecordingDB()
+//	handler := NewHandler(db)
+//	recorder.Reset()
+//	handler.ServeHTTP(w, r)
+//	require.Equal(t, 2, recorder.Len(), recorder.String())
+//
+// Pings and the statements that begin and end transactions are not recorded. The DB is closed when the box is
+// stopped.
+func (b *MySQLBox) RecordingDB() (*sql.DB, *QueryRecorder, error) {
+	if b == nil {
+		return nil, nil, errors.New("mysqlbox is nil")
+	}
+
+	recorder := &QueryRecorder{}
+	instrument := func(c driver.Connector) driver.Connector {
+		if b.instrumentSQL != nil {
+			c = b.instrumentSQL(c)
+		}
+
+		return recordingConnector{Connector: c, recorder: recorder}
+	}
+
+	db, _, err := connectDB(b.host, b.port, b.databaseName, b.rootPassword, b.tlsConfigName(), instrument)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b.derivedDBsMu.Lock()
+	b.derivedDBs = append(b.derivedDBs, db)
+	b.derivedDBsMu.Unlock()
+
+	return db, recorder, nil
+}
+
+// MustRecordingDB returns a new DB connection to the Database that records every statement run through it.
+func (b *MySQLBox) MustRecordingDB() (*sql.DB, *QueryRecorder) {
+	db, recorder, err := b.RecordingDB()
+	if err != nil {
+		panic(err)
+	}
+
+	return db, recorder
+}
+
+// recordingConnector is a driver connector whose connections record their statements.
+type recordingConnector struct {
+	driver.Connector
+	recorder *QueryRecorder
+}
+
+// Connect returns a recording connection.
+func (c recordingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &recordingConn{conn: conn, recorder: c.recorder}, nil
+}
+
+// recordingConn is a driver connection that records its statements. The optional interfaces of the wrapped
+// connection are passed through, or fall back to the behavior of database/sql when they are not implemented.
+type recordingConn struct {
+	conn     driver.Conn
+	recorder *QueryRecorder
+}
+
+// Prepare returns a recording prepared statement.
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+// PrepareContext returns a recording prepared statement.
+func (c *recordingConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := c.conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &recordingStmt{stmt: stmt, query: query, recorder: c.recorder}, nil
+}
+
+// Close closes the connection.
+func (c *recordingConn) Close() error {
+	return c.conn.Close()
+}
+
+// Begin starts a transaction.
+func (c *recordingConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx starts a transaction with the options.
+func (c *recordingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+
+	return c.conn.Begin()
+}
+
+// ExecContext runs a statement without preparing it. If the wrapped connection returns driver.ErrSkip, database/sql
+// prepares the statement, which is recorded by the prepared statement instead.
+func (c *recordingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result,
+	error) {
+	execer, ok := c.conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	started := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		c.recorder.record(query, args, started, err)
+	}
+
+	return result, err
+}
+
+// QueryContext runs a query without preparing it. If the wrapped connection returns driver.ErrSkip, database/sql
+// prepares the query, which is recorded by the prepared statement instead.
+func (c *recordingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows,
+	error) {
+	queryer, ok := c.conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	started := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		c.recorder.record(query, args, started, err)
+	}
+
+	return rows, err
+}
+
+// Ping checks the connection.
+func (c *recordingConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+
+	return nil
+}
+
+// ResetSession resets the connection before it is reused.
+func (c *recordingConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+// IsValid reports whether the connection can be reused.
+func (c *recordingConn) IsValid() bool {
+	if validator, ok := c.conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+
+	return true
+}
+
+// CheckNamedValue converts the arguments of statements like the wrapped connection.
+func (c *recordingConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+
+	return driver.ErrSkip
+}
+
+// recordingStmt is a prepared statement that records its executions.
+type recordingStmt struct {
+	stmt     driver.Stmt
+	query    string
+	recorder *QueryRecorder
+}
+
+// Close closes the statement.
+func (s *recordingStmt) Close() error {
+	return s.stmt.Close()
+}
+
+// NumInput returns the number of placeholders of the statement.
+func (s *recordingStmt) NumInput() int {
+	return s.stmt.NumInput()
+}
+
+// Exec runs the statement.
+func (s *recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+// ExecContext runs the statement.
+func (s *recordingStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	started := time.Now()
+
+	var result driver.Result
+	var err error
+	if execer, ok := s.stmt.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else {
+		result, err = s.stmt.Exec(driverValues(args))
+	}
+	s.recorder.record(s.query, args, started, err)
+
+	return result, err
+}
+
+// Query runs the query.
+func (s *recordingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+// QueryContext runs the query.
+func (s *recordingStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	started := time.Now()
+
+	var rows driver.Rows
+	var err error
+	if queryer, ok := s.stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		rows, err = s.stmt.Query(driverValues(args))
+	}
+	s.recorder.record(s.query, args, started, err)
+
+	return rows, err
+}
+
+// namedValues converts positional arguments to named values.
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for n, arg := range args {
+		named[n] = driver.NamedValue{Ordinal: n + 1, Value: arg}
+	}
+
+	return named
+}
+
+// driverValues converts named values to positional arguments.
+func driverValues(args []driver.NamedValue) []driver.Value {
+	result := make([]driver.Value, len(args))
+	for n, arg := range args {
+		result[n] = arg.Value
+	}
+
+	return result
+}
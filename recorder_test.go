@@ -0,0 +1,141 @@
+package mysqlbox
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConnector returns connections of a driver that runs statements without arguments directly, and prepares
+// statements with arguments like the mysql driver without interpolateParams.
+type fakeConnector struct{}
+
+func (fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return fakeConn{}, nil
+}
+
+func (fakeConnector) Driver() driver.Driver {
+	return nil
+}
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{}, nil
+}
+
+func (fakeConn) Close() error {
+	return nil
+}
+
+func (fakeConn) Begin() (driver.Tx, error) {
+	return fakeTx{}, nil
+}
+
+func (fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if len(args) > 0 {
+		return nil, driver.ErrSkip
+	}
+	if query == "FAIL" {
+		return nil, errors.New("failed")
+	}
+
+	return driver.RowsAffected(1), nil
+}
+
+type fakeStmt struct{}
+
+func (fakeStmt) Close() error {
+	return nil
+}
+
+func (fakeStmt) NumInput() int {
+	return -1
+}
+
+func (fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+func (fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return fakeRows{}, nil
+}
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string {
+	return []string{"id"}
+}
+
+func (fakeRows) Close() error {
+	return nil
+}
+
+func (fakeRows) Next([]driver.Value) error {
+	return io.EOF
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error {
+	return nil
+}
+
+func (fakeTx) Rollback() error {
+	return nil
+}
+
+func TestRecordingConn(t *testing.T) {
+	recorder := &QueryRecorder{}
+	db := sql.OpenDB(recordingConnector{Connector: fakeConnector{}, recorder: recorder})
+	defer db.Close()
+
+	_, err := db.Exec("DELETE FROM users")
+	require.NoError(t, err)
+
+	_, err = db.Exec("FAIL")
+	require.EqualError(t, err, "failed")
+
+	_, err = db.Exec("UPDATE users SET email = ? WHERE id = ?", []byte("a@example.com"), 1)
+	require.NoError(t, err)
+
+	rows, err := db.Query("SELECT id FROM users WHERE id > ?", 5)
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	_, err = tx.Exec("DELETE FROM categories")
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	queries := recorder.Queries()
+	require.Len(t, queries, 5)
+	require.Equal(t, 5, recorder.Len())
+
+	require.Equal(t, "DELETE FROM users", queries[0].Query)
+	require.Nil(t, queries[0].Args)
+	require.NoError(t, queries[0].Err)
+
+	require.Equal(t, "FAIL", queries[1].Query)
+	require.EqualError(t, queries[1].Err, "failed")
+
+	require.Equal(t, "UPDATE users SET email = ? WHERE id = ?", queries[2].Query)
+	require.Equal(t, []interface{}{[]byte("a@example.com"), int64(1)}, queries[2].Args)
+
+	require.Equal(t, "SELECT id FROM users WHERE id > ?", queries[3].Query)
+	require.Equal(t, []interface{}{int64(5)}, queries[3].Args)
+
+	require.Equal(t, "DELETE FROM categories", queries[4].Query)
+
+	require.Contains(t, recorder.String(), "FAIL (")
+	require.Contains(t, recorder.String(), "): failed")
+
+	recorder.Reset()
+	require.Equal(t, 0, recorder.Len())
+}
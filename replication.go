@@ -0,0 +1,87 @@
+package mysqlbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SetReplicationDelay makes the replication SQL thread of the MySQL server apply events from its source with a
+// delay, so that stale reads from a replica can be tested. The delay is rounded down to whole seconds, and zero
+// removes the delay. The server must already be configured as a replica, e.g. a box returned by Attach() for a
+// replica container. The SQL thread is stopped while the delay is changed and started again afterwards.
+func (b *MySQLBox) SetReplicationDelay(d time.Duration) error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	if d < 0 {
+		return errors.New("replication delay cannot be negative")
+	}
+
+	ctx := context.Background()
+	version, err := b.serverVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	// MySQL 8.0.22 and 8.0.23 introduced the REPLICA and SOURCE terminology.
+	statusQuery := "SHOW SLAVE STATUS"
+	stopQuery := "STOP SLAVE SQL_THREAD"
+	changeQuery := "CHANGE MASTER TO MASTER_DELAY = %d"
+	startQuery := "START SLAVE SQL_THREAD"
+	if version.atLeast(8, 0, 23) {
+		statusQuery = "SHOW REPLICA STATUS"
+		stopQuery = "STOP REPLICA SQL_THREAD"
+		changeQuery = "CHANGE REPLICATION SOURCE TO SOURCE_DELAY = %d"
+		startQuery = "START REPLICA SQL_THREAD"
+	}
+
+	conn, err := b.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(ctx, statusQuery)
+	if err != nil {
+		return err
+	}
+	isReplica := rows.Next()
+	err = rows.Close()
+	if err != nil {
+		return err
+	}
+	if !isReplica {
+		return errors.New("server is not a replica")
+	}
+
+	_, err = conn.ExecContext(ctx, stopQuery)
+	if err != nil {
+		return fmt.Errorf("error stopping replication SQL thread: %w", err)
+	}
+
+	_, err = conn.ExecContext(ctx, fmt.Sprintf(changeQuery, int64(d/time.Second)))
+	if err != nil {
+		// Do not leave replication stopped.
+		_, _ = conn.ExecContext(ctx, startQuery)
+		return fmt.Errorf("error setting replication delay: %w", err)
+	}
+
+	_, err = conn.ExecContext(ctx, startQuery)
+	if err != nil {
+		return fmt.Errorf("error starting replication SQL thread: %w", err)
+	}
+
+	return nil
+}
+
+// MustSetReplicationDelay makes the replication SQL thread of the MySQL server apply events from its source with a
+// delay.
+func (b *MySQLBox) MustSetReplicationDelay(d time.Duration) {
+	err := b.SetReplicationDelay(d)
+	if err != nil {
+		panic(err)
+	}
+}
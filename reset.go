@@ -0,0 +1,98 @@
+package mysqlbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Reset drops the Database, creates it again, and replays the initial SQL scripts (see Config.InitialSQL and
+// Config.InitialSQLs) and migrations (see Config.Migrations) through a client connection. For wide schemas this is
+// faster and more thorough than truncating every table, and it also resets AUTO_INCREMENT counters. Each initial SQL
+// script is run as a multi-statement query, so scripts that use the DELIMITER command of the mysql client are not
+// supported. Idle pooled connections are closed after the reset (see FlushPool()). Reset returns an error without
+// changing the Database if Config.InitScriptsDir is used, since the scripts of the directory, which can also be
+// compressed or shell scripts, are run by the image entrypoint and cannot be replayed.
+func (b *MySQLBox) Reset() error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	if b.initScriptsDir != "" {
+		return errors.New("reset is not supported with InitScriptsDir")
+	}
+
+	ctx := context.Background()
+	if b.readOnly {
+		err := b.setDatabaseReadOnly(ctx, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	if b.killLockHolders {
+		err := b.killMetadataLockHolders(ctx, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := b.dropDatabase(ctx, b.databaseName)
+	if err != nil {
+		return fmt.Errorf("error dropping database: %w", err)
+	}
+
+	err = b.createDatabase(ctx, b.databaseName)
+	if err != nil {
+		return fmt.Errorf("error creating database: %w", err)
+	}
+	b.resetSeedSources()
+
+	for n, script := range b.initialSQLs {
+		err := b.execScript(ctx, b.databaseName, script)
+		if err != nil {
+			return fmt.Errorf("error running initial SQL script %d: %w", n+1, err)
+		}
+	}
+
+	if b.migrator != nil {
+		err := b.migrateUp()
+		if err != nil {
+			return fmt.Errorf("error running migrations: %w", err)
+		}
+	}
+
+	if b.readOnly {
+		err := b.setDatabaseReadOnly(ctx, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The pooled connections lost their default database when it was dropped.
+	return b.FlushPool()
+}
+
+// MustReset drops the Database, creates it again, and replays the initial SQL scripts.
+func (b *MySQLBox) MustReset() {
+	err := b.Reset()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// execScript runs an SQL script containing multiple statements against a database.
+func (b *MySQLBox) execScript(ctx context.Context, database string, script []byte) error {
+	mysqlCfg := newMySQLConfig(b.host, b.port, database, b.rootPassword, b.tlsConfigName())
+	mysqlCfg.MultiStatements = true
+
+	db, err := sql.Open("mysql", mysqlCfg.FormatDSN())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.ExecContext(ctx, string(script))
+	return err
+}
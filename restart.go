@@ -0,0 +1,83 @@
+package mysqlbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// Restart restarts the MySQL container and waits until MySQL accepts connections again, or until the context is
+// done. The data in the container is kept, so this can be used to test crash recovery and reconnect logic.
+//
+// The container may be bound to a different host port after the restart when Config.MySQLPort is not set. In that
+// case, the DB of the box is closed and replaced, and DB(), DSN(), and DBAddr() must be called again. DBs returned
+// by ConnectDB() before the restart are not updated. Set Config.MySQLPort to keep existing DBs and DSNs valid
+// across restarts. Restart must not be called concurrently with other methods of the box.
+func (b *MySQLBox) Restart(ctx context.Context) error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	restartedAt := time.Now()
+
+	timeoutSecs := int(b.containerStopTimeout.Seconds())
+	err := b.cli.ContainerRestart(ctx, b.containerID, container.StopOptions{
+		Timeout: &timeoutSecs,
+	})
+	if err != nil {
+		return fmt.Errorf("error restarting container: %w", err)
+	}
+
+	// The log stream of the previous run ended when the container stopped.
+	go readContainerLogs(context.Background(), b.cli, b.containerID, strconv.FormatInt(restartedAt.Unix(), 10),
+		b.cout, b.cerr, b.logErrors, make(chan bool, 1))
+
+	port, err := containerMySQLPort(ctx, b.cli, b.containerID)
+	if err != nil {
+		return err
+	}
+
+	xPort, err := containerHostPort(ctx, b.cli, b.containerID, "33060/tcp")
+	if err != nil {
+		return err
+	}
+
+	if b.adminPort != 0 {
+		b.adminPort, err = containerHostPort(ctx, b.cli, b.containerID, adminPort)
+		if err != nil {
+			return err
+		}
+	}
+
+	if port != b.port {
+		db, dsn, err := connectDB(b.host, port, b.databaseName, b.rootPassword, b.tlsConfigName(), b.instrumentSQL)
+		if err != nil {
+			return err
+		}
+
+		_ = b.db.Close()
+		b.db = db
+		b.dsn = dsn
+		b.port = port
+	}
+	b.xPort = xPort
+
+	err = pingUntilReady(ctx, b.db)
+	if err != nil {
+		return fmt.Errorf("error waiting for MySQL after restart: %w", err)
+	}
+
+	return nil
+}
+
+// MustRestart restarts the MySQL container and waits until MySQL accepts connections again.
+func (b *MySQLBox) MustRestart(ctx context.Context) {
+	err := b.Restart(ctx)
+	if err != nil {
+		panic(err)
+	}
+}
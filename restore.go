@@ -0,0 +1,80 @@
+package mysqlbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Restore replaces the contents of the Database with an SQL dump read from r, e.g. one written by Dump(). The
+// Database is dropped and created again, and the dump is run by the mysql client in the container, so dumps that use
+// the DELIMITER command are supported. Together with Dump(), this saves and restores the state of a box without
+// restarting the container:
+//
+//	var saved bytes.Buffer
+//	box.MustDump(ctx, &saved, nil)
+//	// ... run a test that changes the data
+//	box.MustRestore(ctx, &saved)
+//
+// A dump of other databases creates or replaces them, but the Database is still emptied. Idle pooled connections are
+// closed after the restore (see FlushPool()).
+func (b *MySQLBox) Restore(ctx context.Context, r io.Reader) error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	if b.readOnly {
+		err := b.setDatabaseReadOnly(ctx, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	if b.killLockHolders {
+		err := b.killMetadataLockHolders(ctx, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := b.dropDatabase(ctx, b.databaseName)
+	if err != nil {
+		return fmt.Errorf("error dropping database: %w", err)
+	}
+
+	err = b.createDatabase(ctx, b.databaseName)
+	if err != nil {
+		return fmt.Errorf("error creating database: %w", err)
+	}
+	b.resetSeedSources()
+
+	cmd := []string{"mysql", "--user=root", "--", b.databaseName}
+	stdout, stderr, exitCode, err := b.execInContainer(ctx, cmd, []string{"MYSQL_PWD=" + b.rootPassword}, r)
+	if err != nil {
+		return fmt.Errorf("error restoring dump: %w", err)
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("error restoring dump: exit code %d: %s", exitCode,
+			strings.TrimSpace(string(stderr)+string(stdout)))
+	}
+
+	if b.readOnly {
+		err := b.setDatabaseReadOnly(ctx, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The pooled connections lost their default database when it was dropped.
+	return b.FlushPool()
+}
+
+// MustRestore replaces the contents of the Database with an SQL dump read from r.
+func (b *MySQLBox) MustRestore(ctx context.Context, r io.Reader) {
+	err := b.Restore(ctx, r)
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,226 @@
+package mysqlbox
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+)
+
+// defaultStepTimeout is the maximum time to wait for a scenario step when Scenario.StepTimeout is not set.
+const defaultStepTimeout = 10 * time.Second
+
+// Scenario is a script of statements run by concurrent sessions with an explicit interleaving. It is used to
+// reproduce race conditions, e.g. lock waits and deadlocks between transactions.
+type Scenario struct {
+	// Sessions contains the names of the sessions. Each session has its own connection to the Database.
+	Sessions []string
+
+	// Steps contains the steps in the order they are started.
+	Steps []Step
+
+	// StepTimeout is the maximum time to wait for a statement to complete. The default is 10 seconds.
+	StepTimeout time.Duration
+}
+
+// Step is a statement run by a scenario session, or a barrier that waits for the statement of another session.
+type Step struct {
+	// Session is the name of the session that runs the statement.
+	Session string
+
+	// SQL is the statement. It can be blank in a step that only waits for another session.
+	SQL string
+
+	// Args are the statement arguments.
+	Args []interface{}
+
+	// Async starts the statement without waiting for it to complete, so that the next steps can run while it is
+	// blocked, e.g. waiting for a lock held by another session. The statement is waited for by a later step with
+	// Wait set to the session, by the next statement of the session, or at the end of the scenario.
+	Async bool
+
+	// Wait is the name of a session whose async statement must complete before the step runs.
+	Wait string
+
+	// ExpectError fails the scenario if the statement does not return an error.
+	ExpectError bool
+
+	// Check is an optional function that is called with the result of the statement.
+	Check func(t testing.TB, result StepResult)
+}
+
+// StepResult is the result of a scenario statement.
+type StepResult struct {
+	// Rows contains the rows returned by the statement. Column values are strings, or nil for NULL.
+	Rows [][]interface{}
+
+	// Err is the error returned by the statement.
+	Err error
+}
+
+// scenarioJob is a step sent to a session goroutine.
+type scenarioJob struct {
+	index  int
+	step   Step
+	result chan StepResult
+}
+
+// RunScenario runs the steps of the scenario in order. Each session runs its statements on its own connection to the
+// Database, in a separate goroutine. A step fails the test if its statement returns an unexpected error or does not
+// complete within the step timeout.
+func (b *MySQLBox) RunScenario(t testing.TB, s Scenario) {
+	t.Helper()
+
+	timeout := s.StepTimeout
+	if timeout == 0 {
+		timeout = defaultStepTimeout
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	jobs := map[string]chan scenarioJob{}
+	var conns []*sql.Conn
+
+	// Cancel blocked statements before closing the connections, which waits for them. The connections may still
+	// have open transactions and held locks, so they are discarded instead of being returned to the pool.
+	defer func() {
+		cancel()
+		for _, jobCh := range jobs {
+			close(jobCh)
+		}
+		for _, conn := range conns {
+			_ = conn.Raw(func(interface{}) error {
+				return driver.ErrBadConn
+			})
+			_ = conn.Close()
+		}
+	}()
+
+	for _, session := range s.Sessions {
+		conn, err := b.db.Conn(ctx)
+		if err != nil {
+			t.Fatalf("error connecting session %s: %s", session, err.Error())
+		}
+		conns = append(conns, conn)
+
+		jobCh := make(chan scenarioJob)
+		jobs[session] = jobCh
+
+		go runScenarioSession(ctx, conn, jobCh)
+	}
+
+	pending := map[string]scenarioJob{}
+	wait := func(session string) {
+		t.Helper()
+
+		job, ok := pending[session]
+		if !ok {
+			return
+		}
+		delete(pending, session)
+
+		finishScenarioStep(t, job, timeout)
+	}
+
+	for n, step := range s.Steps {
+		if step.Wait != "" {
+			wait(step.Wait)
+		}
+
+		if step.SQL == "" {
+			continue
+		}
+
+		jobCh, ok := jobs[step.Session]
+		if !ok {
+			t.Fatalf("step %d: unknown session %s", n+1, step.Session)
+		}
+
+		// The statements of a session run one at a time.
+		wait(step.Session)
+
+		job := scenarioJob{index: n + 1, step: step, result: make(chan StepResult, 1)}
+		jobCh <- job
+
+		if step.Async {
+			pending[step.Session] = job
+			continue
+		}
+
+		finishScenarioStep(t, job, timeout)
+	}
+
+	for _, session := range s.Sessions {
+		wait(session)
+	}
+}
+
+// runScenarioSession runs the statements sent to a session on its connection.
+func runScenarioSession(ctx context.Context, conn *sql.Conn, jobs <-chan scenarioJob) {
+	for job := range jobs {
+		job.result <- runScenarioStatement(ctx, conn, job.step)
+	}
+}
+
+// runScenarioStatement runs a statement and reads the rows it returns.
+func runScenarioStatement(ctx context.Context, conn *sql.Conn, step Step) StepResult {
+	rows, err := conn.QueryContext(ctx, step.SQL, step.Args...)
+	if err != nil {
+		return StepResult{Err: err}
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return StepResult{Err: err}
+	}
+
+	var result StepResult
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for n := range values {
+			ptrs[n] = &values[n]
+		}
+
+		err := rows.Scan(ptrs...)
+		if err != nil {
+			return StepResult{Err: err}
+		}
+
+		row := make([]interface{}, len(columns))
+		for n, value := range values {
+			if value.Valid {
+				row[n] = value.String
+			}
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	result.Err = rows.Err()
+
+	return result
+}
+
+// finishScenarioStep waits for the result of a step and checks it.
+func finishScenarioStep(t testing.TB, job scenarioJob, timeout time.Duration) {
+	t.Helper()
+
+	var result StepResult
+	select {
+	case result = <-job.result:
+	case <-time.After(timeout):
+		t.Fatalf("step %d (%s): statement did not complete within %s: %s", job.index, job.step.Session, timeout,
+			job.step.SQL)
+	}
+
+	switch {
+	case result.Err != nil && !job.step.ExpectError:
+		t.Errorf("step %d (%s): %s", job.index, job.step.Session, result.Err.Error())
+	case result.Err == nil && job.step.ExpectError:
+		t.Errorf("step %d (%s): expected an error: %s", job.index, job.step.Session, job.step.SQL)
+	}
+
+	if job.step.Check != nil {
+		job.step.Check(t, result)
+	}
+}
@@ -0,0 +1,325 @@
+package mysqlbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// SchemaTable, SchemaColumn, SchemaIndex, and SchemaConstraint are the kinds of objects compared by DiffSchema().
+	SchemaTable      = "table"
+	SchemaColumn     = "column"
+	SchemaIndex      = "index"
+	SchemaConstraint = "constraint"
+)
+
+// schemaObjectOrder is the order of the kinds of objects of a table in the differences.
+var schemaObjectOrder = map[string]int{SchemaTable: 0, SchemaColumn: 1, SchemaIndex: 2, SchemaConstraint: 3}
+
+// SchemaDifference is a difference between the schema of the Database and an expected schema, found by DiffSchema().
+type SchemaDifference struct {
+	// Object is the kind of the object: SchemaTable, SchemaColumn, SchemaIndex, or SchemaConstraint.
+	Object string
+
+	// Table is the name of the table.
+	Table string
+
+	// Name is the name of the column, index, or constraint. It is blank for tables.
+	Name string
+
+	// Expected is the definition of the object in the expected schema. It is blank if the object is not expected.
+	Expected string
+
+	// Actual is the definition of the object in the Database. It is blank if the object is missing.
+	Actual string
+}
+
+// String describes the difference, e.g. "column users.email: expected varchar(128) NOT NULL, got varchar(64) NULL".
+func (d SchemaDifference) String() string {
+	name := d.Table
+	if d.Name != "" {
+		name += "." + d.Name
+	}
+
+	switch {
+	case d.Actual == "":
+		return fmt.Sprintf("%s %s: missing, expected %s", d.Object, name, d.Expected)
+	case d.Expected == "":
+		return fmt.Sprintf("%s %s: unexpected %s", d.Object, name, d.Actual)
+	}
+
+	return fmt.Sprintf("%s %s: expected %s, got %s", d.Object, name, d.Expected, d.Actual)
+}
+
+// schemaKey identifies an object of a schema.
+type schemaKey struct {
+	object string
+	table  string
+	name   string
+}
+
+// DiffSchema compares the tables, columns, indexes, and constraints of the Database with the schema created by an
+// SQL script, e.g. a checked-in schema file or the output of DumpSchema(), and returns the differences ordered by
+// table. An empty list means that the schemas match. This detects migrations that drift from the expected schema:
+//
+//	diffs := box.MustDiffSchema(mysqlbox.DataFromFile("testdata/schema.sql"))
+//	require.Empty(t, diffs)
+//
+// The script is run in a temporary database, which is dropped afterwards. USE and CREATE DATABASE statements in the
+// script are skipped, so that the script does not change other databases. Only base tables are compared, and the
+// order of columns is ignored.
+func (b *MySQLBox) DiffSchema(expected *Data) ([]SchemaDifference, error) {
+	if b == nil {
+		return nil, errors.New("mysqlbox is nil")
+	}
+
+	if expected == nil {
+		return nil, errors.New("expected schema is nil")
+	}
+
+	script, err := expected.content()
+	if err != nil {
+		return nil, fmt.Errorf("error reading expected schema: %w", err)
+	}
+
+	statements, issues := splitScript(string(script))
+	if len(issues) > 0 {
+		return nil, fmt.Errorf("invalid expected schema: %s", issues[0].String())
+	}
+
+	ctx := context.Background()
+	name := fmt.Sprintf("diff_%s", randStr(8))
+	err = b.createDatabase(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("error creating database: %w", err)
+	}
+	defer func() {
+		_ = b.dropDatabase(context.Background(), name)
+	}()
+
+	err = b.runSchemaStatements(ctx, name, statements)
+	if err != nil {
+		return nil, err
+	}
+
+	want, err := b.schemaObjects(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	got, err := b.schemaObjects(ctx, b.databaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffSchemaObjects(want, got), nil
+}
+
+// MustDiffSchema compares the tables, columns, indexes, and constraints of the Database with the schema created by an
+// SQL script.
+func (b *MySQLBox) MustDiffSchema(expected *Data) []SchemaDifference {
+	diffs, err := b.DiffSchema(expected)
+	if err != nil {
+		panic(err)
+	}
+
+	return diffs
+}
+
+// runSchemaStatements runs the statements of a schema script in a database, except those that select or create
+// databases.
+func (b *MySQLBox) runSchemaStatements(ctx context.Context, database string, statements []scriptStatement) error {
+	mysqlCfg := newMySQLConfig(b.host, b.port, database, b.rootPassword, b.tlsConfigName())
+	db, err := sql.Open("mysql", mysqlCfg.FormatDSN())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// A single connection keeps the session variables set by the script.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for _, statement := range statements {
+		fields := strings.Fields(strings.ToUpper(statement.text))
+		if fields[0] == "USE" || len(fields) > 1 && fields[0] == "CREATE" &&
+			(fields[1] == "DATABASE" || fields[1] == "SCHEMA") {
+			continue
+		}
+
+		_, err := conn.ExecContext(ctx, statement.text)
+		if err != nil {
+			return fmt.Errorf("error running expected schema line %d: %w", statement.line, err)
+		}
+	}
+
+	return nil
+}
+
+// schemaObjects returns the definitions of the base tables of a database and their columns, indexes, and
+// constraints.
+func (b *MySQLBox) schemaObjects(ctx context.Context, database string) (map[schemaKey]string, error) {
+	objects := map[schemaKey]string{}
+
+	version, err := b.serverVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.queryObjects(ctx, objects, SchemaTable, `SELECT table_name, '', CONCAT('ENGINE=', engine, ' COLLATE=',
+			table_collation)
+		FROM information_schema.tables
+		WHERE table_schema = ? AND table_type = 'BASE TABLE'`, database)
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.queryObjects(ctx, objects, SchemaColumn, `SELECT table_name, column_name, CONCAT_WS(' ', column_type,
+			IF(is_nullable = 'YES', 'NULL', 'NOT NULL'),
+			IF(column_default IS NULL, NULL, CONCAT('DEFAULT ', QUOTE(column_default))),
+			IF(collation_name IS NULL, NULL, CONCAT('COLLATE ', collation_name)),
+			NULLIF(extra, ''))
+		FROM information_schema.columns
+		WHERE table_schema = ?`, database)
+	if err != nil {
+		return nil, err
+	}
+
+	// Functional key parts are supported since MySQL 8.0.13.
+	keyPart := "column_name"
+	if version.atLeast(8, 0, 13) {
+		keyPart = "COALESCE(column_name, expression)"
+	}
+
+	err = b.queryObjects(ctx, objects, SchemaIndex, fmt.Sprintf(`SELECT table_name, index_name, CONCAT(
+			IF(non_unique = 0, 'UNIQUE ', ''), index_type, ' (',
+			GROUP_CONCAT(CONCAT(%s, IF(sub_part IS NULL, '', CONCAT('(', sub_part, ')')))
+				ORDER BY seq_in_index SEPARATOR ', '), ')')
+		FROM information_schema.statistics
+		WHERE table_schema = ?
+		GROUP BY table_name, index_name, non_unique, index_type`, keyPart), database)
+	if err != nil {
+		return nil, err
+	}
+
+	// The referenced schema is not compared, since the expected schema is created in another database.
+	err = b.queryObjects(ctx, objects, SchemaConstraint, `SELECT k.table_name, k.constraint_name, CONCAT(
+			'FOREIGN KEY (', GROUP_CONCAT(k.column_name ORDER BY k.ordinal_position SEPARATOR ', '), ') REFERENCES ',
+			k.referenced_table_name, ' (',
+			GROUP_CONCAT(k.referenced_column_name ORDER BY k.ordinal_position SEPARATOR ', '), ') ON UPDATE ',
+			r.update_rule, ' ON DELETE ', r.delete_rule)
+		FROM information_schema.key_column_usage k
+		JOIN information_schema.referential_constraints r
+			ON r.constraint_schema = k.constraint_schema AND r.constraint_name = k.constraint_name
+				AND r.table_name = k.table_name
+		WHERE k.table_schema = ? AND k.referenced_table_name IS NOT NULL
+		GROUP BY k.table_name, k.constraint_name, k.referenced_table_name, r.update_rule, r.delete_rule`, database)
+	if err != nil {
+		return nil, err
+	}
+
+	// CHECK constraints are enforced since MySQL 8.0.16.
+	if version.atLeast(8, 0, 16) {
+		err = b.queryObjects(ctx, objects, SchemaConstraint, `SELECT t.table_name, t.constraint_name,
+				CONCAT('CHECK ', c.check_clause, IF(t.enforced = 'NO', ' NOT ENFORCED', ''))
+			FROM information_schema.table_constraints t
+			JOIN information_schema.check_constraints c
+				ON c.constraint_schema = t.constraint_schema AND c.constraint_name = t.constraint_name
+			WHERE t.table_schema = ? AND t.constraint_type = 'CHECK'`, database)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Objects of views are not compared.
+	for key := range objects {
+		if _, ok := objects[schemaKey{object: SchemaTable, table: key.table}]; !ok {
+			delete(objects, key)
+		}
+	}
+
+	return objects, nil
+}
+
+// queryObjects adds the objects returned by a query to the objects. The query returns the table name, the object
+// name, and the definition of each object, and takes the database as its argument.
+func (b *MySQLBox) queryObjects(ctx context.Context, objects map[schemaKey]string, object string, query string,
+	database string) error {
+	rows, err := b.db.QueryContext(ctx, query, database)
+	if err != nil {
+		return fmt.Errorf("error reading %s definitions: %w", object, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table, name string
+		var definition sql.NullString
+		err := rows.Scan(&table, &name, &definition)
+		if err != nil {
+			return err
+		}
+
+		objects[schemaKey{object: object, table: table, name: name}] = definition.String
+	}
+
+	return rows.Err()
+}
+
+// diffSchemaObjects returns the differences between the expected and actual objects, ordered by table, kind of
+// object, and name. The objects of a table that is missing or not expected are not listed separately.
+func diffSchemaObjects(want map[schemaKey]string, got map[schemaKey]string) []SchemaDifference {
+	keys := map[schemaKey]bool{}
+	for key := range want {
+		keys[key] = true
+	}
+	for key := range got {
+		keys[key] = true
+	}
+
+	var diffs []SchemaDifference
+	for key := range keys {
+		expected, inWant := want[key]
+		actual, inGot := got[key]
+		if inWant && inGot && expected == actual {
+			continue
+		}
+
+		if key.object != SchemaTable {
+			tableKey := schemaKey{object: SchemaTable, table: key.table}
+			_, tableInWant := want[tableKey]
+			_, tableInGot := got[tableKey]
+			if !tableInWant || !tableInGot {
+				continue
+			}
+		}
+
+		diffs = append(diffs, SchemaDifference{
+			Object:   key.object,
+			Table:    key.table,
+			Name:     key.name,
+			Expected: expected,
+			Actual:   actual,
+		})
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		a, b := diffs[i], diffs[j]
+		if a.Table != b.Table {
+			return a.Table < b.Table
+		}
+		if a.Object != b.Object {
+			return schemaObjectOrder[a.Object] < schemaObjectOrder[b.Object]
+		}
+
+		return a.Name < b.Name
+	})
+
+	return diffs
+}
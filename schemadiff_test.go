@@ -0,0 +1,45 @@
+package mysqlbox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffSchemaObjects(t *testing.T) {
+	want := map[schemaKey]string{
+		{object: SchemaTable, table: "users"}:                    "ENGINE=InnoDB COLLATE=utf8mb4_0900_ai_ci",
+		{object: SchemaColumn, table: "users", name: "id"}:       "int NOT NULL",
+		{object: SchemaColumn, table: "users", name: "email"}:    "varchar(128) NOT NULL",
+		{object: SchemaIndex, table: "users", name: "PRIMARY"}:   "UNIQUE BTREE (id)",
+		{object: SchemaTable, table: "orders"}:                   "ENGINE=InnoDB COLLATE=utf8mb4_0900_ai_ci",
+		{object: SchemaColumn, table: "orders", name: "id"}:      "int NOT NULL",
+		{object: SchemaIndex, table: "users", name: "email_idx"}: "UNIQUE BTREE (email)",
+	}
+	got := map[schemaKey]string{
+		{object: SchemaTable, table: "users"}:                   "ENGINE=InnoDB COLLATE=utf8mb4_0900_ai_ci",
+		{object: SchemaColumn, table: "users", name: "id"}:      "int NOT NULL",
+		{object: SchemaColumn, table: "users", name: "email"}:   "varchar(64) NULL",
+		{object: SchemaColumn, table: "users", name: "name"}:    "varchar(64) NULL",
+		{object: SchemaIndex, table: "users", name: "PRIMARY"}:  "UNIQUE BTREE (id)",
+		{object: SchemaTable, table: "events"}:                  "ENGINE=InnoDB COLLATE=utf8mb4_0900_ai_ci",
+		{object: SchemaColumn, table: "events", name: "id"}:     "int NOT NULL",
+		{object: SchemaIndex, table: "events", name: "PRIMARY"}: "UNIQUE BTREE (id)",
+	}
+
+	diffs := diffSchemaObjects(want, got)
+
+	lines := make([]string, len(diffs))
+	for n, diff := range diffs {
+		lines[n] = diff.String()
+	}
+	require.Equal(t, []string{
+		"table events: unexpected ENGINE=InnoDB COLLATE=utf8mb4_0900_ai_ci",
+		"table orders: missing, expected ENGINE=InnoDB COLLATE=utf8mb4_0900_ai_ci",
+		"column users.email: expected varchar(128) NOT NULL, got varchar(64) NULL",
+		"column users.name: unexpected varchar(64) NULL",
+		"index users.email_idx: missing, expected UNIQUE BTREE (email)",
+	}, lines)
+
+	require.Empty(t, diffSchemaObjects(want, want))
+}
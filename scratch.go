@@ -0,0 +1,68 @@
+package mysqlbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+)
+
+// ScratchDB creates a uniquely named empty database for the test and returns a DB connected to it. The database is
+// dropped when the test finishes. Combined with Config.ReadOnlyDatabase, it gives tests write isolation while they
+// share the seeded fixture data, which can be read by qualifying table names with the Database name
+// (e.g. "SELECT * FROM testing.users").
+func (b *MySQLBox) ScratchDB(t testing.TB) *sql.DB {
+	t.Helper()
+
+	name := fmt.Sprintf("scratch_%s", randStr(8))
+	db, _, err := b.CreateDatabase(name)
+	if err != nil {
+		t.Fatalf("error creating scratch database: %s", err.Error())
+	}
+
+	t.Cleanup(func() {
+		db.Close()
+		err := b.dropDatabase(context.Background(), name)
+		if err != nil {
+			t.Errorf("error dropping database %s: %s", name, err.Error())
+		}
+	})
+
+	return db
+}
+
+// setDatabaseReadOnly enables or disables the read-only option of the Database.
+func (b *MySQLBox) setDatabaseReadOnly(ctx context.Context, readOnly bool) error {
+	value := 0
+	if readOnly {
+		value = 1
+	}
+
+	query := fmt.Sprintf("ALTER DATABASE %s READ ONLY = %d", quoteIdent(b.databaseName), value)
+	_, err := b.db.ExecContext(ctx, query)
+
+	return err
+}
+
+// TempTable creates a uniquely named table in the Database for the test and returns its name. ddl contains the
+// column and index definitions of the table, e.g. "id INT NOT NULL PRIMARY KEY, name VARCHAR(64)". The table is
+// dropped when the test finishes.
+func (b *MySQLBox) TempTable(t testing.TB, ddl string) string {
+	t.Helper()
+
+	name := fmt.Sprintf("tmp_%s", randomID())
+	query := fmt.Sprintf("CREATE TABLE %s (%s)", quoteIdent(name), ddl)
+	_, err := b.db.Exec(query)
+	if err != nil {
+		t.Fatalf("error creating temporary table: %s", err.Error())
+	}
+
+	t.Cleanup(func() {
+		_, err := b.db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", quoteIdent(name)))
+		if err != nil {
+			t.Errorf("error dropping table %s: %s", name, err.Error())
+		}
+	})
+
+	return name
+}
@@ -0,0 +1,302 @@
+package mysqlbox
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultForbiddenStatements are the statements reported by LintScript() when ScriptLintConfig.ForbiddenStatements
+// is not set. They drop the system schemas or stop the server, which breaks the container instead of failing a
+// statement.
+var DefaultForbiddenStatements = []string{
+	"(?i)^DROP\\s+(DATABASE|SCHEMA)\\s+(IF\\s+EXISTS\\s+)?`?(mysql|sys|information_schema|performance_schema)`?$",
+	`(?i)^SHUTDOWN$`,
+}
+
+// ScriptLintConfig contains settings for LintScript() and Config.LintInitialSQL.
+type ScriptLintConfig struct {
+	// ForbiddenStatements contains regular expressions of statements that must not be in the script. The
+	// expressions are matched against each statement without comments and its delimiter, with whitespace collapsed
+	// to single spaces. The default is DefaultForbiddenStatements.
+	ForbiddenStatements []string
+
+	// MaxStatements is the maximum number of statements in the script. If zero, the number is not limited.
+	MaxStatements int
+}
+
+// ScriptIssue is a problem found in an SQL script by LintScript().
+type ScriptIssue struct {
+	// Line is the line of the script where the issue starts, counted from 1.
+	Line int
+
+	// Message describes the issue.
+	Message string
+}
+
+// String returns the issue in the form "line N: message".
+func (i ScriptIssue) String() string {
+	return fmt.Sprintf("line %d: %s", i.Line, i.Message)
+}
+
+// scriptStatement is a statement of an SQL script.
+type scriptStatement struct {
+	line int
+	text string
+}
+
+// LintScript checks an SQL script in the syntax of the mysql client for problems that are found without running it:
+// unterminated strings, quoted identifiers and comments, unbalanced parentheses, a DELIMITER command that is not
+// reset to ";", forbidden statements, and too many statements. The issues are returned in the order of their lines.
+// A script without issues returns an empty list. If c is nil, the default settings are used.
+func LintScript(script []byte, c *ScriptLintConfig) ([]ScriptIssue, error) {
+	if c == nil {
+		c = &ScriptLintConfig{}
+	}
+
+	patterns := c.ForbiddenStatements
+	if patterns == nil {
+		patterns = DefaultForbiddenStatements
+	}
+
+	forbidden := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid forbidden statement pattern %q: %w", pattern, err)
+		}
+		forbidden = append(forbidden, re)
+	}
+
+	statements, issues := splitScript(string(script))
+
+	for n, stmt := range statements {
+		if c.MaxStatements > 0 && n == c.MaxStatements {
+			message := fmt.Sprintf("script has %d statements, more than the maximum of %d", len(statements),
+				c.MaxStatements)
+			issues = append(issues, ScriptIssue{Line: stmt.line, Message: message})
+		}
+
+		if depth := parenthesisDepth(stmt.text); depth != 0 {
+			issues = append(issues, ScriptIssue{Line: stmt.line, Message: "unbalanced parentheses"})
+		}
+
+		normalized := strings.Join(strings.Fields(stmt.text), " ")
+		for _, re := range forbidden {
+			if re.MatchString(normalized) {
+				issues = append(issues, ScriptIssue{
+					Line:    stmt.line,
+					Message: fmt.Sprintf("forbidden statement: %s", normalized),
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Line < issues[j].Line
+	})
+
+	return issues, nil
+}
+
+// lintInitialSQLs checks the initial scripts with LintScript() and returns an error listing the issues.
+func lintInitialSQLs(scripts [][]byte, c *ScriptLintConfig) error {
+	var problems []string
+	for n, script := range scripts {
+		issues, err := LintScript(script, c)
+		if err != nil {
+			return err
+		}
+
+		for _, issue := range issues {
+			problems = append(problems, fmt.Sprintf("initial SQL script %d: %s", n+1, issue.String()))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("initial SQL lint failed: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// splitScript splits a script into statements with the delimiters of the mysql client, and returns the issues found
+// while splitting. The text of the statements does not contain comments, and quoted strings are kept as they are.
+func splitScript(script string) ([]scriptStatement, []ScriptIssue) {
+	var statements []scriptStatement
+	var issues []ScriptIssue
+
+	delimiter := ";"
+	delimiterLine := 0
+	line := 1
+	var current strings.Builder
+	startLine := 0
+
+	flush := func() {
+		text := strings.TrimSpace(current.String())
+		if text != "" {
+			statements = append(statements, scriptStatement{line: startLine, text: text})
+		}
+		current.Reset()
+		startLine = 0
+	}
+
+	for pos := 0; pos < len(script); {
+		rest := script[pos:]
+
+		// The DELIMITER command is only recognized at the start of a statement.
+		if strings.TrimSpace(current.String()) == "" && isDelimiterCommand(rest) {
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+
+			fields := strings.Fields(rest[:end])
+			if len(fields) < 2 {
+				issues = append(issues, ScriptIssue{Line: line, Message: "DELIMITER command without a delimiter"})
+			} else {
+				delimiter = fields[1]
+				delimiterLine = line
+			}
+
+			current.Reset()
+			pos += end
+			continue
+		}
+
+		c := rest[0]
+		switch {
+		case strings.HasPrefix(rest, delimiter):
+			flush()
+			pos += len(delimiter)
+			continue
+
+		case c == '\'' || c == '"' || c == '`':
+			end, lines, ok := quotedEnd(rest, c)
+			if !ok {
+				issues = append(issues, ScriptIssue{Line: line, Message: fmt.Sprintf("unterminated %s", quoteName(c))})
+				return statements, issues
+			}
+
+			if startLine == 0 {
+				startLine = line
+			}
+			current.WriteString(rest[:end])
+			line += lines
+			pos += end
+			continue
+
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				issues = append(issues, ScriptIssue{Line: line, Message: "unterminated comment"})
+				return statements, issues
+			}
+
+			comment := rest[:end+4]
+			line += strings.Count(comment, "\n")
+			current.WriteByte(' ')
+			pos += len(comment)
+			continue
+
+		case c == '#' || strings.HasPrefix(rest, "-- ") || strings.HasPrefix(rest, "--\t") ||
+			strings.HasPrefix(rest, "--\n") || rest == "--":
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			pos += end
+			continue
+		}
+
+		if c == '\n' {
+			line++
+		} else if startLine == 0 && c != ' ' && c != '\t' && c != '\r' {
+			startLine = line
+		}
+		current.WriteByte(c)
+		pos++
+	}
+
+	flush()
+
+	if delimiter != ";" {
+		issues = append(issues, ScriptIssue{
+			Line:    delimiterLine,
+			Message: fmt.Sprintf("DELIMITER %s is not reset to ;", delimiter),
+		})
+	}
+
+	return statements, issues
+}
+
+// isDelimiterCommand reports whether the text starts with the DELIMITER command of the mysql client.
+func isDelimiterCommand(text string) bool {
+	if len(text) < len("DELIMITER") || !strings.EqualFold(text[:len("DELIMITER")], "DELIMITER") {
+		return false
+	}
+
+	return len(text) == len("DELIMITER") || strings.ContainsRune(" \t\r\n", rune(text[len("DELIMITER")]))
+}
+
+// quotedEnd returns the position after the closing quote of a quoted string or identifier that starts the text, and
+// the number of line breaks in it. Quotes are escaped by doubling them, and by a backslash in strings.
+func quotedEnd(text string, quote byte) (int, int, bool) {
+	lines := 0
+	for n := 1; n < len(text); n++ {
+		switch text[n] {
+		case '\n':
+			lines++
+		case '\\':
+			if quote != '`' {
+				n++
+				if n < len(text) && text[n] == '\n' {
+					lines++
+				}
+			}
+		case quote:
+			if n+1 < len(text) && text[n+1] == quote {
+				n++
+				continue
+			}
+			return n + 1, lines, true
+		}
+	}
+
+	return 0, 0, false
+}
+
+// quoteName returns the name of the quoted token that starts with the quote.
+func quoteName(quote byte) string {
+	if quote == '`' {
+		return "quoted identifier"
+	}
+
+	return "string"
+}
+
+// parenthesisDepth returns the number of opening parentheses in a statement that are not closed, or a negative number
+// if there are more closing parentheses. Parentheses in quoted strings and identifiers are ignored.
+func parenthesisDepth(text string) int {
+	depth := 0
+	for n := 0; n < len(text); n++ {
+		switch c := text[n]; c {
+		case '\'', '"', '`':
+			end, _, ok := quotedEnd(text[n:], c)
+			if !ok {
+				return depth
+			}
+			n += end - 1
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return depth
+			}
+		}
+	}
+
+	return depth
+}
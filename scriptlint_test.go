@@ -0,0 +1,100 @@
+package mysqlbox
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintScript(t *testing.T) {
+	t.Run("valid scripts", func(t *testing.T) {
+		for _, file := range []string{"testdata/schema.sql", "testdata/cli-script.sql"} {
+			script, err := os.ReadFile(file)
+			require.NoError(t, err)
+
+			issues, err := LintScript(script, nil)
+			require.NoError(t, err)
+			require.Empty(t, issues, file)
+		}
+	})
+
+	t.Run("delimiter", func(t *testing.T) {
+		script := `CREATE TABLE t (id INT);
+DELIMITER //
+CREATE TRIGGER t_bi BEFORE INSERT ON t FOR EACH ROW
+BEGIN
+  SET NEW.id = NEW.id + 1;
+END//
+DELIMITER ;
+INSERT INTO t VALUES (1);
+`
+		issues, err := LintScript([]byte(script), nil)
+		require.NoError(t, err)
+		require.Empty(t, issues)
+
+		statements, _ := splitScript(script)
+		require.Len(t, statements, 3)
+		require.Equal(t, 3, statements[1].line)
+		require.Equal(t, 8, statements[2].line)
+	})
+
+	t.Run("delimiter not reset", func(t *testing.T) {
+		issues, err := LintScript([]byte("SELECT 1;\nDELIMITER $$\nSELECT 2$$\n"), nil)
+		require.NoError(t, err)
+		require.Equal(t, []ScriptIssue{{Line: 2, Message: "DELIMITER $$ is not reset to ;"}}, issues)
+	})
+
+	t.Run("unterminated string", func(t *testing.T) {
+		issues, err := LintScript([]byte("SELECT 'a;\n';\nINSERT INTO t VALUES ('it''s', \"x);\n"), nil)
+		require.NoError(t, err)
+		require.Equal(t, []ScriptIssue{{Line: 3, Message: "unterminated string"}}, issues)
+	})
+
+	t.Run("unterminated comment", func(t *testing.T) {
+		issues, err := LintScript([]byte("SELECT 1;\n\n/* comment\nSELECT 2;\n"), nil)
+		require.NoError(t, err)
+		require.Equal(t, []ScriptIssue{{Line: 3, Message: "unterminated comment"}}, issues)
+	})
+
+	t.Run("unbalanced parentheses", func(t *testing.T) {
+		script := "-- users (\nCREATE TABLE users (\n  id INT,\n  name VARCHAR(10) DEFAULT ')'\n;\nSELECT (1));\n"
+		issues, err := LintScript([]byte(script), nil)
+		require.NoError(t, err)
+		require.Equal(t, []ScriptIssue{
+			{Line: 2, Message: "unbalanced parentheses"},
+			{Line: 6, Message: "unbalanced parentheses"},
+		}, issues)
+	})
+
+	t.Run("forbidden statements", func(t *testing.T) {
+		script := "DROP DATABASE testing;\n# drop it\nDROP  DATABASE\n  IF EXISTS `mysql`;\nshutdown;\n"
+		issues, err := LintScript([]byte(script), nil)
+		require.NoError(t, err)
+		require.Equal(t, []ScriptIssue{
+			{Line: 3, Message: "forbidden statement: DROP DATABASE IF EXISTS `mysql`"},
+			{Line: 5, Message: "forbidden statement: shutdown"},
+		}, issues)
+
+		issues, err = LintScript([]byte(script), &ScriptLintConfig{ForbiddenStatements: []string{`^DROP DATABASE testing$`}})
+		require.NoError(t, err)
+		require.Equal(t, []ScriptIssue{{Line: 1, Message: "forbidden statement: DROP DATABASE testing"}}, issues)
+
+		_, err = LintScript([]byte(script), &ScriptLintConfig{ForbiddenStatements: []string{"("}})
+		require.Error(t, err)
+	})
+
+	t.Run("max statements", func(t *testing.T) {
+		issues, err := LintScript([]byte("SELECT 1;\nSELECT 2;\nSELECT 3;\n"), &ScriptLintConfig{MaxStatements: 2})
+		require.NoError(t, err)
+		require.Equal(t, []ScriptIssue{{Line: 3, Message: "script has 3 statements, more than the maximum of 2"}},
+			issues)
+	})
+}
+
+func TestLintInitialSQLs(t *testing.T) {
+	require.NoError(t, lintInitialSQLs([][]byte{[]byte("SELECT 1;")}, &ScriptLintConfig{}))
+
+	err := lintInitialSQLs([][]byte{[]byte("SELECT 1;"), []byte("\nSELECT 'a;")}, &ScriptLintConfig{})
+	require.EqualError(t, err, "initial SQL lint failed: initial SQL script 2: line 2: unterminated string")
+}
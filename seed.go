@@ -0,0 +1,221 @@
+package mysqlbox
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQL error numbers of constraint violations.
+const (
+	errDupEntry         = 1062
+	errBadNull          = 1048
+	errRowIsReferenced  = 1451
+	errNoReferencedRow  = 1452
+	errCheckConstraint  = 3819
+	errNoReferencedRow2 = 1216
+	errRowIsReferenced2 = 1217
+)
+
+var (
+	dupEntryRe        = regexp.MustCompile(`^Duplicate entry '(.*)' for key '(.+)'$`)
+	foreignKeyRe      = regexp.MustCompile("a foreign key constraint fails \\(`([^`]+)`\\.`([^`]+)`, CONSTRAINT `([^`]+)` FOREIGN KEY \\(([^)]*)\\)")
+	badNullRe         = regexp.MustCompile(`^Column '(.+)' cannot be null$`)
+	checkConstraintRe = regexp.MustCompile(`^Check constraint '(.+)' is violated\.$`)
+)
+
+// ConstraintError describes a constraint violation that occurred while seeding data.
+type ConstraintError struct {
+	// Number is the MySQL error number.
+	Number uint16
+
+	// Table is the name of the table where the violation occurred, if known.
+	Table string
+
+	// Constraint is the name of the violated index or constraint, if known.
+	Constraint string
+
+	// Columns contains the columns of the violated constraint, if known.
+	Columns []string
+
+	// Values contains the conflicting key values for duplicate entries.
+	Values string
+
+	// Err is the original driver error.
+	Err error
+}
+
+// Error returns the error message.
+func (e *ConstraintError) Error() string {
+	var parts []string
+	if e.Table != "" {
+		parts = append(parts, fmt.Sprintf("table %s", e.Table))
+	}
+	if e.Constraint != "" {
+		parts = append(parts, fmt.Sprintf("constraint %s", e.Constraint))
+	}
+	if len(e.Columns) > 0 {
+		parts = append(parts, fmt.Sprintf("columns (%s)", strings.Join(e.Columns, ", ")))
+	}
+	if e.Values != "" {
+		parts = append(parts, fmt.Sprintf("values (%s)", e.Values))
+	}
+
+	return fmt.Sprintf("constraint violation [%s]: %s", strings.Join(parts, ", "), e.Err.Error())
+}
+
+// Unwrap returns the original driver error.
+func (e *ConstraintError) Unwrap() error {
+	return e.Err
+}
+
+// SeedTx calls fn with a transaction for seeding data. The transaction is committed if fn returns nil, and rolled
+// back otherwise. fn may use SAVEPOINT statements to partially roll back its own work. If fn fails because of a
+// constraint violation (duplicate key, foreign key, NOT NULL, or CHECK), the returned error is a *ConstraintError
+// that identifies the failing table, index, and values.
+func (b *MySQLBox) SeedTx(fn func(tx *sql.Tx) error) error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	tx, err := b.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	err = fn(tx)
+	if err != nil {
+		_ = tx.Rollback()
+		return constraintError(err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return constraintError(err)
+	}
+
+	return nil
+}
+
+// MustSeedTx calls fn with a transaction for seeding data.
+func (b *MySQLBox) MustSeedTx(fn func(tx *sql.Tx) error) {
+	err := b.SeedTx(fn)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Seed inserts rows into a table of the Database. Each row maps column names to values, and the INSERT statements
+// use query arguments, so values do not need to be escaped:
+//
+//	box.MustSeed("users", []map[string]interface{}{
+//		{"id": "U-1", "email": "alice@example.com", "created_at": "2021-01-01 00:00:00"},
+//		{"id": "U-2", "email": "bob@example.com", "created_at": time.Now()},
+//	})
+//
+// Values are converted according to the column types like LoadFixtures() does: strings are parsed as timestamps for
+// date and time columns, strings prefixed with "base64:" are decoded for binary columns, and maps and lists are
+// encoded for JSON columns. A nil value inserts NULL. The rows are inserted in a single transaction with foreign key
+// checks disabled, and if a row violates a unique, NOT NULL, or CHECK constraint, no rows are inserted and the
+// returned error wraps a *ConstraintError.
+func (b *MySQLBox) Seed(table string, rows []map[string]interface{}) error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	return b.SeedTables(Fixture{Table: table, Rows: rows})
+}
+
+// MustSeed inserts rows into a table of the Database.
+func (b *MySQLBox) MustSeed(table string, rows []map[string]interface{}) {
+	err := b.Seed(table, rows)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// SeedTables inserts the rows of multiple tables into the Database in a single transaction, with the same value
+// conversions as Seed(). Foreign key checks are disabled while the rows are inserted, so the tables can be listed in
+// any order.
+func (b *MySQLBox) SeedTables(fixtures ...Fixture) error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	for _, fixture := range fixtures {
+		if fixture.Table == "" {
+			return errors.New("table name is empty")
+		}
+	}
+
+	return b.insertFixtures(fixtures)
+}
+
+// MustSeedTables inserts the rows of multiple tables into the Database in a single transaction.
+func (b *MySQLBox) MustSeedTables(fixtures ...Fixture) {
+	err := b.SeedTables(fixtures...)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// constraintError converts a MySQL constraint violation error to a *ConstraintError. Other errors are returned
+// unchanged.
+func constraintError(err error) error {
+	var myErr *mysql.MySQLError
+	if !errors.As(err, &myErr) {
+		return err
+	}
+
+	cerr := &ConstraintError{
+		Number: myErr.Number,
+		Err:    err,
+	}
+
+	switch myErr.Number {
+	case errDupEntry:
+		m := dupEntryRe.FindStringSubmatch(myErr.Message)
+		if m == nil {
+			return cerr
+		}
+		cerr.Values = m[1]
+
+		// Since MySQL 8.0.19 the key name is prefixed with the table name.
+		key := m[2]
+		if i := strings.LastIndex(key, "."); i >= 0 {
+			cerr.Table = key[:i]
+			key = key[i+1:]
+		}
+		cerr.Constraint = key
+	case errNoReferencedRow, errRowIsReferenced, errNoReferencedRow2, errRowIsReferenced2:
+		m := foreignKeyRe.FindStringSubmatch(myErr.Message)
+		if m == nil {
+			return cerr
+		}
+		cerr.Table = m[2]
+		cerr.Constraint = m[3]
+		for _, column := range strings.Split(m[4], ",") {
+			cerr.Columns = append(cerr.Columns, strings.Trim(strings.TrimSpace(column), "`"))
+		}
+	case errBadNull:
+		m := badNullRe.FindStringSubmatch(myErr.Message)
+		if m == nil {
+			return cerr
+		}
+		cerr.Columns = []string{m[1]}
+	case errCheckConstraint:
+		m := checkConstraintRe.FindStringSubmatch(myErr.Message)
+		if m == nil {
+			return cerr
+		}
+		cerr.Constraint = m[1]
+	default:
+		return err
+	}
+
+	return cerr
+}
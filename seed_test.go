@@ -0,0 +1,68 @@
+package mysqlbox
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConstraintError(t *testing.T) {
+	t.Run("duplicate_entry", func(t *testing.T) {
+		err := constraintError(&mysql.MySQLError{
+			Number:  1062,
+			Message: "Duplicate entry 'user1@example.com' for key 'users.users_email_uindex'",
+		})
+
+		var cerr *ConstraintError
+		require.True(t, errors.As(err, &cerr))
+		require.Equal(t, "users", cerr.Table)
+		require.Equal(t, "users_email_uindex", cerr.Constraint)
+		require.Equal(t, "user1@example.com", cerr.Values)
+	})
+
+	t.Run("duplicate_entry_without_table", func(t *testing.T) {
+		err := constraintError(&mysql.MySQLError{
+			Number:  1062,
+			Message: "Duplicate entry '1-2' for key 'PRIMARY'",
+		})
+
+		var cerr *ConstraintError
+		require.True(t, errors.As(err, &cerr))
+		require.Empty(t, cerr.Table)
+		require.Equal(t, "PRIMARY", cerr.Constraint)
+		require.Equal(t, "1-2", cerr.Values)
+	})
+
+	t.Run("foreign_key", func(t *testing.T) {
+		err := constraintError(fmt.Errorf("insert book: %w", &mysql.MySQLError{
+			Number: 1452,
+			Message: "Cannot add or update a child row: a foreign key constraint fails (`testing`.`books`, " +
+				"CONSTRAINT `books_author_fk` FOREIGN KEY (`author_id`) REFERENCES `authors` (`id`))",
+		}))
+
+		var cerr *ConstraintError
+		require.True(t, errors.As(err, &cerr))
+		require.Equal(t, "books", cerr.Table)
+		require.Equal(t, "books_author_fk", cerr.Constraint)
+		require.Equal(t, []string{"author_id"}, cerr.Columns)
+	})
+
+	t.Run("not_null", func(t *testing.T) {
+		err := constraintError(&mysql.MySQLError{
+			Number:  1048,
+			Message: "Column 'email' cannot be null",
+		})
+
+		var cerr *ConstraintError
+		require.True(t, errors.As(err, &cerr))
+		require.Equal(t, []string{"email"}, cerr.Columns)
+	})
+
+	t.Run("other_error", func(t *testing.T) {
+		orig := &mysql.MySQLError{Number: 1146, Message: "Table 'testing.sales' doesn't exist"}
+		require.Equal(t, orig, constraintError(orig))
+	})
+}
@@ -0,0 +1,206 @@
+package mysqlbox
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// SeedReport describes the test data of the Database: the scripts and fixtures that were loaded, and the tables with
+// their row counts and checksums. Comparing reports, e.g. from a local run and a CI run, shows why a test sees
+// different data.
+type SeedReport struct {
+	// Database is the name of the Database.
+	Database string `json:"database"`
+
+	// CreatedAt is the time the report was created.
+	CreatedAt time.Time `json:"created_at"`
+
+	// Sources contains the initial SQL scripts, and the fixtures and dataset profiles loaded since the box was started
+	// or last Reset(), in the order they were loaded.
+	Sources []SeedSource `json:"sources"`
+
+	// Tables contains the base tables of the Database ordered by name.
+	Tables []SeedTable `json:"tables"`
+}
+
+// SeedSource is a script, fixture file, or dataset profile loaded into the Database.
+type SeedSource struct {
+	// Kind is the kind of the source: "initial_sql", "fixtures" (LoadFixtures()), or "profile"
+	// (LoadProfile()).
+	Kind string `json:"kind"`
+
+	// Name is the path of a fixture file, the number of an initial SQL script, or the name and scale of a profile.
+	Name string `json:"name"`
+
+	// SHA256 is the hex-encoded SHA-256 checksum of the script or fixture file contents. It is blank for profiles.
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// SeedTable is a table of the Database in a SeedReport.
+type SeedTable struct {
+	// Name is the name of the table.
+	Name string `json:"name"`
+
+	// Rows is the number of rows in the table.
+	Rows int64 `json:"rows"`
+
+	// Checksum is the live checksum of the table contents returned by CHECKSUM TABLE. Tables with the same schema and
+	// rows have the same checksum.
+	Checksum int64 `json:"checksum"`
+}
+
+// SeedReport returns a report of the test data of the Database. The row counts and checksums are read when the
+// report is created.
+func (b *MySQLBox) SeedReport() (*SeedReport, error) {
+	if b == nil {
+		return nil, errors.New("mysqlbox is nil")
+	}
+
+	report := &SeedReport{
+		Database:  b.databaseName,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	for n, script := range b.initialSQLs {
+		report.Sources = append(report.Sources, SeedSource{
+			Kind:   "initial_sql",
+			Name:   fmt.Sprintf("initial SQL script %d", n+1),
+			SHA256: sha256Hex(script),
+		})
+	}
+
+	b.seedSourcesMu.Lock()
+	report.Sources = append(report.Sources, b.seedSources...)
+	b.seedSourcesMu.Unlock()
+
+	ctx := context.Background()
+	conn, err := b.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	tables, err := baseTables(ctx, conn, b.databaseName)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(tables)
+
+	for _, name := range tables {
+		table, err := seedTable(ctx, conn, b.databaseName, name)
+		if err != nil {
+			return nil, fmt.Errorf("error reading table %s: %w", name, err)
+		}
+		report.Tables = append(report.Tables, table)
+	}
+
+	return report, nil
+}
+
+// MustSeedReport returns a report of the test data of the Database.
+func (b *MySQLBox) MustSeedReport() *SeedReport {
+	report, err := b.SeedReport()
+	if err != nil {
+		panic(err)
+	}
+
+	return report
+}
+
+// WriteSeedReport writes the SeedReport of the Database as indented JSON to a file, e.g. in the directory of other
+// test artifacts. The parent directories of the file are created if needed.
+func (b *MySQLBox) WriteSeedReport(path string) error {
+	report, err := b.SeedReport()
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755) // #nosec G301
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(content, '\n'), 0644) // #nosec G306
+}
+
+// MustWriteSeedReport writes the SeedReport of the Database as indented JSON to a file.
+func (b *MySQLBox) MustWriteSeedReport(path string) {
+	err := b.WriteSeedReport(path)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// seedTable returns the row count and checksum of a table.
+func seedTable(ctx context.Context, conn *sql.Conn, database string, name string) (SeedTable, error) {
+	table := SeedTable{Name: name}
+	qualified := quoteIdent(database) + "." + quoteIdent(name)
+
+	err := conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+qualified).Scan(&table.Rows)
+	if err != nil {
+		return table, err
+	}
+
+	var checksumTable string
+	var checksum sql.NullInt64
+	err = conn.QueryRowContext(ctx, "CHECKSUM TABLE "+qualified).Scan(&checksumTable, &checksum)
+	if err != nil {
+		return table, err
+	}
+	table.Checksum = checksum.Int64
+
+	return table, nil
+}
+
+// addSeedFiles adds the files to the seed sources of the box with the checksums of their contents.
+func (b *MySQLBox) addSeedFiles(kind string, files []string) error {
+	sources := make([]SeedSource, 0, len(files))
+	for _, file := range files {
+		content, err := os.ReadFile(file) // #nosec G304
+		if err != nil {
+			return err
+		}
+
+		sources = append(sources, SeedSource{Kind: kind, Name: file, SHA256: sha256Hex(content)})
+	}
+
+	b.addSeedSources(sources...)
+
+	return nil
+}
+
+// addSeedSources adds sources to the seed sources of the box.
+func (b *MySQLBox) addSeedSources(sources ...SeedSource) {
+	b.seedSourcesMu.Lock()
+	defer b.seedSourcesMu.Unlock()
+
+	b.seedSources = append(b.seedSources, sources...)
+}
+
+// resetSeedSources removes the seed sources of the box after the Database is recreated.
+func (b *MySQLBox) resetSeedSources() {
+	b.seedSourcesMu.Lock()
+	defer b.seedSourcesMu.Unlock()
+
+	b.seedSources = nil
+}
+
+// sha256Hex returns the hex-encoded SHA-256 checksum of the content.
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
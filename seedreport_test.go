@@ -0,0 +1,30 @@
+package mysqlbox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddSeedFiles(t *testing.T) {
+	b := &MySQLBox{}
+
+	files, err := fixtureFiles("./testdata/fixtures")
+	require.NoError(t, err)
+	require.Equal(t, []string{"testdata/fixtures/categories.csv", "testdata/fixtures/users.yml"}, files)
+
+	require.NoError(t, b.addSeedFiles("fixtures", files))
+	require.Len(t, b.seedSources, 2)
+	require.Equal(t, "fixtures", b.seedSources[0].Kind)
+	require.Equal(t, "testdata/fixtures/categories.csv", b.seedSources[0].Name)
+	require.Len(t, b.seedSources[0].SHA256, 64)
+
+	require.Error(t, b.addSeedFiles("fixtures", []string{"./testdata/missing.yml"}))
+
+	b.resetSeedSources()
+	require.Empty(t, b.seedSources)
+}
+
+func TestSHA256Hex(t *testing.T) {
+	require.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", sha256Hex(nil))
+}
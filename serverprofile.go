@@ -0,0 +1,69 @@
+package mysqlbox
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ServerProfile is a preset of MySQL server settings for a common setup, selected with Config.Profile.
+type ServerProfile struct {
+	// Description describes the setup the profile is for.
+	Description string
+
+	// Args contains the mysqld options of the profile, e.g. "--transaction-isolation=READ-COMMITTED". They are
+	// added after the default options of the box.
+	Args []string
+
+	// Tmpfs stores the MySQL data directory on a tmpfs mount in memory instead of a Docker volume. Data is lost when
+	// the container is stopped, which does not matter for boxes that are removed after the tests.
+	Tmpfs bool
+}
+
+// ServerProfiles contains the server profiles that can be selected with Config.Profile. Profiles can be added to it
+// before Start() is called, e.g. in TestMain. The following profiles are available:
+//
+//   - "fast-ci": trades durability for speed in short-lived CI boxes. The data directory is on tmpfs, the binary
+//     log and performance_schema are disabled, and InnoDB does not flush to disk on commit. Features that read
+//     performance_schema, like Config.KillLockHolders and the statements of the debug UI, do not work.
+//   - "prod-like": catches queries that only fail in production. It uses the strict sql_mode of MySQL 8, the
+//     READ-COMMITTED isolation level, and the utf8mb4 character set.
+var ServerProfiles = map[string]ServerProfile{
+	"fast-ci": {
+		Description: "fast, non-durable server for CI",
+		Args: []string{
+			"--performance-schema=OFF",
+			"--skip-log-bin",
+			"--innodb-flush-log-at-trx-commit=0",
+			"--innodb-doublewrite=0",
+		},
+		Tmpfs: true,
+	},
+	"prod-like": {
+		Description: "strict server with production settings",
+		Args: []string{
+			"--sql-mode=ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES,NO_ZERO_IN_DATE,NO_ZERO_DATE," +
+				"ERROR_FOR_DIVISION_BY_ZERO,NO_ENGINE_SUBSTITUTION",
+			"--transaction-isolation=READ-COMMITTED",
+			"--character-set-server=utf8mb4",
+			"--collation-server=utf8mb4_0900_ai_ci",
+		},
+	},
+}
+
+// serverProfile returns the server profile with the name.
+func serverProfile(name string) (ServerProfile, error) {
+	profile, ok := ServerProfiles[name]
+	if !ok {
+		names := make([]string, 0, len(ServerProfiles))
+		for name := range ServerProfiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		return profile, fmt.Errorf("unknown server profile %q, available profiles: %s", name,
+			strings.Join(names, ", "))
+	}
+
+	return profile, nil
+}
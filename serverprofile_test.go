@@ -0,0 +1,17 @@
+package mysqlbox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerProfile(t *testing.T) {
+	profile, err := serverProfile("fast-ci")
+	require.NoError(t, err)
+	require.True(t, profile.Tmpfs)
+	require.Contains(t, profile.Args, "--skip-log-bin")
+
+	_, err = serverProfile("slow")
+	require.EqualError(t, err, `unknown server profile "slow", available profiles: fast-ci, prod-like`)
+}
@@ -0,0 +1,239 @@
+package mysqlbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// SidecarOptions contains settings for RunSidecar.
+type SidecarOptions struct {
+	// Cmd is the command of the container. If empty, the default command of the image is run.
+	Cmd []string
+
+	// Entrypoint overrides the entrypoint of the image.
+	Entrypoint []string
+
+	// Binds contains the volumes mounted in the container, in the "host-path:container-path[:ro]" format of docker
+	// run -v, e.g. to mount the configuration files of the service.
+	Binds []string
+}
+
+// SidecarResult is the output of a sidecar container that ran to completion.
+type SidecarResult struct {
+	// Stdout is the standard output of the container.
+	Stdout string
+
+	// Stderr is the standard error of the container.
+	Stderr string
+
+	// ExitCode is the exit code of the container.
+	ExitCode int64
+}
+
+// RunSidecar runs a container of the image that connects to the MySQL server of the box, e.g. the service under test
+// or a migration tool, waits for it to exit, and returns its output and exit code. A non-zero exit code is not an
+// error. The container is removed after it exits, and the image is pulled if it is not available locally.
+//
+// If the box is connected to Config.Network, the container is started on the same network. Otherwise it shares the
+// network namespace of the MySQL container. The connection settings are passed in the MYSQL_HOST, MYSQL_PORT,
+// MYSQL_USER, MYSQL_PASSWORD, MYSQL_DATABASE, and MYSQL_DSN (in the Go driver format) environment variables, which
+// can be overridden by env:
+//
+//	result := box.MustRunSidecar(ctx, "ghcr.io/acme/orders-migrate:latest", map[string]string{"LOG_LEVEL": "debug"},
+//		nil)
+//	require.Equal(t, int64(0), result.ExitCode, result.Stderr)
+func (b *MySQLBox) RunSidecar(ctx context.Context, image string, env map[string]string,
+	opts *SidecarOptions) (*SidecarResult, error) {
+	if b == nil {
+		return nil, errors.New("mysqlbox is nil")
+	}
+
+	if opts == nil {
+		opts = &SidecarOptions{}
+	}
+
+	host := "127.0.0.1"
+	hostCfg := &container.HostConfig{
+		NetworkMode: container.NetworkMode("container:" + b.containerID),
+		Binds:       opts.Binds,
+	}
+	var netCfg *network.NetworkingConfig
+	if b.network != "" {
+		host = b.containerName
+		if len(b.networkAliases) > 0 {
+			host = b.networkAliases[0]
+		}
+
+		hostCfg.NetworkMode = container.NetworkMode(b.network)
+		netCfg = &network.NetworkingConfig{
+			EndpointsConfig: map[string]*network.EndpointSettings{b.network: {}},
+		}
+	}
+
+	mysqlCfg := newMySQLConfig(host, 3306, b.databaseName, b.rootPassword, "")
+	if b.tls != nil {
+		// The generated server certificate is only valid for 127.0.0.1 and localhost.
+		mysqlCfg.TLSConfig = "skip-verify"
+	}
+
+	vars := map[string]string{
+		"MYSQL_HOST":     host,
+		"MYSQL_PORT":     "3306",
+		"MYSQL_USER":     "root",
+		"MYSQL_PASSWORD": b.rootPassword,
+		"MYSQL_DATABASE": b.databaseName,
+		"MYSQL_DSN":      mysqlCfg.FormatDSN(),
+	}
+	for name, value := range env {
+		vars[name] = value
+	}
+
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	cfg := &container.Config{
+		Image:      image,
+		Cmd:        opts.Cmd,
+		Entrypoint: opts.Entrypoint,
+		Labels:     containerLabels(),
+	}
+	for _, name := range names {
+		cfg.Env = append(cfg.Env, name+"="+vars[name])
+	}
+
+	id, err := b.createContainer(ctx, cfg, hostCfg, netCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.runContainer(ctx, id)
+}
+
+// MustRunSidecar runs a container of the image that connects to the MySQL server of the box and returns its output and
+// exit code.
+func (b *MySQLBox) MustRunSidecar(ctx context.Context, image string, env map[string]string,
+	opts *SidecarOptions) *SidecarResult {
+	result, err := b.RunSidecar(ctx, image, env, opts)
+	if err != nil {
+		panic(err)
+	}
+
+	return result
+}
+
+// runSidecar runs a command in a container of the image that shares the network namespace of the MySQL container,
+// so that the MySQL server is reachable at 127.0.0.1:3306. It waits for the container to exit and returns its output.
+// The image is pulled if it is not available locally. The container is removed after it exits.
+func (b *MySQLBox) runSidecar(ctx context.Context, image string, cmd []string) (*SidecarResult, error) {
+	id, err := b.createSidecar(ctx, &container.Config{Image: image, Cmd: cmd}, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.runContainer(ctx, id)
+}
+
+// runContainer starts a created container, waits for it to exit, and returns its output. The container is removed
+// after it exits.
+func (b *MySQLBox) runContainer(ctx context.Context, id string) (*SidecarResult, error) {
+	defer func() {
+		_ = b.cli.ContainerRemove(context.Background(), id, types.ContainerRemoveOptions{Force: true})
+	}()
+
+	// Wait before starting the container so that a quick exit is not missed.
+	waitCh, waitErrCh := b.cli.ContainerWait(ctx, id, container.WaitConditionNextExit)
+
+	err := b.retry.startContainer(ctx, b.cli, id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SidecarResult{}
+	select {
+	case status := <-waitCh:
+		if status.Error != nil {
+			return nil, errors.New(status.Error.Message)
+		}
+		result.ExitCode = status.StatusCode
+	case err := <-waitErrCh:
+		return nil, err
+	}
+
+	logs, err := b.cli.ContainerLogs(ctx, id, types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true})
+	if err != nil {
+		return nil, err
+	}
+	defer logs.Close()
+
+	var stdout, stderr bytes.Buffer
+	_, err = stdcopy.StdCopy(&stdout, &stderr, logs)
+	if err != nil {
+		return nil, err
+	}
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+
+	return result, nil
+}
+
+// createSidecar creates a container that shares the network namespace of the MySQL container and returns its ID.
+// The labels of the MySQL container are added to cfg. The image is pulled if it is not available locally. If
+// autoRemove is set, the container is removed by Docker when it exits.
+func (b *MySQLBox) createSidecar(ctx context.Context, cfg *container.Config, autoRemove bool) (string, error) {
+	cfg.Labels = containerLabels()
+
+	hostCfg := &container.HostConfig{
+		AutoRemove:  autoRemove,
+		NetworkMode: container.NetworkMode("container:" + b.containerID),
+	}
+
+	return b.createContainer(ctx, cfg, hostCfg, nil)
+}
+
+// createContainer creates a container and returns its ID. The image is pulled if it is not available locally.
+func (b *MySQLBox) createContainer(ctx context.Context, cfg *container.Config, hostCfg *container.HostConfig,
+	netCfg *network.NetworkingConfig) (string, error) {
+	cfg.Image = b.imageName(cfg.Image)
+
+	created, err := b.retry.createContainer(ctx, b.cli, cfg, hostCfg, netCfg, "")
+	if client.IsErrNotFound(err) {
+		err = pullImage(ctx, b.cli, cfg.Image, b.pull)
+		if err != nil {
+			return "", fmt.Errorf("failed to pull image: %w", err)
+		}
+
+		created, err = b.retry.createContainer(ctx, b.cli, cfg, hostCfg, netCfg, "")
+	}
+	if err != nil {
+		return "", fmt.Errorf("error creating container: %w", err)
+	}
+
+	return created.ID, nil
+}
+
+// containerLabels returns the labels of the containers created by this process, which are used by Cleanup() and the
+// reaper to find them.
+func containerLabels() map[string]string {
+	hostname, _ := os.Hostname()
+
+	return map[string]string{
+		containerLabel: "1",
+		pidLabel:       strconv.Itoa(os.Getpid()),
+		hostnameLabel:  hostname,
+		sessionLabel:   sessionID,
+	}
+}
@@ -0,0 +1,242 @@
+package mysqlbox
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// slowQueryLogFile is the file of the slow query log in the container, see Config.SlowQueryLog.
+const slowQueryLogFile = "/var/lib/mysql/slow-query.log"
+
+// slowLogUserHostPattern matches the "# User@Host:" line of a slow query log entry, e.g.
+// "# User@Host: root[root] @  [172.17.0.1]  Id:     8".
+var slowLogUserHostPattern = regexp.MustCompile(`^# User@Host: ([^\[]*)\[[^\]]*\] @ (\S*) \[([^\]]*)\]\s+Id:\s*(\d+)`)
+
+// slowLogHeaders are the prefixes of the lines the server writes at the top of the slow query log when it starts.
+var slowLogHeaders = []string{"/usr/sbin/mysqld, Version:", "Tcp port:", "Time                 Id Command"}
+
+// SlowQueryLogConfig contains settings for Config.SlowQueryLog.
+type SlowQueryLogConfig struct {
+	// LongQueryTime is the running time from which queries are logged. MySQL supports microsecond precision. If zero,
+	// all queries are logged.
+	LongQueryTime time.Duration
+
+	// QueriesNotUsingIndexes also logs the queries that do not use an index or scan all rows of an index, whatever
+	// their running time. This finds unindexed queries on the small tables of tests, where they are still fast.
+	QueriesNotUsingIndexes bool
+}
+
+// SlowQuery is an entry of the slow query log.
+type SlowQuery struct {
+	// Time is the time the query was logged.
+	Time time.Time
+
+	// User is the user that ran the query.
+	User string
+
+	// Host is the host name or IP address of the client.
+	Host string
+
+	// ConnectionID is the ID of the connection that ran the query.
+	ConnectionID int64
+
+	// Database is the default database of the connection, if it is known.
+	Database string
+
+	// Query is the text of the query.
+	Query string
+
+	// QueryTime is the running time of the query.
+	QueryTime time.Duration
+
+	// LockTime is the time the query waited for locks.
+	LockTime time.Duration
+
+	// RowsSent is the number of rows sent to the client.
+	RowsSent int64
+
+	// RowsExamined is the number of rows read by the server to run the query.
+	RowsExamined int64
+}
+
+// String returns the query with its running time and rows examined.
+func (q SlowQuery) String() string {
+	return fmt.Sprintf("slow query (%s, %d rows examined): %s", q.QueryTime, q.RowsExamined, q.Query)
+}
+
+// slowQueryLogArgs returns the mysqld arguments that enable the slow query log.
+func (c *SlowQueryLogConfig) slowQueryLogArgs() []string {
+	args := []string{
+		"--slow-query-log=1",
+		"--slow-query-log-file=" + slowQueryLogFile,
+		"--long-query-time=" + strconv.FormatFloat(c.LongQueryTime.Seconds(), 'f', 6, 64),
+	}
+
+	if c.QueriesNotUsingIndexes {
+		args = append(args, "--log-queries-not-using-indexes=1")
+	}
+
+	return args
+}
+
+// SlowQueries returns the entries of the slow query log of the MySQL server in the order they were logged. The slow
+// query log must be enabled with Config.SlowQueryLog.
+func (b *MySQLBox) SlowQueries(ctx context.Context) ([]SlowQuery, error) {
+	if b == nil {
+		return nil, errors.New("mysqlbox is nil")
+	}
+
+	if !b.slowQueryLog {
+		return nil, errors.New("slow query log is not enabled, see Config.SlowQueryLog")
+	}
+
+	content, err := b.readContainerFile(ctx, slowQueryLogFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading slow query log: %w", err)
+	}
+
+	return parseSlowQueryLog(content)
+}
+
+// MustSlowQueries returns the entries of the slow query log of the MySQL server.
+func (b *MySQLBox) MustSlowQueries(ctx context.Context) []SlowQuery {
+	queries, err := b.SlowQueries(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	return queries
+}
+
+// parseSlowQueryLog parses the entries of a slow query log. The "use" and "SET timestamp" statements that the server
+// writes before each query are not part of the query.
+func parseSlowQueryLog(content []byte) ([]SlowQuery, error) {
+	var queries []SlowQuery
+	var current *SlowQuery
+	var query []string
+	var logTime time.Time
+
+	flush := func() {
+		if current != nil {
+			current.Query = strings.TrimSpace(strings.Join(query, "\n"))
+			queries = append(queries, *current)
+		}
+		current = nil
+		query = nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, "# Time: "):
+			flush()
+
+			t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(strings.TrimPrefix(line, "# Time: ")))
+			if err != nil {
+				return nil, fmt.Errorf("invalid slow query log time %q: %w", line, err)
+			}
+			logTime = t
+
+		case strings.HasPrefix(line, "# User@Host: "):
+			flush()
+
+			current = &SlowQuery{Time: logTime}
+			m := slowLogUserHostPattern.FindStringSubmatch(line)
+			if m != nil {
+				current.User = m[1]
+				current.Host = m[2]
+				if current.Host == "" {
+					current.Host = m[3]
+				}
+				current.ConnectionID, _ = strconv.ParseInt(m[4], 10, 64)
+			}
+
+		case current == nil:
+			// Lines before the first entry, e.g. the header of the log.
+
+		case strings.HasPrefix(line, "# "):
+			err := parseSlowQueryStats(current, strings.TrimPrefix(line, "# "))
+			if err != nil {
+				return nil, err
+			}
+
+		case len(query) == 0 && strings.HasPrefix(line, "use ") && strings.HasSuffix(line, ";"):
+			current.Database = strings.Trim(strings.TrimSuffix(strings.TrimPrefix(line, "use "), ";"), "`")
+
+		case len(query) == 0 && strings.HasPrefix(line, "SET timestamp="):
+
+		case isSlowLogHeader(line):
+			flush()
+
+		default:
+			query = append(query, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	flush()
+
+	return queries, nil
+}
+
+// parseSlowQueryStats parses a line of statistics of a slow query log entry, e.g.
+// "Query_time: 0.000123  Lock_time: 0.000002 Rows_sent: 1  Rows_examined: 5". Unknown statistics are ignored.
+func parseSlowQueryStats(q *SlowQuery, line string) error {
+	fields := strings.Fields(line)
+	for n := 0; n+1 < len(fields); n += 2 {
+		name := strings.TrimSuffix(fields[n], ":")
+		value := fields[n+1]
+
+		var err error
+		switch name {
+		case "Query_time":
+			q.QueryTime, err = parseSlowLogSeconds(value)
+		case "Lock_time":
+			q.LockTime, err = parseSlowLogSeconds(value)
+		case "Rows_sent":
+			q.RowsSent, err = strconv.ParseInt(value, 10, 64)
+		case "Rows_examined":
+			q.RowsExamined, err = strconv.ParseInt(value, 10, 64)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid slow query log %s %q: %w", name, value, err)
+		}
+	}
+
+	return nil
+}
+
+// parseSlowLogSeconds parses a number of seconds with a fraction, e.g. "0.000123".
+func parseSlowLogSeconds(value string) (time.Duration, error) {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(seconds * float64(time.Second)).Round(time.Microsecond), nil
+}
+
+// isSlowLogHeader reports whether a line is part of the header the server writes to the slow query log when it
+// starts.
+func isSlowLogHeader(line string) bool {
+	for _, header := range slowLogHeaders {
+		if strings.HasPrefix(line, header) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,58 @@
+package mysqlbox
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSlowQueryLog(t *testing.T) {
+	content, err := os.ReadFile("testdata/slow-query.log")
+	require.NoError(t, err)
+
+	queries, err := parseSlowQueryLog(content)
+	require.NoError(t, err)
+	require.Equal(t, []SlowQuery{
+		{
+			Time:         time.Date(2023, 5, 1, 10, 0, 0, 123456000, time.UTC),
+			User:         "root",
+			Host:         "172.17.0.1",
+			ConnectionID: 8,
+			Database:     "testing",
+			Query:        "SELECT * FROM users WHERE email = 'a@example.com';",
+			QueryTime:    251 * time.Microsecond,
+			LockTime:     3 * time.Microsecond,
+			RowsSent:     1,
+			RowsExamined: 5,
+		},
+		{
+			Time:         time.Date(2023, 5, 1, 10, 0, 1, 1000, time.UTC),
+			User:         "app",
+			Host:         "localhost",
+			ConnectionID: 12,
+			Query:        "UPDATE categories\nSET name = 'Beta;'\nWHERE id = 'C-TEST2';",
+			QueryTime:    1500 * time.Millisecond,
+			LockTime:     10 * time.Microsecond,
+			RowsExamined: 100000,
+		},
+	}, queries)
+
+	queries, err = parseSlowQueryLog(nil)
+	require.NoError(t, err)
+	require.Empty(t, queries)
+
+	_, err = parseSlowQueryLog([]byte("# Time: yesterday\n"))
+	require.Error(t, err)
+}
+
+func TestSlowQueryLogArgs(t *testing.T) {
+	c := &SlowQueryLogConfig{LongQueryTime: 250 * time.Millisecond, QueriesNotUsingIndexes: true}
+	require.Equal(t, []string{
+		"--slow-query-log=1",
+		"--slow-query-log-file=/var/lib/mysql/slow-query.log",
+		"--long-query-time=0.250000",
+		"--log-queries-not-using-indexes=1",
+	}, c.slowQueryLogArgs())
+}
@@ -0,0 +1,305 @@
+package mysqlbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// TableDiff contains the rows of a table that changed between two snapshots.
+type TableDiff struct {
+	// Table is the name of the table.
+	Table string
+
+	// Inserted contains the rows that are only in the after snapshot.
+	Inserted []map[string]interface{}
+
+	// Updated contains the rows that are in both snapshots with different values.
+	Updated []RowUpdate
+
+	// Deleted contains the rows that are only in the before snapshot.
+	Deleted []map[string]interface{}
+}
+
+// String describes the number of changed rows of the table.
+func (d TableDiff) String() string {
+	return fmt.Sprintf("%s: %d inserted, %d updated, %d deleted", d.Table, len(d.Inserted), len(d.Updated),
+		len(d.Deleted))
+}
+
+// RowUpdate contains the values of an updated row in two snapshots.
+type RowUpdate struct {
+	// Before contains the values of the row in the before snapshot.
+	Before map[string]interface{}
+
+	// After contains the values of the row in the after snapshot.
+	After map[string]interface{}
+}
+
+// Snapshot copies the tables and rows of the Database into a snapshot with the given name. An existing snapshot with
+// the same name is replaced. Snapshots are stored as databases in the container named "<Database>_snapshot_<name>".
+func (b *MySQLBox) Snapshot(name string) error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	ctx := context.Background()
+	database := b.snapshotDatabase(name)
+	err := b.dropDatabase(ctx, database)
+	if err != nil {
+		return err
+	}
+
+	err = b.copyDatabase(ctx, b.databaseName, database)
+	if err != nil {
+		return fmt.Errorf("error creating snapshot %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// MustSnapshot copies the tables and rows of the Database into a snapshot with the given name.
+func (b *MySQLBox) MustSnapshot(name string) {
+	err := b.Snapshot(name)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// DiffSnapshot compares two snapshots taken with Snapshot() and returns the inserted, updated, and deleted rows of
+// each table that changed, ordered by table name. Rows are matched by primary key, so every table must have one.
+// Column values are returned as strings, or nil for NULL.
+func (b *MySQLBox) DiffSnapshot(before string, after string) ([]TableDiff, error) {
+	if b == nil {
+		return nil, errors.New("mysqlbox is nil")
+	}
+
+	ctx := context.Background()
+	beforeDB := b.snapshotDatabase(before)
+	afterDB := b.snapshotDatabase(after)
+
+	tables, err := snapshotTables(ctx, b.db, beforeDB, afterDB)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []TableDiff
+	for _, table := range tables {
+		diff, err := b.diffSnapshotTable(beforeDB, afterDB, table)
+		if err != nil {
+			return nil, fmt.Errorf("error comparing table %s: %w", table, err)
+		}
+
+		if len(diff.Inserted) > 0 || len(diff.Updated) > 0 || len(diff.Deleted) > 0 {
+			diffs = append(diffs, diff)
+		}
+	}
+
+	return diffs, nil
+}
+
+// MustDiffSnapshot compares two snapshots taken with Snapshot() and returns the rows that changed.
+func (b *MySQLBox) MustDiffSnapshot(before string, after string) []TableDiff {
+	diffs, err := b.DiffSnapshot(before, after)
+	if err != nil {
+		panic(err)
+	}
+
+	return diffs
+}
+
+// snapshotDatabase returns the name of the database of a snapshot.
+func (b *MySQLBox) snapshotDatabase(name string) string {
+	return fmt.Sprintf("%s_snapshot_%s", b.databaseName, name)
+}
+
+// snapshotTables returns the sorted names of the base tables in either of two snapshot databases.
+func snapshotTables(ctx context.Context, db *sql.DB, beforeDB string, afterDB string) ([]string, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	seen := map[string]bool{}
+	var tables []string
+	for _, database := range []string{beforeDB, afterDB} {
+		var exists int
+		err := conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM information_schema.schemata WHERE schema_name = ?",
+			database).Scan(&exists)
+		if err != nil {
+			return nil, err
+		}
+		if exists == 0 {
+			return nil, fmt.Errorf("snapshot database %s does not exist", database)
+		}
+
+		names, err := baseTables(ctx, conn, database)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				tables = append(tables, name)
+			}
+		}
+	}
+	sort.Strings(tables)
+
+	return tables, nil
+}
+
+// diffSnapshotTable compares the rows of a table in two snapshot databases.
+func (b *MySQLBox) diffSnapshotTable(beforeDB string, afterDB string, table string) (TableDiff, error) {
+	diff := TableDiff{Table: table}
+
+	pk, err := primaryKeyColumns(b.db, afterDB, table)
+	if err != nil {
+		return diff, err
+	}
+	if len(pk) == 0 {
+		pk, err = primaryKeyColumns(b.db, beforeDB, table)
+		if err != nil {
+			return diff, err
+		}
+	}
+	if len(pk) == 0 {
+		return diff, errors.New("table has no primary key")
+	}
+
+	beforeRows, decimals, err := snapshotRows(b.db, beforeDB, table)
+	if err != nil {
+		return diff, err
+	}
+
+	afterRows, afterDecimals, err := snapshotRows(b.db, afterDB, table)
+	if err != nil {
+		return diff, err
+	}
+
+	for column := range afterDecimals {
+		decimals[column] = true
+	}
+
+	return diffSnapshotRows(table, pk, decimals, beforeRows, afterRows)
+}
+
+// snapshotRows returns all rows of a table in a snapshot database and its DECIMAL columns. It returns no rows if the
+// table does not exist.
+func snapshotRows(db *sql.DB, database string, table string) ([]map[string]interface{}, map[string]bool, error) {
+	var exists int
+	err := db.QueryRow("SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = ? AND table_name = ?",
+		database, table).Scan(&exists)
+	if err != nil {
+		return nil, nil, err
+	}
+	if exists == 0 {
+		return nil, map[string]bool{}, nil
+	}
+
+	rows, err := db.Query(fmt.Sprintf("SELECT * FROM %s.%s", quoteIdent(database), quoteIdent(table)))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	decimals, err := decimalColumns(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var result []map[string]interface{}
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for n := range values {
+			ptrs[n] = &values[n]
+		}
+
+		err := rows.Scan(ptrs...)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		row := make(map[string]interface{}, len(columns))
+		for n, column := range columns {
+			if values[n].Valid {
+				row[column] = values[n].String
+			} else {
+				row[column] = nil
+			}
+		}
+		result = append(result, row)
+	}
+
+	return result, decimals, rows.Err()
+}
+
+// diffSnapshotRows compares the rows of a table in two snapshots by primary key. The values of the decimal columns
+// are compared as numbers.
+func diffSnapshotRows(table string, pk []string, decimals map[string]bool, before []map[string]interface{},
+	after []map[string]interface{}) (TableDiff, error) {
+	diff := TableDiff{Table: table}
+
+	beforeByKey := make(map[string]map[string]interface{}, len(before))
+	for _, row := range before {
+		key, err := rowKey(pk, decimals, row)
+		if err != nil {
+			return diff, err
+		}
+		beforeByKey[key] = row
+	}
+
+	afterKeys := make(map[string]bool, len(after))
+	for _, row := range after {
+		key, err := rowKey(pk, decimals, row)
+		if err != nil {
+			return diff, err
+		}
+		afterKeys[key] = true
+
+		old, ok := beforeByKey[key]
+		if !ok {
+			diff.Inserted = append(diff.Inserted, row)
+			continue
+		}
+
+		if !equalRows(old, row, decimals) {
+			diff.Updated = append(diff.Updated, RowUpdate{Before: old, After: row})
+		}
+	}
+
+	for _, row := range before {
+		key, _ := rowKey(pk, decimals, row)
+		if !afterKeys[key] {
+			diff.Deleted = append(diff.Deleted, row)
+		}
+	}
+
+	return diff, nil
+}
+
+// equalRows reports whether two rows have the same columns and values.
+func equalRows(a map[string]interface{}, b map[string]interface{}, decimals map[string]bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for column, value := range a {
+		other, ok := b[column]
+		if !ok || normalizeColumnValue(value, decimals[column]) != normalizeColumnValue(other, decimals[column]) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,45 @@
+package mysqlbox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffSnapshotRows(t *testing.T) {
+	before := []map[string]interface{}{
+		{"id": "1", "name": "one"},
+		{"id": "2", "name": "two"},
+		{"id": "3", "name": nil},
+	}
+	after := []map[string]interface{}{
+		{"id": "1", "name": "one"},
+		{"id": "3", "name": "three"},
+		{"id": "4", "name": "four"},
+	}
+
+	diff, err := diffSnapshotRows("items", []string{"id"}, nil, before, after)
+	require.NoError(t, err)
+	require.Equal(t, []map[string]interface{}{{"id": "4", "name": "four"}}, diff.Inserted)
+	require.Equal(t, []RowUpdate{{Before: before[2], After: after[1]}}, diff.Updated)
+	require.Equal(t, []map[string]interface{}{{"id": "2", "name": "two"}}, diff.Deleted)
+	require.Equal(t, "items: 1 inserted, 1 updated, 1 deleted", diff.String())
+
+	_, err = diffSnapshotRows("items", []string{"id"}, nil, []map[string]interface{}{{"name": "x"}}, nil)
+	require.Error(t, err)
+
+	t.Run("decimal", func(t *testing.T) {
+		before := []map[string]interface{}{{"id": "1", "price": "1.50", "code": "1.50"}}
+		after := []map[string]interface{}{{"id": "1", "price": "1.500", "code": "1.5"}}
+
+		// The price column was altered to a larger scale, and the code column is text.
+		diff, err := diffSnapshotRows("items", []string{"id"}, map[string]bool{"price": true}, before, after)
+		require.NoError(t, err)
+		require.Equal(t, []RowUpdate{{Before: before[0], After: after[0]}}, diff.Updated)
+
+		diff, err = diffSnapshotRows("items", []string{"id"}, map[string]bool{"price": true, "code": true}, before,
+			after)
+		require.NoError(t, err)
+		require.Empty(t, diff.Updated)
+	})
+}
@@ -0,0 +1,133 @@
+package mysqlbox
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	// containerSocketDir is the directory of the mysqld socket in the container.
+	containerSocketDir = "/var/run/mysqld"
+
+	// socketFileName is the file name of the mysqld socket.
+	socketFileName = "mysqld.sock"
+)
+
+// createSocketDir creates the host directory that is mounted on the socket directory of the container when
+// Config.UnixSocket is set. It returns the parent directory to remove when the box is stopped, and the mounted
+// directory. The entrypoint of the image may change the owner of the mounted directory to the mysql user, so the
+// mounted directory is writable by all users and is nested in a directory owned by the current user, which can
+// still be removed.
+func createSocketDir() (string, string, error) {
+	dir, err := os.MkdirTemp("", "mysqlbox-socket-")
+	if err != nil {
+		return "", "", err
+	}
+
+	socketDir := filepath.Join(dir, "mysqld")
+	err = os.Mkdir(socketDir, 0o777)
+	if err == nil {
+		// The permissions of Mkdir are masked by the umask.
+		err = os.Chmod(socketDir, 0o777)
+	}
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return "", "", err
+	}
+
+	return dir, socketDir, nil
+}
+
+// SocketPath returns the host path of the mysqld unix socket when Config.UnixSocket is set.
+func (b *MySQLBox) SocketPath() (string, error) {
+	if b == nil {
+		return "", errors.New("mysqlbox is nil")
+	}
+
+	if b.socketDir == "" {
+		return "", errors.New("unix socket is not enabled")
+	}
+
+	return filepath.Join(b.socketDir, "mysqld", socketFileName), nil
+}
+
+// MustSocketPath returns the host path of the mysqld unix socket when Config.UnixSocket is set.
+func (b *MySQLBox) MustSocketPath() string {
+	path, err := b.SocketPath()
+	if err != nil {
+		panic(err)
+	}
+
+	return path
+}
+
+// SocketDSN returns a DSN for connecting to the specified database as root through the mysqld unix socket when
+// Config.UnixSocket is set. Socket connections authenticate as 'root'@'localhost' and do not use TLS, since MySQL
+// treats the socket as a secure transport.
+func (b *MySQLBox) SocketDSN(database string) (string, error) {
+	mysqlCfg, err := b.socketMySQLConfig(database)
+	if err != nil {
+		return "", err
+	}
+
+	return mysqlCfg.FormatDSN(), nil
+}
+
+// MustSocketDSN returns a DSN for connecting to the specified database as root through the mysqld unix socket.
+func (b *MySQLBox) MustSocketDSN(database string) string {
+	dsn, err := b.SocketDSN(database)
+	if err != nil {
+		panic(err)
+	}
+
+	return dsn
+}
+
+// ConnectSocketDB returns a DB connection through the mysqld unix socket and the DSN for the specified database when
+// Config.UnixSocket is set. Like ConnectDB(), the connection is closed when the box is stopped.
+func (b *MySQLBox) ConnectSocketDB(database string) (*sql.DB, string, error) {
+	mysqlCfg, err := b.socketMySQLConfig(database)
+	if err != nil {
+		return nil, "", err
+	}
+
+	db, dsn, err := openDB(mysqlCfg, b.instrumentSQL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	b.derivedDBsMu.Lock()
+	b.derivedDBs = append(b.derivedDBs, db)
+	b.derivedDBsMu.Unlock()
+
+	return db, dsn, nil
+}
+
+// MustConnectSocketDB returns a DB connection through the mysqld unix socket and the DSN for the specified database.
+func (b *MySQLBox) MustConnectSocketDB(database string) (*sql.DB, string) {
+	db, dsn, err := b.ConnectSocketDB(database)
+	if err != nil {
+		panic(err)
+	}
+
+	return db, dsn
+}
+
+// socketMySQLConfig returns the MySQL driver config for connecting to the database as root through the mysqld unix
+// socket.
+func (b *MySQLBox) socketMySQLConfig(database string) (*mysql.Config, error) {
+	path, err := b.SocketPath()
+	if err != nil {
+		return nil, err
+	}
+
+	mysqlCfg := newMySQLConfig(b.host, b.port, database, b.rootPassword, "")
+	mysqlCfg.Net = "unix"
+	mysqlCfg.Addr = path
+
+	return mysqlCfg, nil
+}
@@ -0,0 +1,31 @@
+package mysqlbox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateSocketDir(t *testing.T) {
+	dir, socketDir, err := createSocketDir()
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.Equal(t, filepath.Join(dir, "mysqld"), socketDir)
+	info, err := os.Stat(socketDir)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o777), info.Mode().Perm())
+}
+
+func TestSocketDSN(t *testing.T) {
+	b := &MySQLBox{host: "127.0.0.1", port: 3306, rootPassword: "secret", socketDir: "/tmp/mysqlbox-socket-1"}
+
+	dsn, err := b.SocketDSN("testing")
+	require.NoError(t, err)
+	require.Equal(t, "root:secret@unix(/tmp/mysqlbox-socket-1/mysqld/mysqld.sock)/testing?parseTime=true", dsn)
+
+	_, err = (&MySQLBox{}).SocketDSN("testing")
+	require.EqualError(t, err, "unix socket is not enabled")
+}
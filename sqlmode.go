@@ -0,0 +1,48 @@
+package mysqlbox
+
+import (
+	"context"
+	"testing"
+)
+
+// WithSQLMode sets the global sql_mode of the MySQL server for the duration of the test, e.g. to check queries with
+// and without ONLY_FULL_GROUP_BY. Idle pooled connections are closed (see FlushPool()), so that the statements of
+// the test run on new sessions with the mode. The previous mode is restored when the test finishes. Tests that call
+// WithSQLMode on the same box must not run in parallel.
+func (b *MySQLBox) WithSQLMode(t testing.TB, mode string) {
+	t.Helper()
+
+	if b == nil {
+		t.Fatal("mysqlbox is nil")
+	}
+
+	ctx := context.Background()
+
+	var previous string
+	err := b.db.QueryRowContext(ctx, "SELECT @@GLOBAL.sql_mode").Scan(&previous)
+	if err != nil {
+		t.Fatalf("error reading sql_mode: %s", err.Error())
+	}
+
+	err = b.setSQLMode(ctx, mode)
+	if err != nil {
+		t.Fatalf("error setting sql_mode: %s", err.Error())
+	}
+
+	t.Cleanup(func() {
+		err := b.setSQLMode(ctx, previous)
+		if err != nil {
+			t.Errorf("error restoring sql_mode: %s", err.Error())
+		}
+	})
+}
+
+// setSQLMode sets the global sql_mode and flushes the connection pools.
+func (b *MySQLBox) setSQLMode(ctx context.Context, mode string) error {
+	_, err := b.db.ExecContext(ctx, "SET GLOBAL sql_mode = ?", mode)
+	if err != nil {
+		return err
+	}
+
+	return b.FlushPool()
+}
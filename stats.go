@@ -0,0 +1,155 @@
+package mysqlbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+)
+
+// Stats is a sample of the resource usage of the MySQL container.
+type Stats struct {
+	// Time is the time the sample was taken.
+	Time time.Time
+
+	// CPUPercent is the CPU usage since the previous sample, as a percentage of one CPU. It can exceed 100 on
+	// machines with multiple CPUs.
+	CPUPercent float64
+
+	// MemoryUsage is the memory used by the container in bytes, excluding the inactive page cache.
+	MemoryUsage uint64
+
+	// MemoryLimit is the memory limit of the container in bytes.
+	MemoryLimit uint64
+
+	// BlockRead is the number of bytes read from block devices.
+	BlockRead uint64
+
+	// BlockWrite is the number of bytes written to block devices.
+	BlockWrite uint64
+
+	// NetworkRx is the number of bytes received over the network.
+	NetworkRx uint64
+
+	// NetworkTx is the number of bytes sent over the network.
+	NetworkTx uint64
+}
+
+// Stats returns a sample of the CPU, memory, and I/O usage of the MySQL container. It takes about a second, since
+// the CPU usage is computed from two samples.
+func (b *MySQLBox) Stats(ctx context.Context) (Stats, error) {
+	if b == nil {
+		return Stats{}, errors.New("mysqlbox is nil")
+	}
+
+	resp, err := b.cli.ContainerStats(ctx, b.containerID, false)
+	if err != nil {
+		return Stats{}, err
+	}
+	defer resp.Body.Close()
+
+	var sample types.StatsJSON
+	err = json.NewDecoder(resp.Body).Decode(&sample)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	return newStats(&sample), nil
+}
+
+// MustStats returns a sample of the CPU, memory, and I/O usage of the MySQL container.
+func (b *MySQLBox) MustStats(ctx context.Context) Stats {
+	stats, err := b.Stats(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	return stats
+}
+
+// WatchStats calls fn with samples of the resource usage of the MySQL container, at most once per interval, until the
+// context is done. It blocks until then and returns nil, or an error if the samples cannot be read. Docker produces
+// samples about once per second, so shorter intervals have no effect.
+func (b *MySQLBox) WatchStats(ctx context.Context, interval time.Duration, fn func(Stats)) error {
+	if b == nil {
+		return errors.New("mysqlbox is nil")
+	}
+
+	resp, err := b.cli.ContainerStats(ctx, b.containerID, true)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	dec := json.NewDecoder(resp.Body)
+	var last time.Time
+	for {
+		var sample types.StatsJSON
+		err := dec.Decode(&sample)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if !last.IsZero() && sample.Read.Sub(last) < interval {
+			continue
+		}
+		last = sample.Read
+
+		fn(newStats(&sample))
+	}
+}
+
+// newStats returns the resource usage of a Docker stats sample. The memory usage is computed like the docker stats
+// command does.
+func newStats(sample *types.StatsJSON) Stats {
+	stats := Stats{
+		Time:        sample.Read,
+		MemoryLimit: sample.MemoryStats.Limit,
+	}
+
+	cpuDelta := float64(sample.CPUStats.CPUUsage.TotalUsage) - float64(sample.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(sample.CPUStats.SystemUsage) - float64(sample.PreCPUStats.SystemUsage)
+	cpus := float64(sample.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(sample.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		stats.CPUPercent = cpuDelta / systemDelta * cpus * 100
+	}
+
+	// The inactive page cache can be reclaimed, so it is not counted (cgroup v2 and v1 names).
+	inactive, ok := sample.MemoryStats.Stats["inactive_file"]
+	if !ok {
+		inactive = sample.MemoryStats.Stats["total_inactive_file"]
+	}
+	stats.MemoryUsage = sample.MemoryStats.Usage
+	if inactive < stats.MemoryUsage {
+		stats.MemoryUsage -= inactive
+	}
+
+	for _, entry := range sample.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			stats.BlockRead += entry.Value
+		case "write":
+			stats.BlockWrite += entry.Value
+		}
+	}
+
+	for _, network := range sample.Networks {
+		stats.NetworkRx += network.RxBytes
+		stats.NetworkTx += network.TxBytes
+	}
+
+	return stats
+}
@@ -0,0 +1,65 @@
+package mysqlbox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStats(t *testing.T) {
+	read := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	sample := &types.StatsJSON{
+		Stats: types.Stats{
+			Read: read,
+			CPUStats: types.CPUStats{
+				CPUUsage:    types.CPUUsage{TotalUsage: 3_000_000},
+				SystemUsage: 20_000_000,
+				OnlineCPUs:  4,
+			},
+			PreCPUStats: types.CPUStats{
+				CPUUsage:    types.CPUUsage{TotalUsage: 1_000_000},
+				SystemUsage: 10_000_000,
+			},
+			MemoryStats: types.MemoryStats{
+				Usage: 500 << 20,
+				Limit: 2 << 30,
+				Stats: map[string]uint64{"inactive_file": 100 << 20},
+			},
+			BlkioStats: types.BlkioStats{
+				IoServiceBytesRecursive: []types.BlkioStatEntry{
+					{Op: "read", Value: 1000},
+					{Op: "write", Value: 2000},
+					{Op: "Read", Value: 10},
+					{Op: "Total", Value: 3010},
+				},
+			},
+		},
+		Networks: map[string]types.NetworkStats{
+			"eth0": {RxBytes: 100, TxBytes: 200},
+			"eth1": {RxBytes: 1, TxBytes: 2},
+		},
+	}
+
+	stats := newStats(sample)
+	require.Equal(t, read, stats.Time)
+	require.InDelta(t, 80.0, stats.CPUPercent, 0.001)
+	require.Equal(t, uint64(400<<20), stats.MemoryUsage)
+	require.Equal(t, uint64(2<<30), stats.MemoryLimit)
+	require.Equal(t, uint64(1010), stats.BlockRead)
+	require.Equal(t, uint64(2000), stats.BlockWrite)
+	require.Equal(t, uint64(101), stats.NetworkRx)
+	require.Equal(t, uint64(202), stats.NetworkTx)
+
+	t.Run("cgroup v1", func(t *testing.T) {
+		sample.MemoryStats.Stats = map[string]uint64{"total_inactive_file": 50 << 20}
+		sample.CPUStats.OnlineCPUs = 0
+		sample.CPUStats.CPUUsage.PercpuUsage = []uint64{1, 1}
+
+		stats := newStats(sample)
+		require.Equal(t, uint64(450<<20), stats.MemoryUsage)
+		require.InDelta(t, 40.0, stats.CPUPercent, 0.001)
+	})
+}
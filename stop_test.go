@@ -0,0 +1,70 @@
+package mysqlbox
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/docker/docker/client"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDockerAPI is a Docker API server that records the requests and responds with the status codes of the handlers
+// whose path suffix matches the request path.
+func fakeDockerAPI(t *testing.T, statuses map[string]int) (*client.Client, func() []string) {
+	var mu sync.Mutex
+	var requests []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path[strings.Index(r.URL.Path, "/containers"):])
+		mu.Unlock()
+
+		for suffix, status := range statuses {
+			if strings.HasSuffix(r.URL.Path, suffix) {
+				w.WriteHeader(status)
+				_, _ = w.Write([]byte(`{"message":"fake error"}`))
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	cli, err := client.NewClientWithOpts(client.WithHost("tcp://"+strings.TrimPrefix(srv.URL, "http://")),
+		client.WithVersion("1.42"))
+	require.NoError(t, err)
+
+	return cli, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]string(nil), requests...)
+	}
+}
+
+func TestStopContainerKillFallback(t *testing.T) {
+	cli, requests := fakeDockerAPI(t, map[string]int{"/stop": http.StatusInternalServerError})
+	b := &MySQLBox{cli: cli, containerID: "abc", logger: DiscardLogger}
+
+	require.NoError(t, b.stopContainer())
+	require.Equal(t, []string{"POST /containers/abc/stop", "POST /containers/abc/kill"}, requests())
+}
+
+func TestStopContainerNotFound(t *testing.T) {
+	cli, requests := fakeDockerAPI(t, map[string]int{"/stop": http.StatusNotFound})
+	b := &MySQLBox{cli: cli, containerID: "abc", logger: DiscardLogger}
+
+	require.NoError(t, b.stopContainer())
+	require.Equal(t, []string{"POST /containers/abc/stop"}, requests())
+}
+
+func TestWaitContainerRemovedNotFound(t *testing.T) {
+	cli, _ := fakeDockerAPI(t, map[string]int{"/wait": http.StatusNotFound})
+	b := &MySQLBox{cli: cli, containerID: "abc", logger: DiscardLogger}
+
+	require.NoError(t, b.waitContainerRemoved())
+}
@@ -0,0 +1,199 @@
+package mysqlbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultSysbenchImage is the Docker image used by RunSysbench() when SysbenchOptions.Image is blank.
+const defaultSysbenchImage = "severalnines/sysbench"
+
+var (
+	sysbenchTransactionsRe = regexp.MustCompile(`transactions:\s+(\d+)\s+\(([\d.]+) per sec\.\)`)
+	sysbenchQueriesRe      = regexp.MustCompile(`queries:\s+(\d+)\s+\(([\d.]+) per sec\.\)`)
+	sysbenchLatencyRe      = regexp.MustCompile(`(min|avg|max|(\d+)th percentile):\s+([\d.]+)`)
+)
+
+// SysbenchOptions specifies the sysbench test run by RunSysbench().
+type SysbenchOptions struct {
+	// Image is the sysbench Docker image. If blank, it defaults to "severalnines/sysbench".
+	Image string
+
+	// Test is the sysbench test name. If blank, it defaults to "oltp_read_write".
+	Test string
+
+	// Tables is the number of test tables. The default is 1.
+	Tables int
+
+	// TableSize is the number of rows in each test table. The default is 10000.
+	TableSize int
+
+	// Threads is the number of client threads. The default is 1.
+	Threads int
+
+	// Duration is how long the test runs. The default is 10 seconds.
+	Duration time.Duration
+
+	// Percentile is the latency percentile to report. The default is 95.
+	Percentile int
+
+	// Args contains additional sysbench arguments.
+	Args []string
+}
+
+// SysbenchResult contains the statistics of a sysbench run.
+type SysbenchResult struct {
+	// Transactions is the number of transactions performed.
+	Transactions int64
+
+	// TPS is the number of transactions per second.
+	TPS float64
+
+	// Queries is the number of queries performed.
+	Queries int64
+
+	// QPS is the number of queries per second.
+	QPS float64
+
+	// LatencyMin is the minimum latency.
+	LatencyMin time.Duration
+
+	// LatencyAvg is the average latency.
+	LatencyAvg time.Duration
+
+	// LatencyMax is the maximum latency.
+	LatencyMax time.Duration
+
+	// LatencyPercentile is the latency at the percentile in SysbenchOptions.Percentile.
+	LatencyPercentile time.Duration
+
+	// Output is the output of the sysbench run command.
+	Output string
+}
+
+// RunSysbench runs a sysbench test against the Database in a sysbench container that shares the network of the MySQL
+// container. It prepares the test tables, runs the test, and drops the tables, and returns the statistics of the run.
+func (b *MySQLBox) RunSysbench(ctx context.Context, opts SysbenchOptions) (*SysbenchResult, error) {
+	if b == nil {
+		return nil, errors.New("mysqlbox is nil")
+	}
+
+	opts.loadDefaults()
+
+	args := []string{
+		"sysbench",
+		opts.Test,
+		"--db-driver=mysql",
+		"--mysql-host=127.0.0.1",
+		"--mysql-port=3306",
+		"--mysql-user=root",
+		fmt.Sprintf("--mysql-password=%s", b.rootPassword),
+		fmt.Sprintf("--mysql-db=%s", b.databaseName),
+		fmt.Sprintf("--tables=%d", opts.Tables),
+		fmt.Sprintf("--table-size=%d", opts.TableSize),
+		fmt.Sprintf("--threads=%d", opts.Threads),
+		fmt.Sprintf("--time=%d", int(opts.Duration.Seconds())),
+		fmt.Sprintf("--percentile=%d", opts.Percentile),
+	}
+	if b.tls != nil {
+		args = append(args, "--mysql-ssl=on")
+	}
+	args = append(args, opts.Args...)
+
+	var result *SysbenchResult
+	for _, command := range []string{"prepare", "run", "cleanup"} {
+		out, err := b.runSidecar(ctx, opts.Image, append(args, command))
+		if err != nil {
+			return nil, fmt.Errorf("sysbench %s failed: %w", command, err)
+		}
+
+		if out.ExitCode != 0 {
+			return nil, fmt.Errorf("sysbench %s exited with code %d: %s", command, out.ExitCode,
+				strings.TrimSpace(out.Stderr+out.Stdout))
+		}
+
+		if command == "run" {
+			result, err = parseSysbenchOutput(out.Stdout)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// loadDefaults sets the blank options to their default values.
+func (o *SysbenchOptions) loadDefaults() {
+	if o.Image == "" {
+		o.Image = defaultSysbenchImage
+	}
+
+	if o.Test == "" {
+		o.Test = "oltp_read_write"
+	}
+
+	if o.Tables == 0 {
+		o.Tables = 1
+	}
+
+	if o.TableSize == 0 {
+		o.TableSize = 10000
+	}
+
+	if o.Threads == 0 {
+		o.Threads = 1
+	}
+
+	if o.Duration == 0 {
+		o.Duration = 10 * time.Second
+	}
+
+	if o.Percentile == 0 {
+		o.Percentile = 95
+	}
+}
+
+// parseSysbenchOutput parses the statistics in the output of the sysbench run command.
+func parseSysbenchOutput(output string) (*SysbenchResult, error) {
+	result := &SysbenchResult{Output: output}
+
+	match := sysbenchTransactionsRe.FindStringSubmatch(output)
+	if match == nil {
+		return nil, errors.New("sysbench output does not contain transaction statistics")
+	}
+	result.Transactions, _ = strconv.ParseInt(match[1], 10, 64)
+	result.TPS, _ = strconv.ParseFloat(match[2], 64)
+
+	match = sysbenchQueriesRe.FindStringSubmatch(output)
+	if match != nil {
+		result.Queries, _ = strconv.ParseInt(match[1], 10, 64)
+		result.QPS, _ = strconv.ParseFloat(match[2], 64)
+	}
+
+	for _, match := range sysbenchLatencyRe.FindAllStringSubmatch(output, -1) {
+		ms, err := strconv.ParseFloat(match[3], 64)
+		if err != nil {
+			continue
+		}
+		latency := time.Duration(ms * float64(time.Millisecond))
+
+		switch {
+		case match[1] == "min":
+			result.LatencyMin = latency
+		case match[1] == "avg":
+			result.LatencyAvg = latency
+		case match[1] == "max":
+			result.LatencyMax = latency
+		case match[2] != "":
+			result.LatencyPercentile = latency
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,28 @@
+package mysqlbox
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSysbenchOutput(t *testing.T) {
+	output, err := os.ReadFile("./testdata/sysbench-run.txt")
+	require.NoError(t, err)
+
+	result, err := parseSysbenchOutput(string(output))
+	require.NoError(t, err)
+	require.Equal(t, int64(1006), result.Transactions)
+	require.Equal(t, 100.55, result.TPS)
+	require.Equal(t, int64(20120), result.Queries)
+	require.Equal(t, 2011.02, result.QPS)
+	require.Equal(t, 7120*time.Microsecond, result.LatencyMin)
+	require.Equal(t, 9940*time.Microsecond, result.LatencyAvg)
+	require.Equal(t, 30510*time.Microsecond, result.LatencyMax)
+	require.Equal(t, 13460*time.Microsecond, result.LatencyPercentile)
+
+	_, err = parseSysbenchOutput("FATAL: error")
+	require.Error(t, err)
+}
@@ -0,0 +1,11 @@
+CREATE TABLE counters (id INT PRIMARY KEY, hits INT NOT NULL);
+
+DELIMITER //
+CREATE PROCEDURE add_counter(IN counter_id INT)
+BEGIN
+  INSERT INTO counters (id, hits) VALUES (counter_id, 0);
+END //
+DELIMITER ;
+
+CALL add_counter(1);
+CALL add_counter(2);
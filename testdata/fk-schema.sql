@@ -0,0 +1,25 @@
+CREATE TABLE authors
+(
+    id   int          NOT NULL AUTO_INCREMENT,
+    name varchar(128) NOT NULL,
+    PRIMARY KEY (id)
+) ENGINE = InnoDB
+DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE books
+(
+    id        int          NOT NULL AUTO_INCREMENT,
+    author_id int          NOT NULL,
+    title     varchar(128) NOT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT books_author_fk FOREIGN KEY (author_id) REFERENCES authors (id)
+) ENGINE = InnoDB
+DEFAULT CHARSET = utf8mb4;
+
+INSERT INTO authors (id, name)
+VALUES (1, 'Ursula K. Le Guin'),
+       (2, 'Octavia E. Butler');
+
+INSERT INTO books (author_id, title)
+VALUES (1, 'The Dispossessed'),
+       (2, 'Kindred');
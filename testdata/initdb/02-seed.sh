@@ -0,0 +1,6 @@
+#!/bin/bash
+# Sourced by the MySQL image entrypoint, which provides docker_process_sql.
+docker_process_sql <<-'EOSQL'
+	INSERT INTO users (id, email, created_at, updated_at)
+	VALUES ('U-SCRIPT1', 'script1@example.com', NOW(), NOW());
+EOSQL
@@ -0,0 +1,16 @@
+CREATE TABLE accounts
+(
+    id   int          NOT NULL,
+    name varchar(128) NOT NULL,
+    PRIMARY KEY (id)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE notes
+(
+    account_id int  NOT NULL,
+    body       text NOT NULL,
+    KEY notes_body_index (body(64)),
+    CONSTRAINT notes_account_fk FOREIGN KEY (account_id) REFERENCES accounts (id)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb3;
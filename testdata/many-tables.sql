@@ -0,0 +1,21 @@
+CREATE TABLE t01 (id int NOT NULL PRIMARY KEY);
+CREATE TABLE t02 (id int NOT NULL PRIMARY KEY);
+CREATE TABLE t03 (id int NOT NULL PRIMARY KEY);
+CREATE TABLE t04 (id int NOT NULL PRIMARY KEY);
+CREATE TABLE t05 (id int NOT NULL PRIMARY KEY);
+CREATE TABLE t06 (id int NOT NULL PRIMARY KEY);
+CREATE TABLE t07 (id int NOT NULL PRIMARY KEY);
+CREATE TABLE t08 (id int NOT NULL PRIMARY KEY);
+CREATE TABLE t09 (id int NOT NULL PRIMARY KEY);
+CREATE TABLE t10 (id int NOT NULL PRIMARY KEY);
+
+INSERT INTO t01 VALUES (1);
+INSERT INTO t02 VALUES (1);
+INSERT INTO t03 VALUES (1);
+INSERT INTO t04 VALUES (1);
+INSERT INTO t05 VALUES (1);
+INSERT INTO t06 VALUES (1);
+INSERT INTO t07 VALUES (1);
+INSERT INTO t08 VALUES (1);
+INSERT INTO t09 VALUES (1);
+INSERT INTO t10 VALUES (1);
@@ -0,0 +1,2 @@
+INSERT INTO users (id, email, created_at, updated_at)
+VALUES ('U-SEED1', 'seed1@example.com', '2021-01-01 00:00:00', '2021-01-01 00:00:00');
@@ -0,0 +1,202 @@
+package mysqlbox
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// containerCertsDir is the directory in the container where the TLS certificates are mounted.
+const containerCertsDir = "/etc/mysql/mysqlbox-certs"
+
+// tlsCerts contains a generated CA, server, and client certificate set.
+type tlsCerts struct {
+	// dir is the host directory containing the CA certificate and the server certificate and key PEM files.
+	dir string
+
+	// clientConfig is the TLS config for clients of the server. It trusts the CA and presents the client certificate.
+	clientConfig *tls.Config
+
+	// configName is the name the client config is registered with in the MySQL driver.
+	configName string
+}
+
+// TLSConfig returns a TLS config for connecting to the MySQL service when Config.EnableTLS is set. It verifies the
+// server certificate against the generated CA and presents a client certificate signed by the same CA.
+func (b *MySQLBox) TLSConfig() (*tls.Config, error) {
+	if b == nil {
+		return nil, errors.New("mysqlbox is nil")
+	}
+
+	if b.tls == nil {
+		return nil, errors.New("TLS is not enabled")
+	}
+
+	return b.tls.clientConfig.Clone(), nil
+}
+
+// MustTLSConfig returns a TLS config for connecting to the MySQL service when Config.EnableTLS is set.
+func (b *MySQLBox) MustTLSConfig() *tls.Config {
+	cfg, err := b.TLSConfig()
+	if err != nil {
+		panic(err)
+	}
+
+	return cfg
+}
+
+// tlsConfigName returns the name of the registered TLS config of the box, or a blank string if TLS is not enabled.
+func (b *MySQLBox) tlsConfigName() string {
+	if b.tls == nil {
+		return ""
+	}
+
+	return b.tls.configName
+}
+
+// generateTLSCerts generates a CA, a server certificate for 127.0.0.1, localhost, and the additional IP addresses,
+// and a client certificate. The CA certificate and the server certificate and key are written to a temporary directory
+// that can be mounted in the container, and the client TLS config is registered in the MySQL driver.
+func generateTLSCerts(ips ...net.IP) (*tlsCerts, error) {
+	notBefore := time.Now().Add(-time.Hour)
+	notAfter := notBefore.Add(24 * time.Hour)
+
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "mysqlbox CA"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return nil, err
+	}
+
+	serverCertPEM, serverKeyPEM, err := signCertificate(caCert, caKey, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "mysqlbox server"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  append([]net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")}, ips...),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	clientCertPEM, clientKeyPEM, err := signCertificate(caCert, caKey, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "mysqlbox client"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	clientCert, err := tls.X509KeyPair(clientCertPEM, clientKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	caPool := x509.NewCertPool()
+	caPool.AddCert(caCert)
+
+	dir, err := os.MkdirTemp("", "mysqlbox-tls-")
+	if err != nil {
+		return nil, err
+	}
+
+	// The directory and files must be readable by the mysql user in the container.
+	err = os.Chmod(dir, 0755)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+
+	files := map[string][]byte{
+		"ca.pem":          pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}),
+		"server-cert.pem": serverCertPEM,
+		"server-key.pem":  serverKeyPEM,
+	}
+	for name, content := range files {
+		err := os.WriteFile(filepath.Join(dir, name), content, 0644) // #nosec G306
+		if err != nil {
+			_ = os.RemoveAll(dir)
+			return nil, err
+		}
+	}
+
+	certs := &tlsCerts{
+		dir: dir,
+		clientConfig: &tls.Config{
+			RootCAs:      caPool,
+			Certificates: []tls.Certificate{clientCert},
+			MinVersion:   tls.VersionTLS12,
+		},
+		configName: "mysqlbox-" + randStr(12),
+	}
+
+	err = mysql.RegisterTLSConfig(certs.configName, certs.clientConfig)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+
+	return certs, nil
+}
+
+// signCertificate creates a key and a certificate from the template signed by the CA. It returns the certificate
+// and the key in PEM format.
+func signCertificate(caCert *x509.Certificate, caKey *rsa.PrivateKey, template *x509.Certificate) ([]byte, []byte,
+	error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	return certPEM, keyPEM, nil
+}
+
+// cleanup removes the certificate files and deregisters the client TLS config.
+func (c *tlsCerts) cleanup() {
+	_ = os.RemoveAll(c.dir)
+	mysql.DeregisterTLSConfig(c.configName)
+}
@@ -0,0 +1,35 @@
+package mysqlbox
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateTLSCerts(t *testing.T) {
+	certs, err := generateTLSCerts()
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(certs.dir, "server-cert.pem"))
+	require.NoError(t, err)
+
+	block, _ := pem.Decode(content)
+	require.NotNil(t, block)
+
+	serverCert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+
+	_, err = serverCert.Verify(x509.VerifyOptions{
+		DNSName: "127.0.0.1",
+		Roots:   certs.clientConfig.RootCAs,
+	})
+	require.NoError(t, err)
+	require.Len(t, certs.clientConfig.Certificates, 1)
+
+	certs.cleanup()
+	require.NoDirExists(t, certs.dir)
+}
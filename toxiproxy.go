@@ -0,0 +1,245 @@
+package mysqlbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+)
+
+const defaultToxiproxyImage = "ghcr.io/shopify/toxiproxy:2.5.0"
+
+const (
+	// toxiproxyAPIPort is the container port of the Toxiproxy HTTP API.
+	toxiproxyAPIPort = "8474/tcp"
+
+	// toxiproxyListenPort is the container port of the proxied MySQL server.
+	toxiproxyListenPort = "8666/tcp"
+
+	// toxiproxyName is the name of the MySQL proxy in Toxiproxy.
+	toxiproxyName = "mysql"
+)
+
+// Proxy is a Toxiproxy proxy in front of the MySQL server, enabled with Config.Toxiproxy. Connections made through
+// the proxy address are subject to the toxics added to the proxy, e.g. latency or connection resets.
+type Proxy struct {
+	containerID string
+	apiURL      string
+	port        int
+	box         *MySQLBox
+	client      *http.Client
+}
+
+// toxic is a Toxiproxy toxic.
+type toxic struct {
+	Name       string                 `json:"name"`
+	Type       string                 `json:"type"`
+	Stream     string                 `json:"stream"`
+	Toxicity   float64                `json:"toxicity"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// Proxy returns the Toxiproxy proxy in front of the MySQL server when Config.Toxiproxy is set.
+func (b *MySQLBox) Proxy() (*Proxy, error) {
+	if b == nil {
+		return nil, errors.New("mysqlbox is nil")
+	}
+
+	if b.proxy == nil {
+		return nil, errors.New("toxiproxy is not enabled")
+	}
+
+	return b.proxy, nil
+}
+
+// MustProxy returns the Toxiproxy proxy in front of the MySQL server when Config.Toxiproxy is set.
+func (b *MySQLBox) MustProxy() *Proxy {
+	p, err := b.Proxy()
+	if err != nil {
+		panic(err)
+	}
+
+	return p
+}
+
+// Addr returns the host address of the proxied MySQL server.
+func (p *Proxy) Addr() string {
+	return net.JoinHostPort(p.box.host, strconv.Itoa(p.port))
+}
+
+// DSN returns a DSN for connecting to the database as root through the proxy.
+func (p *Proxy) DSN(database string) string {
+	mysqlCfg := newMySQLConfig(p.box.host, p.port, database, p.box.rootPassword, p.box.tlsConfigName())
+	return mysqlCfg.FormatDSN()
+}
+
+// AddLatency adds a toxic that delays the data sent by the server by latency, plus or minus up to jitter. It
+// returns the name of the toxic.
+func (p *Proxy) AddLatency(latency time.Duration, jitter time.Duration) (string, error) {
+	return p.addToxic("latency", map[string]interface{}{
+		"latency": latency.Milliseconds(),
+		"jitter":  jitter.Milliseconds(),
+	})
+}
+
+// AddBandwidth adds a toxic that limits the data sent by the server to rate KB per second. It returns the name of
+// the toxic.
+func (p *Proxy) AddBandwidth(rate int64) (string, error) {
+	return p.addToxic("bandwidth", map[string]interface{}{
+		"rate": rate,
+	})
+}
+
+// AddResetPeer adds a toxic that resets the connections after timeout. A zero timeout resets them immediately. It
+// returns the name of the toxic.
+func (p *Proxy) AddResetPeer(timeout time.Duration) (string, error) {
+	return p.addToxic("reset_peer", map[string]interface{}{
+		"timeout": timeout.Milliseconds(),
+	})
+}
+
+// RemoveToxic removes a toxic from the proxy.
+func (p *Proxy) RemoveToxic(name string) error {
+	return p.do(http.MethodDelete, "/proxies/"+toxiproxyName+"/toxics/"+name, nil, nil)
+}
+
+// Reset removes all toxics and enables the proxy.
+func (p *Proxy) Reset() error {
+	return p.do(http.MethodPost, "/reset", nil, nil)
+}
+
+// SetEnabled enables or disables the proxy. A disabled proxy closes its connections and refuses new ones.
+func (p *Proxy) SetEnabled(enabled bool) error {
+	return p.do(http.MethodPost, "/proxies/"+toxiproxyName, map[string]interface{}{"enabled": enabled}, nil)
+}
+
+// addToxic adds a downstream toxic of a type to the proxy.
+func (p *Proxy) addToxic(toxicType string, attributes map[string]interface{}) (string, error) {
+	t := toxic{
+		Name:       toxicType + "_" + randStr(8),
+		Type:       toxicType,
+		Stream:     "downstream",
+		Toxicity:   1,
+		Attributes: attributes,
+	}
+
+	err := p.do(http.MethodPost, "/proxies/"+toxiproxyName+"/toxics", t, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return t.Name, nil
+}
+
+// do sends a request to the Toxiproxy API. The body and the response are JSON encoded.
+func (p *Proxy) do(method string, path string, body interface{}, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, p.apiURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("toxiproxy %s %s failed: %s: %s", method, path, resp.Status, bytes.TrimSpace(msg))
+	}
+
+	if result != nil {
+		return json.NewDecoder(resp.Body).Decode(result)
+	}
+
+	return nil
+}
+
+// startToxiproxy starts a Toxiproxy container that shares the network namespace of the MySQL container and creates
+// the MySQL proxy. The API and the proxy listen on ports that are published by the MySQL container.
+func (b *MySQLBox) startToxiproxy(ctx context.Context, image string, timeout time.Duration) (*Proxy, error) {
+	if image == "" {
+		image = defaultToxiproxyImage
+	}
+
+	apiPort, err := containerHostPort(ctx, b.cli, b.containerID, toxiproxyAPIPort)
+	if err != nil {
+		return nil, err
+	}
+
+	port, err := containerHostPort(ctx, b.cli, b.containerID, toxiproxyListenPort)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := b.createSidecar(ctx, &container.Config{Image: image, Cmd: []string{"-host", "0.0.0.0"}}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Proxy{
+		containerID: id,
+		apiURL:      "http://" + net.JoinHostPort(b.host, strconv.Itoa(apiPort)),
+		port:        port,
+		box:         b,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+
+	err = b.retry.startContainer(ctx, b.cli, id)
+	if err != nil {
+		p.stop()
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err = p.do(http.MethodGet, "/version", nil, nil)
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			p.stop()
+			return nil, phaseTimeout("toxiproxy start", timeout)
+		}
+		time.Sleep(waitBetweenPings)
+	}
+
+	err = p.do(http.MethodPost, "/proxies", map[string]interface{}{
+		"name":     toxiproxyName,
+		"listen":   "0.0.0.0:" + nat.Port(toxiproxyListenPort).Port(),
+		"upstream": "127.0.0.1:3306",
+		"enabled":  true,
+	}, nil)
+	if err != nil {
+		p.stop()
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// stop removes the Toxiproxy container.
+func (p *Proxy) stop() {
+	_ = p.box.cli.ContainerRemove(context.Background(), p.containerID, types.ContainerRemoveOptions{Force: true})
+}
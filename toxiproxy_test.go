@@ -0,0 +1,76 @@
+package mysqlbox
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyToxics(t *testing.T) {
+	type request struct {
+		method string
+		path   string
+		body   map[string]interface{}
+	}
+
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := request{method: r.Method, path: r.URL.Path}
+		if r.ContentLength > 0 {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req.body))
+		}
+		requests = append(requests, req)
+
+		if r.URL.Path == "/proxies/mysql/toxics/missing" {
+			http.Error(w, `{"error":"toxic not found"}`, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p := &Proxy{apiURL: server.URL, port: 8666, box: &MySQLBox{host: "127.0.0.1"}, client: server.Client()}
+
+	name, err := p.AddLatency(200*time.Millisecond, 50*time.Millisecond)
+	require.NoError(t, err)
+	require.Contains(t, name, "latency_")
+
+	_, err = p.AddBandwidth(64)
+	require.NoError(t, err)
+
+	_, err = p.AddResetPeer(0)
+	require.NoError(t, err)
+
+	require.NoError(t, p.RemoveToxic(name))
+	require.NoError(t, p.SetEnabled(false))
+	require.NoError(t, p.Reset())
+
+	err = p.RemoveToxic("missing")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "toxic not found")
+
+	require.Len(t, requests, 7)
+
+	require.Equal(t, http.MethodPost, requests[0].method)
+	require.Equal(t, "/proxies/mysql/toxics", requests[0].path)
+	require.Equal(t, "latency", requests[0].body["type"])
+	require.Equal(t, "downstream", requests[0].body["stream"])
+	require.Equal(t, map[string]interface{}{"latency": float64(200), "jitter": float64(50)},
+		requests[0].body["attributes"])
+
+	require.Equal(t, map[string]interface{}{"rate": float64(64)}, requests[1].body["attributes"])
+	require.Equal(t, "reset_peer", requests[2].body["type"])
+
+	require.Equal(t, http.MethodDelete, requests[3].method)
+	require.Equal(t, "/proxies/mysql/toxics/"+name, requests[3].path)
+
+	require.Equal(t, "/proxies/mysql", requests[4].path)
+	require.Equal(t, false, requests[4].body["enabled"])
+
+	require.Equal(t, "/reset", requests[5].path)
+
+	require.Equal(t, "127.0.0.1:8666", p.Addr())
+	require.Equal(t, "root@tcp(127.0.0.1:8666)/app?parseTime=true", p.DSN("app"))
+}
@@ -0,0 +1,74 @@
+package mysqlbox
+
+import (
+	"fmt"
+)
+
+const (
+	// minBufferPoolSize and maxBufferPoolSize are the limits of the InnoDB buffer pool size set by the auto tuning.
+	// Test datasets are small, so a larger buffer pool only costs memory and startup time.
+	minBufferPoolSize = 128 << 20
+	maxBufferPoolSize = 1 << 30
+
+	// bufferPoolChunkSize is the default innodb_buffer_pool_chunk_size. The buffer pool size is a multiple of it.
+	bufferPoolChunkSize = 128 << 20
+
+	// maxTunedThreads is the maximum number of InnoDB I/O and purge threads set by the auto tuning, which is the
+	// default of MySQL.
+	maxTunedThreads = 4
+)
+
+// unsafeFastArgs contains the mysqld options of Config.UnsafeFast. InnoDB writes the redo log once per second
+// without flushing it on commit, pages are written without the doublewrite buffer, and the binary log is disabled.
+var unsafeFastArgs = []string{
+	"--innodb-flush-log-at-trx-commit=0",
+	"--innodb-doublewrite=0",
+	"--skip-log-bin",
+	"--sync-binlog=0",
+}
+
+// autoTuningArgs returns the mysqld options for test workloads with the memory available to the container in bytes
+// and the number of CPUs. The durability settings do not depend on the resources: the redo log is flushed once per
+// second instead of on every commit, the binary log is not synced, and client hostnames are not resolved. If memory
+// or cpus is zero, e.g. because the Docker daemon did not report it, the related options are left at their defaults.
+func autoTuningArgs(memory int64, cpus int) []string {
+	args := []string{
+		"--innodb-flush-log-at-trx-commit=2",
+		"--sync-binlog=0",
+		"--skip-name-resolve",
+	}
+
+	if memory > 0 {
+		size := memory / 8
+		if size > maxBufferPoolSize {
+			size = maxBufferPoolSize
+		}
+		size -= size % bufferPoolChunkSize
+		if size < minBufferPoolSize {
+			size = minBufferPoolSize
+		}
+
+		args = append(args,
+			fmt.Sprintf("--innodb-buffer-pool-size=%d", size),
+			"--innodb-buffer-pool-instances=1",
+		)
+	}
+
+	if cpus > 0 {
+		threads := cpus / 2
+		if threads < 1 {
+			threads = 1
+		}
+		if threads > maxTunedThreads {
+			threads = maxTunedThreads
+		}
+
+		args = append(args,
+			fmt.Sprintf("--innodb-read-io-threads=%d", threads),
+			fmt.Sprintf("--innodb-write-io-threads=%d", threads),
+			fmt.Sprintf("--innodb-purge-threads=%d", threads),
+		)
+	}
+
+	return args
+}
@@ -0,0 +1,50 @@
+package mysqlbox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoTuningArgs(t *testing.T) {
+	durability := []string{
+		"--innodb-flush-log-at-trx-commit=2",
+		"--sync-binlog=0",
+		"--skip-name-resolve",
+	}
+
+	t.Run("unknown resources", func(t *testing.T) {
+		require.Equal(t, durability, autoTuningArgs(0, 0))
+	})
+
+	t.Run("small host", func(t *testing.T) {
+		require.Equal(t, append(durability,
+			"--innodb-buffer-pool-size=134217728",
+			"--innodb-buffer-pool-instances=1",
+			"--innodb-read-io-threads=1",
+			"--innodb-write-io-threads=1",
+			"--innodb-purge-threads=1",
+		), autoTuningArgs(512<<20, 1))
+	})
+
+	t.Run("medium host", func(t *testing.T) {
+		// 3000 MiB / 8 is rounded down to a multiple of the 128 MiB chunk size.
+		require.Equal(t, append(durability,
+			"--innodb-buffer-pool-size=268435456",
+			"--innodb-buffer-pool-instances=1",
+			"--innodb-read-io-threads=3",
+			"--innodb-write-io-threads=3",
+			"--innodb-purge-threads=3",
+		), autoTuningArgs(3000<<20, 6))
+	})
+
+	t.Run("large host", func(t *testing.T) {
+		require.Equal(t, append(durability,
+			"--innodb-buffer-pool-size=1073741824",
+			"--innodb-buffer-pool-instances=1",
+			"--innodb-read-io-threads=4",
+			"--innodb-write-io-threads=4",
+			"--innodb-purge-threads=4",
+		), autoTuningArgs(64<<30, 32))
+	})
+}
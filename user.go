@@ -0,0 +1,51 @@
+package mysqlbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CreateUser creates a MySQL account that can connect from any host and applies the grants to it. Each grant is the
+// privileges and object part of a GRANT statement, e.g. "SELECT, INSERT ON testing.*". It returns a DSN for
+// connecting to the Database as the new user.
+func (b *MySQLBox) CreateUser(name string, password string, grants ...string) (string, error) {
+	if b == nil {
+		return "", errors.New("mysqlbox is nil")
+	}
+
+	ctx := context.Background()
+	account := fmt.Sprintf("%s@'%%'", quoteString(name))
+
+	query := fmt.Sprintf("CREATE USER %s IDENTIFIED BY %s", account, quoteString(password))
+	_, err := b.db.ExecContext(ctx, query)
+	if err != nil {
+		return "", fmt.Errorf("error creating user %s: %w", name, err)
+	}
+
+	for _, grant := range grants {
+		_, err := b.db.ExecContext(ctx, fmt.Sprintf("GRANT %s TO %s", grant, account))
+		if err != nil {
+			return "", fmt.Errorf("error granting %s to user %s: %w", grant, name, err)
+		}
+	}
+
+	return b.DSNFor(name, password, b.databaseName, nil)
+}
+
+// MustCreateUser creates a MySQL account and applies the grants to it. It returns a DSN for the new user.
+func (b *MySQLBox) MustCreateUser(name string, password string, grants ...string) string {
+	dsn, err := b.CreateUser(name, password, grants...)
+	if err != nil {
+		panic(err)
+	}
+
+	return dsn
+}
+
+// quoteString quotes a MySQL string literal with single quotes.
+func quoteString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
@@ -0,0 +1,140 @@
+package mysqlbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// VariableChange is a server variable whose value differs from its expected value.
+type VariableChange struct {
+	// Name is the name of the variable.
+	Name string
+
+	// Expected is the value in the expected variables file.
+	Expected string
+
+	// Current is the value of the variable in the MySQL server.
+	Current string
+}
+
+// String describes the variable change.
+func (c VariableChange) String() string {
+	return fmt.Sprintf("variable %s is %q, expected %q", c.Name, c.Current, c.Expected)
+}
+
+// EffectiveVariables returns the values of global server variables by name. If no names are given, all global
+// variables are returned. Names are case-insensitive and returned in lowercase. It returns an error if a variable
+// does not exist.
+func (b *MySQLBox) EffectiveVariables(names ...string) (map[string]string, error) {
+	if b == nil {
+		return nil, errors.New("mysqlbox is nil")
+	}
+
+	rows, err := b.db.QueryContext(context.Background(), "SHOW GLOBAL VARIABLES")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	all := map[string]string{}
+	for rows.Next() {
+		var name, value string
+		err := rows.Scan(&name, &value)
+		if err != nil {
+			return nil, err
+		}
+		all[strings.ToLower(name)] = value
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(names) == 0 {
+		return all, nil
+	}
+
+	result := make(map[string]string, len(names))
+	for _, name := range names {
+		name = strings.ToLower(name)
+		value, ok := all[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown variable %s", name)
+		}
+		result[name] = value
+	}
+
+	return result, nil
+}
+
+// MustEffectiveVariables returns the values of global server variables by name.
+func (b *MySQLBox) MustEffectiveVariables(names ...string) map[string]string {
+	vars, err := b.EffectiveVariables(names...)
+	if err != nil {
+		panic(err)
+	}
+
+	return vars
+}
+
+// CompareVariables compares the global server variables with the expected values in a JSON file that maps variable
+// names to values, e.g. {"innodb_flush_log_at_trx_commit": "1", "sql_mode": "..."}. It returns the variables whose
+// values changed, ordered by name. This detects new image releases that change defaults the application relies on.
+func (b *MySQLBox) CompareVariables(path string) ([]VariableChange, error) {
+	if b == nil {
+		return nil, errors.New("mysqlbox is nil")
+	}
+
+	content, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		return nil, err
+	}
+
+	expected := map[string]string{}
+	err = json.Unmarshal(content, &expected)
+	if err != nil {
+		return nil, fmt.Errorf("error reading expected variables %s: %w", path, err)
+	}
+
+	names := make([]string, 0, len(expected))
+	for name := range expected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	current, err := b.EffectiveVariables(names...)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []VariableChange
+	for _, name := range names {
+		value := current[strings.ToLower(name)]
+		if value != expected[name] {
+			changes = append(changes, VariableChange{Name: name, Expected: expected[name], Current: value})
+		}
+	}
+
+	return changes, nil
+}
+
+// CheckVariables compares the global server variables with the expected values in a JSON file and fails the test for
+// each variable whose value changed. See CompareVariables().
+func (b *MySQLBox) CheckVariables(t testing.TB, path string) {
+	t.Helper()
+
+	changes, err := b.CompareVariables(path)
+	if err != nil {
+		t.Fatalf("error comparing variables: %s", err.Error())
+	}
+
+	for _, change := range changes {
+		t.Error(change.String())
+	}
+}
@@ -0,0 +1,163 @@
+package mysqlbox
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// WaitStrategy decides when the MySQL server of a box is ready, see Config.WaitFor.
+type WaitStrategy interface {
+	// WaitUntilReady blocks until the server of the box is ready or the context is done. The context is done when
+	// Config.ReadyTimeout is reached or the container exits.
+	WaitUntilReady(ctx context.Context, b *MySQLBox) error
+}
+
+// WaitStrategyFunc is a function that implements WaitStrategy.
+type WaitStrategyFunc func(ctx context.Context, b *MySQLBox) error
+
+// WaitUntilReady calls f.
+func (f WaitStrategyFunc) WaitUntilReady(ctx context.Context, b *MySQLBox) error {
+	return f(ctx, b)
+}
+
+// WaitForPing waits until a ping from the host through the DB of the box succeeds.
+func WaitForPing() WaitStrategy {
+	return WaitStrategyFunc(func(ctx context.Context, b *MySQLBox) error {
+		return pingUntilReady(ctx, b.db)
+	})
+}
+
+// WaitForHealthcheck waits until the Docker health status of the container is healthy. The healthcheck of the
+// container runs mysqladmin ping. It does not wait if the healthcheck cannot run in the container, e.g. because the
+// image does not have mysqladmin.
+func WaitForHealthcheck() WaitStrategy {
+	return WaitStrategyFunc(func(ctx context.Context, b *MySQLBox) error {
+		return b.waitHealthy(ctx, nil)
+	})
+}
+
+// WaitForPort waits until a TCP connection to the MySQL port of the box can be opened from the host.
+func WaitForPort() WaitStrategy {
+	return WaitStrategyFunc(func(ctx context.Context, b *MySQLBox) error {
+		var dialer net.Dialer
+		return Poller{}.Poll(ctx, func(ctx context.Context) error {
+			conn, err := dialer.DialContext(ctx, "tcp", b.DBAddr())
+			if err != nil {
+				return err
+			}
+
+			return conn.Close()
+		})
+	})
+}
+
+// WaitForSQL waits until a query run through the DB of the box returns a row whose first column is not NULL, zero,
+// or blank, e.g. "SELECT COUNT(*) FROM migrations". Errors of the query are retried.
+func WaitForSQL(query string, args ...interface{}) WaitStrategy {
+	return WaitStrategyFunc(func(ctx context.Context, b *MySQLBox) error {
+		return Poller{}.Poll(ctx, func(ctx context.Context) error {
+			var value sql.NullString
+			err := b.db.QueryRowContext(ctx, query, args...).Scan(&value)
+			if err != nil {
+				return err
+			}
+
+			if !value.Valid || value.String == "" || value.String == "0" {
+				return fmt.Errorf("query returned %q", value.String)
+			}
+
+			return nil
+		})
+	})
+}
+
+// WaitForLog waits until a line of the container logs matches the regular expression for the number of times. The
+// MySQL image entrypoint runs a temporary server for the initial scripts before the final server, and both print
+// "ready for connections". Only the final server listens on port 3306, so its line can be matched with:
+//
+//	mysqlbox.WaitForLog(`ready for connections.*port: 3306`, 1)
+func WaitForLog(pattern string, occurrences int) WaitStrategy {
+	re, err := regexp.Compile(pattern)
+
+	return WaitStrategyFunc(func(ctx context.Context, b *MySQLBox) error {
+		if err != nil {
+			return fmt.Errorf("invalid log pattern: %w", err)
+		}
+
+		return b.waitForLog(ctx, re, occurrences)
+	})
+}
+
+// WaitForAll waits for the strategies one after another.
+func WaitForAll(strategies ...WaitStrategy) WaitStrategy {
+	return WaitStrategyFunc(func(ctx context.Context, b *MySQLBox) error {
+		for _, strategy := range strategies {
+			err := strategy.WaitUntilReady(ctx, b)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// defaultWaitStrategy waits for the container healthcheck, which does not depend on the host port being routable,
+// and then for a ping from the host, which usually succeeds immediately afterwards.
+func defaultWaitStrategy() WaitStrategy {
+	return WaitForAll(WaitForHealthcheck(), WaitForPing())
+}
+
+// waitForLog follows the container logs from the start until the number of lines match the regular expression.
+func (b *MySQLBox) waitForLog(ctx context.Context, re *regexp.Regexp, occurrences int) error {
+	logs, err := b.cli.ContainerLogs(ctx, b.containerID, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+	})
+	if err != nil {
+		return err
+	}
+	defer logs.Close()
+
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := stdcopy.StdCopy(pw, pw, logs)
+		_ = pw.CloseWithError(err)
+	}()
+
+	// Closing the logs stops the copy when the context is done.
+	go func() {
+		<-ctx.Done()
+		_ = logs.Close()
+	}()
+
+	matches := 0
+	scanner := bufio.NewScanner(pr)
+	for scanner.Scan() {
+		if re.MatchString(scanner.Text()) {
+			matches++
+			if matches >= occurrences {
+				return nil
+			}
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if scanner.Err() != nil {
+		return scanner.Err()
+	}
+
+	return errors.New("container logs ended before the wait pattern matched")
+}
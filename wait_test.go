@@ -0,0 +1,67 @@
+package mysqlbox
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitForPort(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	b := &MySQLBox{host: "127.0.0.1", port: ln.Addr().(*net.TCPAddr).Port}
+	require.NoError(t, WaitForPort().WaitUntilReady(context.Background(), b))
+}
+
+func TestWaitForAll(t *testing.T) {
+	var calls []string
+	step := func(name string, err error) WaitStrategy {
+		return WaitStrategyFunc(func(context.Context, *MySQLBox) error {
+			calls = append(calls, name)
+			return err
+		})
+	}
+
+	err := WaitForAll(step("first", nil), step("second", errors.New("not ready")), step("third", nil)).
+		WaitUntilReady(context.Background(), &MySQLBox{})
+	require.EqualError(t, err, "not ready")
+	require.Equal(t, []string{"first", "second"}, calls)
+}
+
+func TestWaitForLogInvalidPattern(t *testing.T) {
+	err := WaitForLog("ready (", 1).WaitUntilReady(context.Background(), &MySQLBox{})
+	require.ErrorContains(t, err, "invalid log pattern")
+}
+
+func TestWaitForDBStrategy(t *testing.T) {
+	blocking := WaitStrategyFunc(func(ctx context.Context, b *MySQLBox) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	t.Run("ready", func(t *testing.T) {
+		b := &MySQLBox{waitFor: WaitStrategyFunc(func(context.Context, *MySQLBox) error {
+			return nil
+		})}
+		require.NoError(t, b.waitForDB(time.Second, nil))
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		b := &MySQLBox{waitFor: blocking}
+		require.ErrorIs(t, b.waitForDB(100*time.Millisecond, nil), ErrTimeout)
+	})
+
+	t.Run("container_closed", func(t *testing.T) {
+		closed := make(chan bool, 1)
+		closed <- true
+
+		b := &MySQLBox{waitFor: blocking}
+		require.EqualError(t, b.waitForDB(10*time.Second, closed), "container closed")
+	})
+}
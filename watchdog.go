@@ -0,0 +1,132 @@
+package mysqlbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+)
+
+// KilledQuery is a query that was killed by the query watchdog (see Config.QueryWatchdog), or a connection that was
+// killed because it held a metadata lock on a table that was cleaned (see Config.KillLockHolders).
+type KilledQuery struct {
+	// ID is the connection ID of the query.
+	ID int64
+
+	// User is the MySQL user of the connection.
+	User string
+
+	// Database is the default database of the connection.
+	Database string
+
+	// Query is the statement that was killed. It is blank for an idle connection killed for holding a lock.
+	Query string
+
+	// LockedTable is the table the connection held a metadata lock on when it was killed for Config.KillLockHolders.
+	// It is blank for queries killed by the query watchdog.
+	LockedTable string
+
+	// Duration is how long the query had been running when it was killed.
+	Duration time.Duration
+
+	// KilledAt is the time the query was killed.
+	KilledAt time.Time
+}
+
+// String describes the killed query.
+func (q KilledQuery) String() string {
+	if q.LockedTable != "" {
+		return fmt.Sprintf("killed connection %d holding a metadata lock on %s for %s: %s", q.ID, q.LockedTable,
+			q.Duration, q.Query)
+	}
+
+	return fmt.Sprintf("killed query of connection %d after %s: %s", q.ID, q.Duration, q.Query)
+}
+
+// KilledQueries returns the queries killed by the query watchdog and the connections killed for holding metadata
+// locks, in the order they were killed.
+func (b *MySQLBox) KilledQueries() []KilledQuery {
+	if b == nil {
+		return nil
+	}
+
+	b.killedQueriesMu.Lock()
+	defer b.killedQueriesMu.Unlock()
+
+	return append([]KilledQuery(nil), b.killedQueries...)
+}
+
+// startQueryWatchdog starts a goroutine that kills the queries that run longer than the threshold, until the box is
+// stopped. MySQL reports the running time of queries in whole seconds, so the threshold is rounded up to seconds.
+func (b *MySQLBox) startQueryWatchdog(threshold time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	b.stopQueryWatchdog = cancel
+
+	interval := threshold / 2
+	if interval > time.Second {
+		interval = time.Second
+	}
+	if interval < 100*time.Millisecond {
+		interval = 100 * time.Millisecond
+	}
+
+	seconds := int64(math.Ceil(threshold.Seconds()))
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				b.killLongQueries(ctx, seconds)
+			}
+		}
+	}()
+}
+
+// killLongQueries kills the queries that have been running for at least the number of seconds. Errors are ignored,
+// since the query may complete before it is killed, and the box may be stopping.
+func (b *MySQLBox) killLongQueries(ctx context.Context, seconds int64) {
+	rows, err := b.db.QueryContext(ctx, `SELECT id, user, db, info, time FROM information_schema.processlist
+		WHERE command IN ('Query', 'Execute') AND time >= ? AND id <> CONNECTION_ID() AND info IS NOT NULL`, seconds)
+	if err != nil {
+		return
+	}
+
+	var queries []KilledQuery
+	for rows.Next() {
+		var q KilledQuery
+		var database sql.NullString
+		var running int64
+		err := rows.Scan(&q.ID, &q.User, &database, &q.Query, &running)
+		if err != nil {
+			break
+		}
+		q.Database = database.String
+		q.Duration = time.Duration(running) * time.Second
+		queries = append(queries, q)
+	}
+	_ = rows.Close()
+
+	for _, q := range queries {
+		_, err := b.db.ExecContext(ctx, fmt.Sprintf("KILL QUERY %d", q.ID))
+		if err != nil {
+			continue
+		}
+
+		q.KilledAt = time.Now()
+		b.addKilledQuery(q)
+	}
+}
+
+// addKilledQuery adds a killed query to the queries returned by KilledQueries().
+func (b *MySQLBox) addKilledQuery(q KilledQuery) {
+	b.killedQueriesMu.Lock()
+	defer b.killedQueriesMu.Unlock()
+
+	b.killedQueries = append(b.killedQueries, q)
+}